@@ -0,0 +1,370 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The decoder is the counterpart of abi_encode.go, the decoded values have the
+// same types as the encoded ones and are formatted in the argument syntax of
+// parseABIValue, so that they can be used in macros again.
+
+var (
+	errInvalidABIData     = errors.New("invalid abi data")
+	errSelectorMismatch   = errors.New("function selector mismatch")
+	errUnknownABISelector = errors.New("unknown function selector")
+	errTopicMismatch      = errors.New("event topic mismatch")
+	errUnknownABITopic    = errors.New("unknown event topic")
+)
+
+// abiEvent is a parsed event signature.
+type abiEvent struct {
+	Name      string
+	Inputs    []*abiType
+	Names     []string
+	Indexed   []bool
+	Anonymous bool
+}
+
+// parseABIEvent parses the human-readable event signature, e.g.
+// "event Transfer(address indexed from, address indexed to, uint256 value)".
+func parseABIEvent(sig string) (*abiEvent, error) {
+	sig = strings.TrimSpace(sig)
+	if strings.HasPrefix(sig, "event ") {
+		sig = strings.TrimSpace(sig[len("event "):])
+	}
+	idx := strings.Index(sig, "(")
+	end := closingParen(sig, idx)
+	if idx <= 0 || end < 0 {
+		return nil, fmt.Errorf("%v %s", errInvalidABISignature, sig)
+	}
+	ev := &abiEvent{Name: strings.TrimSpace(sig[:idx])}
+	switch strings.TrimSpace(sig[end+1:]) {
+	case "":
+	case "anonymous":
+		ev.Anonymous = true
+	default:
+		return nil, fmt.Errorf("%v %s", errInvalidABISignature, sig)
+	}
+	fields, err := splitABIList(sig[idx+1 : end])
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		types, names, err := parseABIParams(field)
+		if err != nil {
+			return nil, err
+		}
+		// The keywords follow the type, whose brackets may contain names
+		indexed := false
+		for _, word := range strings.Fields(field[strings.LastIndexAny(field, ")]")+1:]) {
+			if word == "indexed" {
+				indexed = true
+			}
+		}
+		ev.Inputs = append(ev.Inputs, types[0])
+		ev.Names = append(ev.Names, names[0])
+		ev.Indexed = append(ev.Indexed, indexed)
+	}
+	return ev, nil
+}
+
+// Sig returns the canonical signature of event.
+func (ev *abiEvent) Sig() string {
+	return (&abiFunction{Name: ev.Name, Inputs: ev.Inputs}).Sig()
+}
+
+// HumanReadable returns the human-readable signature with the parameter names
+// and indexed keywords.
+func (ev *abiEvent) HumanReadable() string {
+	signature := "event " + ev.Name + humanReadableParams(ev.Inputs, ev.Names, ev.Indexed)
+	if ev.Anonymous {
+		signature += " anonymous"
+	}
+	return signature
+}
+
+// Topic returns the keccak256 hash of signature, which is the first topic of
+// non-anonymous event logs.
+func (ev *abiEvent) Topic() common.Hash {
+	return crypto.Keccak256Hash([]byte(ev.Sig()))
+}
+
+// UnpackLog decodes the event log. The indexed values of dynamic types are
+// stored as their keccak256 hashes, so they're returned as bytes32 with the
+// replaced types.
+func (ev *abiEvent) UnpackLog(topics []common.Hash, data []byte) ([]*abiType, []interface{}, error) {
+	if !ev.Anonymous {
+		if len(topics) == 0 || topics[0] != ev.Topic() {
+			return nil, nil, errTopicMismatch
+		}
+		topics = topics[1:]
+	}
+	var (
+		types    = make([]*abiType, len(ev.Inputs))
+		values   = make([]interface{}, len(ev.Inputs))
+		nonIndex []*abiType
+	)
+	for idx, typ := range ev.Inputs {
+		types[idx] = typ
+		if !ev.Indexed[idx] {
+			nonIndex = append(nonIndex, typ)
+			continue
+		}
+		if len(topics) == 0 {
+			return nil, nil, fmt.Errorf("%v, %s has more indexed arguments than the topics", errTopicMismatch, ev.Sig())
+		}
+		if typ.dynamic() || typ.kind == abiArray || typ.kind == abiTuple {
+			types[idx] = &abiType{kind: abiFixedBytes, size: 32}
+		}
+		value, err := decodeABIValue(types[idx], topics[0].Bytes())
+		if err != nil {
+			return nil, nil, err
+		}
+		values[idx], topics = value, topics[1:]
+	}
+	if len(topics) != 0 {
+		return nil, nil, fmt.Errorf("%v, %s has less indexed arguments than the topics", errTopicMismatch, ev.Sig())
+	}
+	decoded, err := decodeABISequence(nonIndex, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	for idx := range values {
+		if !ev.Indexed[idx] {
+			values[idx], decoded = decoded[0], decoded[1:]
+		}
+	}
+	return types, values, nil
+}
+
+// Unpack decodes the arguments of calldata, which starts with the selector.
+func (fn *abiFunction) Unpack(calldata []byte) ([]interface{}, error) {
+	if len(calldata) < 4 || !bytes.Equal(calldata[:4], fn.Selector()) {
+		return nil, errSelectorMismatch
+	}
+	return decodeABISequence(fn.Inputs, calldata[4:])
+}
+
+// UnpackOutput decodes the return values of function.
+func (fn *abiFunction) UnpackOutput(output []byte) ([]interface{}, error) {
+	return decodeABISequence(fn.Outputs, output)
+}
+
+// decodeCalldata decodes the calldata with the first function whose selector
+// matches and arguments can be decoded.
+func decodeCalldata(functions []*abiFunction, calldata []byte) (*abiFunction, []interface{}, error) {
+	if len(calldata) < 4 {
+		return nil, nil, fmt.Errorf("%v, calldata 0x%x is shorter than selector", errInvalidABIData, calldata)
+	}
+	err := fmt.Errorf("%v 0x%x", errUnknownABISelector, calldata[:4])
+	for _, fn := range functions {
+		if !bytes.Equal(fn.Selector(), calldata[:4]) {
+			continue
+		}
+		var values []interface{}
+		if values, err = fn.Unpack(calldata); err == nil {
+			return fn, values, nil
+		}
+	}
+	return nil, nil, err
+}
+
+// decodeABISequence decodes the data as a tuple of given types.
+func decodeABISequence(types []*abiType, data []byte) ([]interface{}, error) {
+	var (
+		values = make([]interface{}, len(types))
+		pos    int
+	)
+	for idx, typ := range types {
+		var (
+			value  interface{}
+			offset int
+			err    error
+		)
+		if typ.dynamic() {
+			if offset, err = readABILength(data, pos); err != nil {
+				return nil, err
+			}
+			value, err = decodeABIValue(typ, data[offset:])
+		} else {
+			if pos > len(data) {
+				return nil, fmt.Errorf("%v, %s at offset %d is out of data", errInvalidABIData, typ, pos)
+			}
+			value, err = decodeABIValue(typ, data[pos:])
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[idx] = value
+		pos += typ.headSize()
+	}
+	return values, nil
+}
+
+// decodeABIValue decodes a single value at the beginning of data.
+func decodeABIValue(typ *abiType, data []byte) (interface{}, error) {
+	switch typ.kind {
+	case abiUint, abiInt, abiAddress, abiBool, abiFixedBytes:
+		word, err := readABIWord(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		switch typ.kind {
+		case abiUint:
+			return new(big.Int).SetBytes(word), nil
+		case abiInt:
+			v := new(big.Int).SetBytes(word)
+			if word[0]&0x80 != 0 {
+				// Two's complement representation
+				v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 256))
+			}
+			return v, nil
+		case abiAddress:
+			return common.BytesToAddress(word[12:]), nil
+		case abiBool:
+			return word[31] != 0, nil
+		default:
+			return common.CopyBytes(word[:typ.size]), nil
+		}
+	case abiBytes, abiString:
+		length, err := readABILength(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		if 32+length > len(data) {
+			return nil, fmt.Errorf("%v, %s of length %d is out of data", errInvalidABIData, typ, length)
+		}
+		if typ.kind == abiString {
+			return string(data[32 : 32+length]), nil
+		}
+		return common.CopyBytes(data[32 : 32+length]), nil
+	case abiSlice:
+		length, err := readABILength(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		// Every element takes one word at least, which bounds the allocation
+		if length > (len(data)-32)/32 {
+			return nil, fmt.Errorf("%v, %s of length %d is out of data", errInvalidABIData, typ, length)
+		}
+		types := make([]*abiType, length)
+		for idx := range types {
+			types[idx] = typ.elem
+		}
+		return decodeABISequence(types, data[32:])
+	case abiArray:
+		if typ.size > len(data)/32 {
+			return nil, fmt.Errorf("%v, %s is out of data", errInvalidABIData, typ)
+		}
+		types := make([]*abiType, typ.size)
+		for idx := range types {
+			types[idx] = typ.elem
+		}
+		return decodeABISequence(types, data)
+	case abiTuple:
+		return decodeABISequence(typ.components, data)
+	}
+	return nil, errInvalidABIType
+}
+
+// readABIWord returns the word at given position.
+func readABIWord(data []byte, pos int) ([]byte, error) {
+	if pos < 0 || pos+32 > len(data) {
+		return nil, fmt.Errorf("%v, word at offset %d is out of data", errInvalidABIData, pos)
+	}
+	return data[pos : pos+32], nil
+}
+
+// readABILength reads the word at given position as an offset or length, which
+// must be within the data.
+func readABILength(data []byte, pos int) (int, error) {
+	word, err := readABIWord(data, pos)
+	if err != nil {
+		return 0, err
+	}
+	v := new(big.Int).SetBytes(word)
+	if !v.IsInt64() || v.Int64() > int64(len(data)) {
+		return 0, fmt.Errorf("%v, offset or length %v at offset %d is out of data", errInvalidABIData, v, pos)
+	}
+	return int(v.Int64()), nil
+}
+
+// formatABIValue formats the decoded value in the argument syntax.
+func formatABIValue(typ *abiType, value interface{}) string {
+	switch typ.kind {
+	case abiUint, abiInt:
+		return value.(*big.Int).String()
+	case abiAddress:
+		return value.(common.Address).Hex()
+	case abiBool:
+		return strconv.FormatBool(value.(bool))
+	case abiFixedBytes, abiBytes:
+		return "0x" + common.Bytes2Hex(value.([]byte))
+	case abiString:
+		return strconv.Quote(value.(string))
+	}
+	var (
+		values = value.([]interface{})
+		fields = make([]string, len(values))
+	)
+	for idx, elem := range values {
+		elemType := typ.elem
+		if typ.kind == abiTuple {
+			elemType = typ.components[idx]
+		}
+		fields[idx] = formatABIValue(elemType, elem)
+	}
+	if typ.kind == abiTuple {
+		return "(" + strings.Join(fields, ",") + ")"
+	}
+	return "[" + strings.Join(fields, ",") + "]"
+}
+
+// jsonABIValue converts the decoded value to the json representation, the
+// integers are kept as decimal strings for the precision.
+func jsonABIValue(typ *abiType, value interface{}) interface{} {
+	switch typ.kind {
+	case abiUint, abiInt:
+		return value.(*big.Int).String()
+	case abiAddress:
+		return value.(common.Address).Hex()
+	case abiBool, abiString:
+		return value
+	case abiFixedBytes, abiBytes:
+		return "0x" + common.Bytes2Hex(value.([]byte))
+	}
+	var (
+		values = value.([]interface{})
+		elems  = make([]interface{}, len(values))
+	)
+	for idx, elem := range values {
+		elemType := typ.elem
+		if typ.kind == abiTuple {
+			elemType = typ.components[idx]
+		}
+		elems[idx] = jsonABIValue(elemType, elem)
+	}
+	return elems
+}
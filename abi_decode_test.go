@@ -0,0 +1,150 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestABIDecode(t *testing.T) {
+	var tests = []struct {
+		signature string
+		args      []string
+		expect    []string
+	}{
+		{"baz(uint32,bool)", []string{"69", "true"}, []string{"69", "true"}},
+		{"sam(bytes,bool,uint256[])", []string{"0x64617665", "true", "[1,2,3]"}, []string{"0x64617665", "true", "[1,2,3]"}},
+		{"f(uint,uint32[],bytes10,bytes)", []string{"0x123", "[0x456, 0x789]", "0x31323334353637383930", "0x48656c6c6f"},
+			[]string{"291", "[1110,1929]", "0x31323334353637383930", "0x48656c6c6f"}},
+		{"g((uint256 amount, bytes data) order, bool)", []string{"(5, 0xabcd)", "true"}, []string{"(5,0xabcd)", "true"}},
+		{"h((address,uint8)[2],string,int256)", []string{"[(0x8f0909ccb296ebd319834edb0d5785794b781d7f,1),(0x8f0909ccb296ebd319834edb0d5785794b781d7f,2)]", `"a, \"b\""`, "-1"},
+			[]string{"[(0x8f0909CCB296EBd319834EDB0d5785794B781d7f,1),(0x8f0909CCB296EBd319834EDB0d5785794B781d7f,2)]", `"a, \"b\""`, "-1"}},
+		{"transfer(address,uint256)", []string{"0x8f0909ccb296ebd319834edb0d5785794b781d7f", "1.5ether"},
+			[]string{"0x8f0909CCB296EBd319834EDB0d5785794B781d7f", "1500000000000000000"}},
+	}
+	for _, test := range tests {
+		fn, err := parseABISignature(test.signature)
+		if err != nil {
+			t.Fatalf("%s: failed to parse signature: %v", test.signature, err)
+		}
+		encoded, err := fn.PackString(test.args)
+		if err != nil {
+			t.Fatalf("%s: failed to encode: %v", test.signature, err)
+		}
+		values, err := fn.Unpack(encoded)
+		if err != nil {
+			t.Fatalf("%s: failed to decode: %v", test.signature, err)
+		}
+		formatted := make([]string, len(values))
+		for idx, value := range values {
+			formatted[idx] = formatABIValue(fn.Inputs[idx], value)
+		}
+		if strings.Join(formatted, " ") != strings.Join(test.expect, " ") {
+			t.Errorf("%s: decoded arguments mismatch, want %q, got %q", test.signature, test.expect, formatted)
+		}
+		// The formatted arguments must be encoded to the same data again
+		again, err := fn.PackString(formatted)
+		if err != nil {
+			t.Fatalf("%s: failed to encode the decoded arguments: %v", test.signature, err)
+		}
+		if !bytes.Equal(again, encoded) {
+			t.Errorf("%s: round trip mismatch, want %x, got %x", test.signature, encoded, again)
+		}
+	}
+}
+
+func TestABIDecodeErrors(t *testing.T) {
+	fn, err := parseABISignature("f(uint256,bytes)")
+	if err != nil {
+		t.Fatalf("failed to parse signature: %v", err)
+	}
+	selector := common.Bytes2Hex(fn.Selector())
+	var tests = []struct {
+		calldata string
+		err      error
+	}{
+		{"", errSelectorMismatch},
+		{"a9059cbb" + abiTestWord("1") + abiTestWord("40") + abiTestWord("0"), errSelectorMismatch},
+		{selector + abiTestWord("1"), errInvalidABIData},
+		{selector + abiTestWord("1") + abiTestWord("1000"), errInvalidABIData},
+		{selector + abiTestWord("1") + abiTestWord("40") + abiTestWord("ffff"), errInvalidABIData},
+		{selector + abiTestWord("1") + abiTestWord("40") + strings.Repeat("f", 64), errInvalidABIData},
+	}
+	for _, test := range tests {
+		_, err := fn.Unpack(common.FromHex(test.calldata))
+		if err == nil || !strings.HasPrefix(err.Error(), test.err.Error()) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.calldata, test.err, err)
+		}
+	}
+	// The slice length can't exceed the data
+	slice, err := parseABISignature("g(uint256[])")
+	if err != nil {
+		t.Fatalf("failed to parse signature: %v", err)
+	}
+	calldata := append(slice.Selector(), common.FromHex(abiTestWord("20")+abiTestWord("10"))...)
+	if _, err := slice.Unpack(calldata); err == nil || !strings.HasPrefix(err.Error(), errInvalidABIData.Error()) {
+		t.Errorf("slice out of data: error mismatch, want %v, got %v", errInvalidABIData, err)
+	}
+}
+
+func TestParseABIEvent(t *testing.T) {
+	var tests = []struct {
+		signature string
+		sig       string
+		names     string
+		indexed   string
+		anonymous bool
+		err       error
+	}{
+		{"event Transfer(address indexed from, address indexed to, uint256 value)", "Transfer(address,address,uint256)", "from,to,value", "true,true,false", false, nil},
+		{"Filled((uint256 id, bytes data) indexed order, uint256[] amounts) anonymous", "Filled((uint256,bytes),uint256[])", "order,amounts", "true,false", true, nil},
+		{"event Log(string)", "Log(string)", "", "false", false, nil},
+		{"event Log(string) indexed", "", "", "", false, errInvalidABISignature},
+		{"event Log(string", "", "", "", false, errInvalidABISignature},
+	}
+	for _, test := range tests {
+		ev, err := parseABIEvent(test.signature)
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.signature, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		var indexed []string
+		for _, flag := range ev.Indexed {
+			indexed = append(indexed, strconv.FormatBool(flag))
+		}
+		if ev.Sig() != test.sig || strings.Join(ev.Names, ",") != test.names || strings.Join(indexed, ",") != test.indexed || ev.Anonymous != test.anonymous {
+			t.Errorf("%s: event mismatch, got %s %q %q %v", test.signature, ev.Sig(), ev.Names, indexed, ev.Anonymous)
+		}
+	}
+	// The json abi events have the same representation
+	def, err := parseABIDefinition([]byte(`[{"type":"event","name":"Transfer","anonymous":false,"inputs":[
+		{"indexed":true,"name":"from","type":"address"},
+		{"indexed":true,"name":"to","type":"address"},
+		{"indexed":false,"name":"value","type":"uint256"}
+	]}]`))
+	if err != nil || len(def.Events) != 1 || def.Events[0].Topic() != crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")) ||
+		strings.Join(def.Events[0].Names, ",") != "from,to,value" || def.Events[0].Indexed[2] {
+		t.Errorf("json event mismatch, got %+v, %v", def, err)
+	}
+}
@@ -0,0 +1,1012 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The abi package of go-ethereum doesn't support tuple types, so the function
+// signature driven encoder is implemented here. It follows the contract ABI
+// specification, all types including nested arrays and tuples are supported.
+// The arguments are parsed from the macro syntax such as "1.5ether", which the
+// abi package can't take either. The same encoding is shared by macros, call,
+// encode and decode, and it's tested against the abi package for the types
+// supported there.
+
+var (
+	errInvalidABIType      = errors.New("invalid abi type")
+	errInvalidABISignature = errors.New("invalid function signature")
+	errABIArgumentNumber   = errors.New("abi argument number mismatch")
+	errUnknownABIMethod    = errors.New("unknown abi method")
+	errAmbiguousABIMethod  = errors.New("ambiguous abi method")
+)
+
+// Kinds of abi types.
+const (
+	abiUint = iota
+	abiInt
+	abiAddress
+	abiBool
+	abiFixedBytes
+	abiBytes
+	abiString
+	abiSlice
+	abiArray
+	abiTuple
+)
+
+// abiType is a parsed abi type.
+type abiType struct {
+	kind       int
+	size       int        // Bit size for integers, byte size for fixed bytes, length for fixed arrays
+	elem       *abiType   // Element type for arrays
+	components []*abiType // Component types for tuples
+}
+
+// parseABIType parses the abi type, e.g. "uint256", "bytes32[]" or "(address,uint256)[2]".
+// The parameter name and data location following the type are ignored.
+func parseABIType(typ string) (*abiType, error) {
+	typ = strings.TrimSpace(typ)
+	depth := 0
+	for idx, c := range typ {
+		if c == '(' || c == '[' {
+			depth += 1
+		} else if c == ')' || c == ']' {
+			depth -= 1
+		} else if (c == ' ' || c == '\t') && depth == 0 {
+			typ = typ[:idx]
+			break
+		}
+	}
+	// Array types, the last brackets wrap the outermost dimension
+	if strings.HasSuffix(typ, "]") {
+		idx := strings.LastIndex(typ, "[")
+		if idx <= 0 {
+			return nil, fmt.Errorf("%v %s", errInvalidABIType, typ)
+		}
+		elem, err := parseABIType(typ[:idx])
+		if err != nil {
+			return nil, err
+		}
+		length := typ[idx+1 : len(typ)-1]
+		if length == "" {
+			return &abiType{kind: abiSlice, elem: elem}, nil
+		}
+		n, err := strconv.Atoi(length)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%v %s", errInvalidABIType, typ)
+		}
+		return &abiType{kind: abiArray, size: n, elem: elem}, nil
+	}
+	// Tuple types
+	if strings.HasPrefix(typ, "(") {
+		if !strings.HasSuffix(typ, ")") {
+			return nil, fmt.Errorf("%v %s", errInvalidABIType, typ)
+		}
+		fields, err := splitABIList(typ[1 : len(typ)-1])
+		if err != nil {
+			return nil, err
+		}
+		tuple := &abiType{kind: abiTuple}
+		for _, field := range fields {
+			component, err := parseABIType(field)
+			if err != nil {
+				return nil, err
+			}
+			tuple.components = append(tuple.components, component)
+		}
+		return tuple, nil
+	}
+	// Elementary types
+	switch {
+	case typ == "address":
+		return &abiType{kind: abiAddress}, nil
+	case typ == "bool":
+		return &abiType{kind: abiBool}, nil
+	case typ == "string":
+		return &abiType{kind: abiString}, nil
+	case typ == "bytes":
+		return &abiType{kind: abiBytes}, nil
+	case typ == "byte":
+		return &abiType{kind: abiFixedBytes, size: 1}, nil
+	case typ == "function":
+		return &abiType{kind: abiFixedBytes, size: 24}, nil
+	case strings.HasPrefix(typ, "bytes"):
+		n, err := strconv.Atoi(typ[len("bytes"):])
+		if err != nil || n < 1 || n > 32 {
+			return nil, fmt.Errorf("%v %s", errInvalidABIType, typ)
+		}
+		return &abiType{kind: abiFixedBytes, size: n}, nil
+	case strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "int"):
+		kind, bits := abiUint, strings.TrimPrefix(typ, "uint")
+		if strings.HasPrefix(typ, "int") {
+			kind, bits = abiInt, strings.TrimPrefix(typ, "int")
+		}
+		if bits == "" {
+			bits = "256"
+		}
+		n, err := strconv.Atoi(bits)
+		if err != nil || n < 8 || n > 256 || n%8 != 0 {
+			return nil, fmt.Errorf("%v %s", errInvalidABIType, typ)
+		}
+		return &abiType{kind: kind, size: n}, nil
+	}
+	return nil, fmt.Errorf("%v %s", errInvalidABIType, typ)
+}
+
+// String returns the canonical type name used in signatures.
+func (t *abiType) String() string {
+	switch t.kind {
+	case abiUint:
+		return "uint" + strconv.Itoa(t.size)
+	case abiInt:
+		return "int" + strconv.Itoa(t.size)
+	case abiAddress:
+		return "address"
+	case abiBool:
+		return "bool"
+	case abiFixedBytes:
+		return "bytes" + strconv.Itoa(t.size)
+	case abiBytes:
+		return "bytes"
+	case abiString:
+		return "string"
+	case abiSlice:
+		return t.elem.String() + "[]"
+	case abiArray:
+		return t.elem.String() + "[" + strconv.Itoa(t.size) + "]"
+	default:
+		var names []string
+		for _, component := range t.components {
+			names = append(names, component.String())
+		}
+		return "(" + strings.Join(names, ",") + ")"
+	}
+}
+
+// dynamic reports whether the encoding of type is dynamic.
+func (t *abiType) dynamic() bool {
+	switch t.kind {
+	case abiBytes, abiString, abiSlice:
+		return true
+	case abiArray:
+		return t.elem.dynamic()
+	case abiTuple:
+		for _, component := range t.components {
+			if component.dynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headSize returns the size of type in the head part of encoding.
+func (t *abiType) headSize() int {
+	if t.dynamic() {
+		return 32
+	}
+	switch t.kind {
+	case abiArray:
+		return t.size * t.elem.headSize()
+	case abiTuple:
+		size := 0
+		for _, component := range t.components {
+			size += component.headSize()
+		}
+		return size
+	}
+	return 32
+}
+
+// abiFunction is a parsed function signature.
+type abiFunction struct {
+	Name        string
+	Inputs      []*abiType
+	InputNames  []string // Parameter names, empty if it's unnamed
+	Outputs     []*abiType
+	OutputNames []string
+	Constant    bool // Whether it's a view or pure function
+}
+
+// abiModifiers are the keywords allowed between the parameters and the return
+// types in human-readable signatures.
+var abiModifiers = map[string]bool{
+	"external": true, "public": true, "view": true, "pure": true,
+	"payable": true, "nonpayable": true, "constant": true,
+}
+
+// parseABISignature parses the function signature, e.g. "transfer(address,uint256)".
+// Parameter names are allowed, e.g. "transfer(address to, uint256 value)". The
+// human-readable form with return types is accepted as well, e.g.
+// "function balanceOf(address owner) view returns (uint256 balance)".
+func parseABISignature(sig string) (*abiFunction, error) {
+	sig = strings.TrimSpace(sig)
+	if strings.HasPrefix(sig, "function ") {
+		sig = strings.TrimSpace(sig[len("function "):])
+	}
+	idx := strings.Index(sig, "(")
+	end := closingParen(sig, idx)
+	if idx <= 0 || end < 0 {
+		return nil, fmt.Errorf("%v %s", errInvalidABISignature, sig)
+	}
+	fn := &abiFunction{Name: strings.TrimSpace(sig[:idx])}
+	var err error
+	if fn.Inputs, fn.InputNames, err = parseABIParams(sig[idx+1 : end]); err != nil {
+		return nil, err
+	}
+	// Skip the modifiers and parse the return types if there are
+	rest := strings.TrimSpace(sig[end+1:])
+	for rest != "" {
+		if strings.HasPrefix(rest, "returns") {
+			list := strings.TrimSpace(rest[len("returns"):])
+			if !strings.HasPrefix(list, "(") || closingParen(list, 0) != len(list)-1 {
+				return nil, fmt.Errorf("%v %s", errInvalidABISignature, sig)
+			}
+			if fn.Outputs, fn.OutputNames, err = parseABIParams(list[1 : len(list)-1]); err != nil {
+				return nil, err
+			}
+			break
+		}
+		word := strings.Fields(rest)[0]
+		if !abiModifiers[word] {
+			return nil, fmt.Errorf("%v %s", errInvalidABISignature, sig)
+		}
+		if word == "view" || word == "pure" || word == "constant" {
+			fn.Constant = true
+		}
+		rest = strings.TrimSpace(rest[len(word):])
+	}
+	return fn, nil
+}
+
+// closingParen returns the index of parenthesis which closes the one at given
+// index, -1 is returned if it's unbalanced.
+func closingParen(s string, open int) int {
+	if open < 0 || open >= len(s) || s[open] != '(' {
+		return -1
+	}
+	depth := 0
+	for idx := open; idx < len(s); idx++ {
+		switch s[idx] {
+		case '(':
+			depth += 1
+		case ')':
+			if depth -= 1; depth == 0 {
+				return idx
+			}
+		}
+	}
+	return -1
+}
+
+// parseABIParams parses the comma separated parameter list with the optional
+// names, the data locations and other keywords are ignored.
+func parseABIParams(list string) ([]*abiType, []string, error) {
+	fields, err := splitABIList(list)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		types []*abiType
+		names []string
+	)
+	for _, field := range fields {
+		typ, err := parseABIType(field)
+		if err != nil {
+			return nil, nil, err
+		}
+		var name string
+		if idx := strings.LastIndexAny(field, " \t"); idx >= 0 && !strings.ContainsAny(field[idx+1:], "()[]") {
+			switch word := field[idx+1:]; word {
+			case "memory", "calldata", "storage", "indexed", "payable":
+			default:
+				name = word
+			}
+		}
+		types, names = append(types, typ), append(names, name)
+	}
+	return types, names, nil
+}
+
+// Sig returns the canonical signature of function.
+func (fn *abiFunction) Sig() string {
+	var names []string
+	for _, input := range fn.Inputs {
+		names = append(names, input.String())
+	}
+	return fn.Name + "(" + strings.Join(names, ",") + ")"
+}
+
+// HumanReadable returns the human-readable signature with the parameter names
+// and return types, which can be parsed by parseABISignature again.
+func (fn *abiFunction) HumanReadable() string {
+	signature := "function " + fn.Name + humanReadableParams(fn.Inputs, fn.InputNames, nil)
+	if fn.Constant {
+		signature += " view"
+	}
+	if len(fn.Outputs) > 0 {
+		signature += " returns " + humanReadableParams(fn.Outputs, fn.OutputNames, nil)
+	}
+	return signature
+}
+
+// humanReadableParams returns the parameter list with names and the indexed
+// keywords if they're given.
+func humanReadableParams(types []*abiType, names []string, indexed []bool) string {
+	fields := make([]string, len(types))
+	for idx, typ := range types {
+		fields[idx] = typ.String()
+		if idx < len(indexed) && indexed[idx] {
+			fields[idx] += " indexed"
+		}
+		if idx < len(names) && names[idx] != "" {
+			fields[idx] += " " + names[idx]
+		}
+	}
+	return "(" + strings.Join(fields, ", ") + ")"
+}
+
+// Selector returns the first 4 bytes of the keccak256 hash of signature.
+func (fn *abiFunction) Selector() []byte {
+	return crypto.Keccak256([]byte(fn.Sig()))[:4]
+}
+
+// Pack encodes the arguments with selector.
+func (fn *abiFunction) Pack(args ...interface{}) ([]byte, error) {
+	if len(args) != len(fn.Inputs) {
+		return nil, errABIArgumentNumber
+	}
+	encoded, err := encodeABISequence(fn.Inputs, args)
+	if err != nil {
+		return nil, err
+	}
+	return append(fn.Selector(), encoded...), nil
+}
+
+// PackString parses the textual arguments per input type and encodes them.
+func (fn *abiFunction) PackString(args []string) ([]byte, error) {
+	values, err := fn.parseArguments(args)
+	if err != nil {
+		return nil, err
+	}
+	return fn.Pack(values...)
+}
+
+// PackArguments parses the textual arguments and encodes them without selector,
+// which is used for the constructor arguments.
+func (fn *abiFunction) PackArguments(args []string) ([]byte, error) {
+	values, err := fn.parseArguments(args)
+	if err != nil {
+		return nil, err
+	}
+	return encodeABISequence(fn.Inputs, values)
+}
+
+// PackPacked parses the textual arguments and encodes them in the non-standard
+// packed mode like abi.encodePacked in solidity.
+func (fn *abiFunction) PackPacked(args []string) ([]byte, error) {
+	values, err := fn.parseArguments(args)
+	if err != nil {
+		return nil, err
+	}
+	var packed []byte
+	for idx, typ := range fn.Inputs {
+		encoded, err := encodeABIPacked(typ, values[idx], false)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", idx, err)
+		}
+		packed = append(packed, encoded...)
+	}
+	return packed, nil
+}
+
+// parseArguments parses the textual arguments per input type.
+func (fn *abiFunction) parseArguments(args []string) ([]interface{}, error) {
+	if len(args) != len(fn.Inputs) {
+		return nil, errABIArgumentNumber
+	}
+	values := make([]interface{}, len(args))
+	for idx, arg := range args {
+		value, err := parseABIValue(fn.Inputs[idx], arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", idx, err)
+		}
+		values[idx] = value
+	}
+	return values, nil
+}
+
+// encodeABISequence encodes the values as a tuple of given types.
+func encodeABISequence(types []*abiType, values []interface{}) ([]byte, error) {
+	headSize := 0
+	for _, typ := range types {
+		headSize += typ.headSize()
+	}
+	var head, tail []byte
+	for idx, typ := range types {
+		encoded, err := encodeABIValue(typ, values[idx])
+		if err != nil {
+			return nil, err
+		}
+		if typ.dynamic() {
+			head = append(head, abiWord(big.NewInt(int64(headSize+len(tail))))...)
+			tail = append(tail, encoded...)
+		} else {
+			head = append(head, encoded...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
+// encodeABIValue encodes a single value. The value types are *big.Int for
+// integers, common.Address, bool, []byte, string and []interface{} for arrays
+// and tuples.
+func encodeABIValue(typ *abiType, value interface{}) ([]byte, error) {
+	switch typ.kind {
+	case abiUint, abiInt:
+		v, ok := value.(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("invalid value %v for %s", value, typ)
+		}
+		if !abiIntInRange(typ, v) {
+			return nil, fmt.Errorf("value %v overflows %s", v, typ)
+		}
+		if v.Sign() < 0 {
+			// Two's complement representation
+			v = new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 256), v)
+		}
+		return abiWord(v), nil
+	case abiAddress:
+		v, ok := value.(common.Address)
+		if !ok {
+			return nil, fmt.Errorf("invalid value %v for %s", value, typ)
+		}
+		return common.LeftPadBytes(v.Bytes(), 32), nil
+	case abiBool:
+		v, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid value %v for %s", value, typ)
+		}
+		if v {
+			return abiWord(big.NewInt(1)), nil
+		}
+		return abiWord(big.NewInt(0)), nil
+	case abiFixedBytes:
+		v, ok := value.([]byte)
+		if !ok || len(v) > typ.size {
+			return nil, fmt.Errorf("invalid value %v for %s", value, typ)
+		}
+		return common.RightPadBytes(v, 32), nil
+	case abiBytes, abiString:
+		var v []byte
+		switch data := value.(type) {
+		case []byte:
+			v = data
+		case string:
+			v = []byte(data)
+		default:
+			return nil, fmt.Errorf("invalid value %v for %s", value, typ)
+		}
+		padded := common.RightPadBytes(v, (len(v)+31)/32*32)
+		return append(abiWord(big.NewInt(int64(len(v)))), padded...), nil
+	case abiSlice, abiArray:
+		v, ok := value.([]interface{})
+		if !ok || (typ.kind == abiArray && len(v) != typ.size) {
+			return nil, fmt.Errorf("invalid value %v for %s", value, typ)
+		}
+		types := make([]*abiType, len(v))
+		for idx := range types {
+			types[idx] = typ.elem
+		}
+		encoded, err := encodeABISequence(types, v)
+		if err != nil {
+			return nil, err
+		}
+		if typ.kind == abiSlice {
+			return append(abiWord(big.NewInt(int64(len(v)))), encoded...), nil
+		}
+		return encoded, nil
+	case abiTuple:
+		v, ok := value.([]interface{})
+		if !ok || len(v) != len(typ.components) {
+			return nil, fmt.Errorf("invalid value %v for %s", value, typ)
+		}
+		return encodeABISequence(typ.components, v)
+	}
+	return nil, errInvalidABIType
+}
+
+// encodeABIPacked encodes a single value in packed mode. The elementary types
+// take their own sizes, the dynamic ones are encoded in place without length,
+// and the array elements are padded to 32 bytes. Tuples and nested arrays are
+// not supported as solidity does.
+func encodeABIPacked(typ *abiType, value interface{}, elem bool) ([]byte, error) {
+	switch typ.kind {
+	case abiUint, abiInt, abiAddress, abiBool, abiFixedBytes:
+		encoded, err := encodeABIValue(typ, value)
+		if err != nil || elem {
+			return encoded, err
+		}
+		switch typ.kind {
+		case abiUint, abiInt:
+			return encoded[32-typ.size/8:], nil
+		case abiAddress:
+			return encoded[12:], nil
+		case abiBool:
+			return encoded[31:], nil
+		default:
+			return encoded[:typ.size], nil
+		}
+	case abiBytes, abiString:
+		if elem {
+			return nil, fmt.Errorf("%v %s in packed array", errInvalidABIType, typ)
+		}
+		if v, ok := value.(string); ok {
+			return []byte(v), nil
+		}
+		if v, ok := value.([]byte); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("invalid value %v for %s", value, typ)
+	case abiSlice, abiArray:
+		v, ok := value.([]interface{})
+		if elem || !ok {
+			return nil, fmt.Errorf("%v %s in packed mode", errInvalidABIType, typ)
+		}
+		var packed []byte
+		for _, item := range v {
+			encoded, err := encodeABIPacked(typ.elem, item, true)
+			if err != nil {
+				return nil, err
+			}
+			packed = append(packed, encoded...)
+		}
+		return packed, nil
+	}
+	return nil, fmt.Errorf("%v %s in packed mode", errInvalidABIType, typ)
+}
+
+// abiWord returns the 32 bytes big endian representation of non-negative integer.
+func abiWord(v *big.Int) []byte {
+	return common.LeftPadBytes(v.Bytes(), 32)
+}
+
+// abiIntInRange reports whether the integer fits in the integer type.
+func abiIntInRange(typ *abiType, v *big.Int) bool {
+	if typ.kind == abiUint {
+		return v.Sign() >= 0 && v.BitLen() <= typ.size
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(typ.size-1))
+	return v.Cmp(limit) < 0 && v.Cmp(new(big.Int).Neg(limit)) >= 0
+}
+
+// abiUnits are the ether unit suffixes allowed in integer arguments.
+var abiUnits = map[string]int{
+	"wei":    0,
+	"kwei":   3,
+	"mwei":   6,
+	"gwei":   9,
+	"szabo":  12,
+	"finney": 15,
+	"ether":  18,
+}
+
+// parseABIValue parses the textual argument per type. The syntax is:
+//
+//    integers   decimal or hex number, decimals and exponents are allowed with
+//               unit suffixes, e.g. 100, 0xff, 1.5ether, 20gwei, 1e18
+//    address    hex address
+//    bool       true or false
+//    bytes      hex string, e.g. 0x1234
+//    string     raw or double quoted text with escapes
+//    arrays     comma separated elements in brackets, e.g. [1,2,3]
+//    tuples     comma separated components in parentheses, e.g. (0x12..,100)
+func parseABIValue(typ *abiType, arg string) (interface{}, error) {
+	arg = strings.TrimSpace(arg)
+	switch typ.kind {
+	case abiUint, abiInt:
+		return parseABIInteger(arg)
+	case abiAddress:
+		if !common.IsHexAddress(arg) {
+			return nil, fmt.Errorf("invalid address %s", arg)
+		}
+		return common.HexToAddress(arg), nil
+	case abiBool:
+		v, err := strconv.ParseBool(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool %s", arg)
+		}
+		return v, nil
+	case abiFixedBytes, abiBytes:
+		if !strings.HasPrefix(arg, "0x") && !strings.HasPrefix(arg, "0X") {
+			return nil, fmt.Errorf("invalid bytes %s", arg)
+		}
+		v, err := hexDecode(arg[2:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes %s", arg)
+		}
+		if typ.kind == abiFixedBytes && len(v) > typ.size {
+			return nil, fmt.Errorf("bytes %s overflows %s", arg, typ)
+		}
+		return v, nil
+	case abiString:
+		if strings.HasPrefix(arg, "\"") {
+			v, err := strconv.Unquote(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s", arg)
+			}
+			return v, nil
+		}
+		return arg, nil
+	case abiSlice, abiArray, abiTuple:
+		open, close, elems := "[", "]", []*abiType(nil)
+		if typ.kind == abiTuple {
+			open, close, elems = "(", ")", typ.components
+		}
+		if !strings.HasPrefix(arg, open) || !strings.HasSuffix(arg, close) {
+			return nil, fmt.Errorf("invalid %s value %s", typ, arg)
+		}
+		fields, err := splitABIList(arg[1 : len(arg)-1])
+		if err != nil {
+			return nil, err
+		}
+		if typ.kind != abiTuple {
+			for range fields {
+				elems = append(elems, typ.elem)
+			}
+		}
+		if len(fields) != len(elems) || (typ.kind == abiArray && len(fields) != typ.size) {
+			return nil, fmt.Errorf("invalid %s value %s, element number mismatch", typ, arg)
+		}
+		values := make([]interface{}, len(fields))
+		for idx, field := range fields {
+			if values[idx], err = parseABIValue(elems[idx], field); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, errInvalidABIType
+}
+
+// parseABIInteger parses the integer argument with optional unit suffix.
+func parseABIInteger(arg string) (*big.Int, error) {
+	var (
+		number = strings.ToLower(arg)
+		unit   = 0
+	)
+	// The suffix consists of letters after the number, hex digits are excluded
+	// by checking the unit table.
+	if idx := strings.LastIndexAny(number, "0123456789."); idx >= 0 && idx < len(number)-1 {
+		if decimals, exist := abiUnits[strings.TrimSpace(number[idx+1:])]; exist {
+			number, unit = number[:idx+1], decimals
+		}
+	}
+	if strings.HasPrefix(number, "0x") || strings.HasPrefix(number, "-0x") {
+		v, ok := new(big.Int).SetString(number, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %s", arg)
+		}
+		return v.Mul(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(unit)), nil)), nil
+	}
+	rat, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %s", arg)
+	}
+	rat.Mul(rat, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(unit)), nil)))
+	if !rat.IsInt() {
+		return nil, fmt.Errorf("integer %s has fractional part", arg)
+	}
+	return new(big.Int).Set(rat.Num()), nil
+}
+
+// hexDecode decodes the hex string without prefix.
+func hexDecode(s string) ([]byte, error) {
+	if len(s)%2 == 1 {
+		return nil, errInvalidContent
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return nil, errInvalidContent
+		}
+	}
+	return common.FromHex(s), nil
+}
+
+// splitABIList splits the comma separated list at the top level, the commas in
+// brackets, parentheses and quoted strings are kept.
+func splitABIList(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	var (
+		fields []string
+		depth  int
+		quoted bool
+		escape bool
+		start  int
+	)
+	for idx, c := range list {
+		switch {
+		case escape:
+			escape = false
+		case quoted && c == '\\':
+			escape = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[' || c == '(':
+			depth += 1
+		case c == ']' || c == ')':
+			if depth -= 1; depth < 0 {
+				return nil, fmt.Errorf("unbalanced brackets in %s", list)
+			}
+		case c == ',' && depth == 0:
+			fields = append(fields, strings.TrimSpace(list[start:idx]))
+			start = idx + 1
+		}
+	}
+	if depth != 0 || quoted {
+		return nil, fmt.Errorf("unbalanced brackets in %s", list)
+	}
+	return append(fields, strings.TrimSpace(list[start:])), nil
+}
+
+// abiJSONArgument is the argument definition in json abi, tuples are described
+// with components.
+type abiJSONArgument struct {
+	Name       string            `json:"name"`
+	Type       string            `json:"type"`
+	Indexed    bool              `json:"indexed"`
+	Components []abiJSONArgument `json:"components"`
+}
+
+// typeName returns the type name in signature format.
+func (arg abiJSONArgument) typeName() string {
+	if !strings.HasPrefix(arg.Type, "tuple") {
+		return arg.Type
+	}
+	var names []string
+	for _, component := range arg.Components {
+		names = append(names, component.typeName())
+	}
+	return "(" + strings.Join(names, ",") + ")" + strings.TrimPrefix(arg.Type, "tuple")
+}
+
+// abiJSONEntry is a single entry in json abi.
+type abiJSONEntry struct {
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	Inputs    []abiJSONArgument `json:"inputs"`
+	Outputs   []abiJSONArgument `json:"outputs"`
+	Anonymous bool              `json:"anonymous"`
+
+	Constant        bool   `json:"constant"`        // Legacy mutability flag
+	StateMutability string `json:"stateMutability"` // One of pure, view, nonpayable and payable
+}
+
+// params returns the parameter list of arguments in signature format.
+func params(args []abiJSONArgument) string {
+	var fields []string
+	for _, arg := range args {
+		field := arg.typeName()
+		if arg.Indexed {
+			field += " indexed"
+		}
+		fields = append(fields, strings.TrimSpace(field+" "+arg.Name))
+	}
+	return "(" + strings.Join(fields, ",") + ")"
+}
+
+// abiDefinition is the parsed contract abi.
+type abiDefinition struct {
+	Functions   []*abiFunction
+	Events      []*abiEvent
+	Constructor *abiFunction // Nil if it's not defined
+	Bytecode    string       // Creation bytecode in the artifact, empty if it's not given
+}
+
+// loadABIFunctions loads the functions from the abi file.
+func loadABIFunctions(path string) ([]*abiFunction, error) {
+	def, err := loadABIDefinition(path)
+	if err != nil {
+		return nil, err
+	}
+	return def.Functions, nil
+}
+
+// loadABIDefinition loads the abi file.
+func loadABIDefinition(path string) (*abiDefinition, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	def, err := parseABIDefinition(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid abi file %s: %v", path, err)
+	}
+	return def, nil
+}
+
+// parseABIFunctions parses the functions in the abi.
+func parseABIFunctions(content []byte) ([]*abiFunction, error) {
+	def, err := parseABIDefinition(content)
+	if err != nil {
+		return nil, err
+	}
+	return def.Functions, nil
+}
+
+// parseABIDefinition parses the abi, which can be given as:
+//
+//    json abi, the entries can be the human-readable signatures as well
+//    truffle or hardhat artifact, which has the json abi in "abi" field
+//    human-readable signatures, one per line
+func parseABIDefinition(content []byte) (*abiDefinition, error) {
+	def := new(abiDefinition)
+	content = bytes.TrimSpace(content)
+	if bytes.HasPrefix(content, []byte("{")) {
+		var artifact struct {
+			ABI      json.RawMessage `json:"abi"`
+			Bytecode json.RawMessage `json:"bytecode"`
+		}
+		if err := json.Unmarshal(content, &artifact); err != nil {
+			return nil, err
+		}
+		if len(artifact.ABI) == 0 {
+			return nil, errors.New("no abi in the artifact")
+		}
+		// The bytecode is a hex string, or an object with the hex string in
+		// "object" field
+		var bytecode struct {
+			Object string `json:"object"`
+		}
+		if err := json.Unmarshal(artifact.Bytecode, &def.Bytecode); err != nil {
+			if err := json.Unmarshal(artifact.Bytecode, &bytecode); err == nil {
+				def.Bytecode = bytecode.Object
+			}
+		}
+		content = artifact.ABI
+	}
+	if !bytes.HasPrefix(content, []byte("[")) {
+		if err := def.addSignatures(strings.Split(string(content), "\n")); err != nil {
+			return nil, err
+		}
+		return def, nil
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+	for _, raw := range entries {
+		var signature string
+		if err := json.Unmarshal(raw, &signature); err == nil {
+			if err := def.addSignatures([]string{signature}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		var entry abiJSONEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, err
+		}
+		switch entry.Type {
+		case "function", "":
+			fn, err := parseABISignature(entry.Name + params(entry.Inputs) + " returns " + params(entry.Outputs))
+			if err != nil {
+				return nil, err
+			}
+			fn.Constant = entry.Constant || entry.StateMutability == "view" || entry.StateMutability == "pure"
+			def.Functions = append(def.Functions, fn)
+		case "constructor":
+			fn, err := parseABISignature("constructor" + params(entry.Inputs))
+			if err != nil {
+				return nil, err
+			}
+			def.Constructor = fn
+		case "event":
+			signature := entry.Name + params(entry.Inputs)
+			if entry.Anonymous {
+				signature += " anonymous"
+			}
+			ev, err := parseABIEvent(signature)
+			if err != nil {
+				return nil, err
+			}
+			def.Events = append(def.Events, ev)
+		}
+	}
+	return def, nil
+}
+
+// addSignatures parses the human-readable signatures, the empty lines, comments
+// and other entries such as errors are skipped.
+func (def *abiDefinition) addSignatures(lines []string) error {
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		var keyword string
+		if words := strings.FieldsFunc(line, func(c rune) bool { return c == ' ' || c == '(' }); len(words) > 0 {
+			keyword = words[0]
+		}
+		switch keyword {
+		case "error", "fallback", "receive", "struct":
+			continue
+		case "event":
+			ev, err := parseABIEvent(line)
+			if err != nil {
+				return err
+			}
+			def.Events = append(def.Events, ev)
+			continue
+		}
+		fn, err := parseABISignature(line)
+		if err != nil {
+			return err
+		}
+		if keyword == "constructor" {
+			def.Constructor = fn
+		} else {
+			def.Functions = append(def.Functions, fn)
+		}
+	}
+	return nil
+}
+
+// findABIFunction returns the function with given signature, or the function
+// with given name and argument number in the functions. The return types are
+// taken from the functions if they're omitted in the signature.
+func findABIFunction(functions []*abiFunction, signature string, args int) (*abiFunction, error) {
+	if strings.Contains(signature, "(") {
+		fn, err := parseABISignature(signature)
+		if err != nil || len(fn.Outputs) != 0 {
+			return fn, err
+		}
+		for _, known := range functions {
+			if known.Sig() == fn.Sig() {
+				return known, nil
+			}
+		}
+		return fn, nil
+	}
+	var matched []*abiFunction
+	for _, fn := range functions {
+		if fn.Name == signature && len(fn.Inputs) == args {
+			matched = append(matched, fn)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, fmt.Errorf("%v %s with %d arguments", errUnknownABIMethod, signature, args)
+	case 1:
+		return matched[0], nil
+	default:
+		return nil, fmt.Errorf("%v %s, use the function signature instead", errAmbiguousABIMethod, signature)
+	}
+}
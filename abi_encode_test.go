@@ -0,0 +1,332 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func abiTestWord(hex string) string {
+	return strings.Repeat("0", 64-len(hex)) + hex
+}
+
+func TestABIEncode(t *testing.T) {
+	var tests = []struct {
+		signature string
+		args      []string
+		selector  string // empty means unchecked
+		expect    string
+	}{
+		// Examples of the contract abi specification
+		{"baz(uint32,bool)", []string{"69", "true"},
+			"cdcd77c0",
+			abiTestWord("45") + abiTestWord("1")},
+		{"sam(bytes,bool,uint256[])", []string{"0x64617665", "true", "[1,2,3]"},
+			"a5643bf2",
+			abiTestWord("60") + abiTestWord("1") + abiTestWord("a0") +
+				abiTestWord("4") + "6461766500000000000000000000000000000000000000000000000000000000" +
+				abiTestWord("3") + abiTestWord("1") + abiTestWord("2") + abiTestWord("3")},
+		{"f(uint,uint32[],bytes10,bytes)", []string{"0x123", "[0x456, 0x789]", "0x31323334353637383930", `0x48656c6c6f2c20776f726c6421`},
+			"8be65246",
+			abiTestWord("123") + abiTestWord("80") +
+				"3132333435363738393000000000000000000000000000000000000000000000" + abiTestWord("e0") +
+				abiTestWord("2") + abiTestWord("456") + abiTestWord("789") +
+				abiTestWord("d") + "48656c6c6f2c20776f726c642100000000000000000000000000000000000000"},
+		// Dynamic tuple
+		{"g((uint256 amount, bytes data) order, bool)", []string{"(5, 0xabcd)", "true"},
+			"", abiTestWord("40") + abiTestWord("1") +
+				abiTestWord("5") + abiTestWord("40") + abiTestWord("2") + "abcd" + strings.Repeat("0", 60)},
+		// Static tuple array, strings and units
+		{"h((address,uint8)[2],string,int256)", []string{"[(0x8f0909ccb296ebd319834edb0d5785794b781d7f,1),(0x8f0909ccb296ebd319834edb0d5785794b781d7f,2)]", `"a, b"`, "-1"},
+			"", abiTestWord("8f0909ccb296ebd319834edb0d5785794b781d7f") + abiTestWord("1") +
+				abiTestWord("8f0909ccb296ebd319834edb0d5785794b781d7f") + abiTestWord("2") +
+				abiTestWord("c0") + strings.Repeat("f", 64) +
+				abiTestWord("4") + "612c206200000000000000000000000000000000000000000000000000000000"},
+		{"transfer(address,uint256)", []string{"0x8f0909ccb296ebd319834edb0d5785794b781d7f", "1.5ether"},
+			"a9059cbb",
+			abiTestWord("8f0909ccb296ebd319834edb0d5785794b781d7f") + abiTestWord("14d1120d7b160000")},
+	}
+	for _, test := range tests {
+		fn, err := parseABISignature(test.signature)
+		if err != nil {
+			t.Fatalf("%s: failed to parse signature: %v", test.signature, err)
+		}
+		encoded, err := fn.PackString(test.args)
+		if err != nil {
+			t.Fatalf("%s: failed to encode: %v", test.signature, err)
+		}
+		if test.selector != "" && common.Bytes2Hex(encoded[:4]) != test.selector {
+			t.Errorf("%s: selector mismatch, want %s, got %x", test.signature, test.selector, encoded[:4])
+		}
+		if got := common.Bytes2Hex(encoded[4:]); got != test.expect {
+			t.Errorf("%s: encoding mismatch\nwant %s\ngot  %s", test.signature, test.expect, got)
+		}
+	}
+}
+
+// TestABIMatchesVendored checks the encoder and decoder against the abi package
+// of go-ethereum for the types it supports.
+func TestABIMatchesVendored(t *testing.T) {
+	var (
+		holder   = common.HexToAddress("0x8f0909ccb296ebd319834edb0d5785794b781d7f")
+		spender  = common.HexToAddress("0xadd0354d4f5c101685509001053730417321db49")
+		amount   = new(big.Int).Mul(big.NewInt(15), new(big.Int).Exp(big.NewInt(10), big.NewInt(17), nil))
+		bytes32  = [32]byte{0xab}
+		word32   = "0xab" + strings.Repeat("0", 62)
+		holders  = fmt.Sprintf("[%s,%s]", holder.Hex(), spender.Hex())
+		integers = []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+	)
+	var tests = []struct {
+		types  []string
+		values []interface{} // Arguments of abi.Pack
+		args   []string      // Arguments of PackString
+		decode []string      // Decoded values in argument syntax
+	}{
+		{[]string{"address", "uint256"}, []interface{}{holder, amount},
+			[]string{strings.ToLower(holder.Hex()), "1.5ether"}, []string{holder.Hex(), amount.String()}},
+		{[]string{"bool", "int256", "bytes32", "uint8"}, []interface{}{true, big.NewInt(-5), bytes32, uint8(255)},
+			[]string{"true", "-5", word32, "255"}, []string{"true", "-5", word32, "255"}},
+		{[]string{"int64", "uint16"}, []interface{}{int64(-7), uint16(65535)},
+			[]string{"-7", "65535"}, []string{"-7", "65535"}},
+		{[]string{"string", "bytes"}, []interface{}{"a, b", []byte{1, 2}},
+			[]string{`"a, b"`, "0x0102"}, []string{`"a, b"`, "0x0102"}},
+		{[]string{"uint256[]", "address[2]", "bool"}, []interface{}{integers, [2]common.Address{holder, spender}, false},
+			[]string{"[1,2,3]", holders, "false"}, []string{"[1,2,3]", holders, "false"}},
+	}
+	for _, test := range tests {
+		var params []string
+		for idx, typ := range test.types {
+			params = append(params, fmt.Sprintf(`{"name":"a%d","type":"%s"}`, idx, typ))
+		}
+		list := strings.Join(params, ",")
+		parsed, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"f","inputs":[` + list + `],"outputs":[` + list + `]}]`))
+		if err != nil {
+			t.Fatalf("%v: failed to parse abi: %v", test.types, err)
+		}
+		fn, err := parseABISignature("f(" + strings.Join(test.types, ",") + ") returns (" + strings.Join(test.types, ",") + ")")
+		if err != nil {
+			t.Fatalf("%v: failed to parse signature: %v", test.types, err)
+		}
+		want, err := parsed.Pack("f", test.values...)
+		if err != nil {
+			t.Fatalf("%v: failed to pack with abi package: %v", test.types, err)
+		}
+		got, err := fn.PackString(test.args)
+		if err != nil {
+			t.Fatalf("%v: failed to pack: %v", test.types, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%v: encoding mismatch\nwant %x\ngot  %x", test.types, want, got)
+		}
+		values, err := fn.UnpackOutput(want[4:])
+		if err != nil {
+			t.Fatalf("%v: failed to unpack: %v", test.types, err)
+		}
+		for idx, value := range values {
+			if formatted := formatABIValue(fn.Outputs[idx], value); formatted != test.decode[idx] {
+				t.Errorf("%v: output %d mismatch, want %s, got %s", test.types, idx, test.decode[idx], formatted)
+			}
+		}
+	}
+}
+
+func TestABISignature(t *testing.T) {
+	var tests = []struct {
+		signature string
+		canonical string
+	}{
+		{"transfer(address,uint)", "transfer(address,uint256)"},
+		{"f(int, byte[], (uint8 a, bytes[2] b)[] c)", "f(int256,bytes1[],(uint8,bytes[2])[])"},
+		{"g()", "g()"},
+	}
+	for _, test := range tests {
+		fn, err := parseABISignature(test.signature)
+		if err != nil {
+			t.Fatalf("%s: failed to parse signature: %v", test.signature, err)
+		}
+		if fn.Sig() != test.canonical {
+			t.Errorf("canonical signature mismatch, want %s, got %s", test.canonical, fn.Sig())
+		}
+	}
+	for _, invalid := range []string{"f", "f(uint7)", "f(uint264)", "f(bytes33)", "f((uint256)", "f(foo)", "f(uint256[0])"} {
+		if _, err := parseABISignature(invalid); err == nil {
+			t.Errorf("%s: expect error", invalid)
+		}
+	}
+}
+
+func TestParseABIValue(t *testing.T) {
+	var tests = []struct {
+		typ    string
+		arg    string
+		expect string // encoded value, empty means error
+	}{
+		{"uint256", "20gwei", abiTestWord("4a817c800")},
+		{"uint256", "1e3 wei", abiTestWord("3e8")},
+		{"uint256", "0xff", abiTestWord("ff")},
+		{"uint256", "1.5", ""},
+		{"uint256", "-1", ""},
+		{"uint8", "256", ""},
+		{"int8", "-128", strings.Repeat("f", 62) + "80"},
+		{"int8", "-129", ""},
+		{"bool", "yes", ""},
+		{"address", "0x1234", ""},
+		{"bytes2", "0x123456", ""},
+		{"bytes2", "0x1234", "1234" + strings.Repeat("0", 60)},
+		{"uint256[2]", "[1]", ""},
+		{"(uint256,bool)", "(1)", ""},
+		{"string", `"unterminated`, ""},
+	}
+	for _, test := range tests {
+		typ, err := parseABIType(test.typ)
+		if err != nil {
+			t.Fatalf("%s: failed to parse type: %v", test.typ, err)
+		}
+		var encoded []byte
+		value, err := parseABIValue(typ, test.arg)
+		if err == nil {
+			encoded, err = encodeABIValue(typ, value)
+		}
+		if test.expect == "" {
+			if err == nil {
+				t.Errorf("%s %s: expect error", test.typ, test.arg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: unexpected error: %v", test.typ, test.arg, err)
+			continue
+		}
+		if got := common.Bytes2Hex(encoded); got != test.expect {
+			t.Errorf("%s %s: encoding mismatch, want %s, got %s", test.typ, test.arg, test.expect, got)
+		}
+	}
+	if v, _ := parseABIInteger("2.5finney"); v.Cmp(big.NewInt(2500000000000000)) != 0 {
+		t.Errorf("unit conversion mismatch, got %v", v)
+	}
+}
+
+func TestLoadABIFunctions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-abi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	abiFile := path.Join(dir, "market.json")
+	ioutil.WriteFile(abiFile, []byte(`[
+		{"type":"constructor","inputs":[{"name":"owner","type":"address"}]},
+		{"type":"event","name":"Filled","inputs":[{"name":"id","type":"uint256"}]},
+		{"type":"function","name":"fill","inputs":[
+			{"name":"orders","type":"tuple[]","components":[
+				{"name":"maker","type":"address"},
+				{"name":"amounts","type":"uint256[2]"}
+			]},
+			{"name":"deadline","type":"uint64"}
+		]}
+	]`), 0644)
+	functions, err := loadABIFunctions(abiFile)
+	if err != nil {
+		t.Fatalf("failed to load abi: %v", err)
+	}
+	if len(functions) != 1 || functions[0].Sig() != "fill((address,uint256[2])[],uint64)" {
+		t.Fatalf("loaded functions mismatch, got %v", functions)
+	}
+}
+
+func TestHumanReadableSignature(t *testing.T) {
+	var tests = []struct {
+		signature string
+		sig       string
+		inputs    []string
+		outputs   string
+		names     []string
+		err       error
+	}{
+		{"function balanceOf(address owner) view returns (uint256 balance)", "balanceOf(address)", []string{"owner"}, "uint256", []string{"balance"}, nil},
+		{"getReserves() external view returns (uint112, uint112 reserve1, uint32)", "getReserves()", nil, "uint112,uint112,uint32", []string{"", "reserve1", ""}, nil},
+		{"function batch(uint256[] calldata ids, (address to, bytes data)[] memory calls) payable", "batch(uint256[],(address,bytes)[])", []string{"ids", "calls"}, "", nil, nil},
+		{"transfer(address to, uint256 value)", "transfer(address,uint256)", []string{"to", "value"}, "", nil, nil},
+		{"function name() view returns string", "", nil, "", nil, errInvalidABISignature},
+		{"function f(uint256) mutable", "", nil, "", nil, errInvalidABISignature},
+		{"function f(uint256", "", nil, "", nil, errInvalidABISignature},
+	}
+	for _, test := range tests {
+		fn, err := parseABISignature(test.signature)
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.signature, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		var outputs []string
+		for _, output := range fn.Outputs {
+			outputs = append(outputs, output.String())
+		}
+		if fn.Sig() != test.sig || strings.Join(outputs, ",") != test.outputs {
+			t.Errorf("%s: signature mismatch, want %s returns (%s), got %s returns (%s)", test.signature, test.sig, test.outputs, fn.Sig(), strings.Join(outputs, ","))
+		}
+		if strings.Join(fn.InputNames, ",") != strings.Join(test.inputs, ",") || strings.Join(fn.OutputNames, ",") != strings.Join(test.names, ",") {
+			t.Errorf("%s: names mismatch, want %q and %q, got %q and %q", test.signature, test.inputs, test.names, fn.InputNames, fn.OutputNames)
+		}
+	}
+}
+
+func TestLoadABIFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-abi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var tests = []struct {
+		name    string
+		content string
+	}{
+		{"abi.json", `[{"type":"function","name":"balanceOf","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"balance","type":"uint256"}]}]`},
+		{"artifact.json", `{"contractName":"Token","abi":[{"type":"function","name":"balanceOf","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"balance","type":"uint256"}]}],"bytecode":"0x"}`},
+		{"human.json", `["event Transfer(address indexed from, address indexed to, uint256 value)", "function balanceOf(address owner) view returns (uint256 balance)"]`},
+		{"human.txt", "// token interface\nconstructor(string symbol)\nfunction balanceOf(address owner) view returns (uint256 balance)\n\n"},
+	}
+	for _, test := range tests {
+		abiFile := path.Join(dir, test.name)
+		ioutil.WriteFile(abiFile, []byte(test.content), 0644)
+		functions, err := loadABIFunctions(abiFile)
+		if err != nil {
+			t.Errorf("%s: failed to load abi: %v", test.name, err)
+			continue
+		}
+		if len(functions) != 1 || functions[0].Sig() != "balanceOf(address)" || len(functions[0].Outputs) != 1 ||
+			functions[0].Outputs[0].String() != "uint256" || functions[0].OutputNames[0] != "balance" {
+			t.Errorf("%s: loaded functions mismatch, got %v", test.name, functions)
+		}
+	}
+	abiFile := path.Join(dir, "invalid.json")
+	ioutil.WriteFile(abiFile, []byte(`{"contractName":"Token"}`), 0644)
+	if _, err := loadABIFunctions(abiFile); err == nil {
+		t.Errorf("artifact without abi: expected error")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rjl493456442/ethclient/client"
+)
+
+var (
+	errInvalidBlock    = errors.New("invalid block, expect number, hash, latest, pending or earliest")
+	errUnresolvedBlock = errors.New("block hash is not resolved")
+)
+
+// BlockTag is the block whose state the calls and balance queries are executed
+// on, the nil tag stands for the latest block.
+type BlockTag struct {
+	Number  *big.Int    // Block number, nil if it's specified by hash or pending
+	Hash    common.Hash // Block hash, which is resolved into number before use
+	Pending bool        // Whether the pending state is used
+}
+
+// ParseBlockTag parses the block in decimal or hex number, 32 bytes hash, or
+// one of latest, pending and earliest.
+func ParseBlockTag(s string) (*BlockTag, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "latest":
+		return nil, nil
+	case "pending":
+		return &BlockTag{Pending: true}, nil
+	case "earliest":
+		return &BlockTag{Number: new(big.Int)}, nil
+	}
+	var (
+		number = new(big.Int)
+		ok     bool
+	)
+	if strings.HasPrefix(s, "0x") {
+		if len(s) == 2+2*common.HashLength {
+			if _, err := hexDecode(s[2:]); err != nil {
+				return nil, fmt.Errorf("%v: %s", errInvalidBlock, s)
+			}
+			return &BlockTag{Hash: common.HexToHash(s)}, nil
+		}
+		_, ok = number.SetString(s[2:], 16)
+	} else {
+		_, ok = number.SetString(s, 10)
+	}
+	if !ok || number.Sign() < 0 {
+		return nil, fmt.Errorf("%v: %s", errInvalidBlock, s)
+	}
+	return &BlockTag{Number: number}, nil
+}
+
+// Resolve resolves the block hash into number, since the calls and balance
+// queries only accept the block number.
+func (tag *BlockTag) Resolve(client *client.Client) error {
+	if tag == nil || tag.Number != nil || tag.Pending {
+		return nil
+	}
+	if client == nil {
+		return fmt.Errorf("failed to resolve block %s: %v", tag.Hash.Hex(), errNoClient)
+	}
+	ctx, _ := makeTimeoutContext(5 * time.Second)
+	header, err := client.Cli.HeaderByHash(ctx, tag.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to resolve block %s: %v", tag.Hash.Hex(), err)
+	}
+	tag.Number = header.Number
+	return nil
+}
+
+// String returns the block in the form accepted by ParseBlockTag.
+func (tag *BlockTag) String() string {
+	switch {
+	case tag == nil:
+		return "latest"
+	case tag.Pending:
+		return "pending"
+	case tag.Number != nil:
+		return tag.Number.String()
+	default:
+		return tag.Hash.Hex()
+	}
+}
+
+// number returns the block number passed to the client, nil for the latest.
+// The block hash must be resolved already, otherwise the query would silently
+// run against the latest block.
+func (tag *BlockTag) number() (*big.Int, error) {
+	if tag == nil {
+		return nil, nil
+	}
+	if tag.Number == nil {
+		return nil, fmt.Errorf("%v: %s", errUnresolvedBlock, tag.Hash.Hex())
+	}
+	return tag.Number, nil
+}
+
+// balanceAt queries the ether balance of account at the block.
+func balanceAt(client *client.Client, block *BlockTag, account common.Address) (*big.Int, error) {
+	ctx, _ := makeTimeoutContext(5 * time.Second)
+	if block != nil && block.Pending {
+		return client.Cli.PendingBalanceAt(ctx, account)
+	}
+	number, err := block.number()
+	if err != nil {
+		return nil, err
+	}
+	return client.Cli.BalanceAt(ctx, account, number)
+}
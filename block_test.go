@@ -0,0 +1,201 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseBlockTag(t *testing.T) {
+	hash := "0x64912ac4307eb7f44f4940967cdfafee53bd81790ed2035c29b8d9798c193f4f"
+	var tests = []struct {
+		input  string
+		output string
+		err    error
+	}{
+		{"", "latest", nil},
+		{"Latest", "latest", nil},
+		{"pending", "pending", nil},
+		{"earliest", "0", nil},
+		{"5000000", "5000000", nil},
+		{"0x4c4b40", "5000000", nil},
+		{hash, hash, nil},
+		{"-1", "", errInvalidBlock},
+		{"0x", "", errInvalidBlock},
+		{"1e6", "", errInvalidBlock},
+		{"0x" + strings.Repeat("zz", 32), "", errInvalidBlock},
+		{"safe", "", errInvalidBlock},
+	}
+	for _, test := range tests {
+		tag, err := ParseBlockTag(test.input)
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.input, test.err, err)
+			continue
+		}
+		if err == nil && tag.String() != test.output {
+			t.Errorf("%s: block mismatch, want %s, got %s", test.input, test.output, tag.String())
+		}
+	}
+}
+
+func TestBlockTagQueries(t *testing.T) {
+	var (
+		hash    = common.HexToHash("0x64912ac4307eb7f44f4940967cdfafee53bd81790ed2035c29b8d9798c193f4f")
+		account = common.HexToAddress("0x8f0909ccb296ebd319834edb0d5785794b781d7f")
+		blocks  []string
+	)
+	server, cli := newTestRPCServer(t, func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "eth_call", "eth_getBalance":
+			var block string
+			if err := json.Unmarshal(params[1], &block); err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, block)
+			if method == "eth_call" {
+				return "0x01", nil
+			}
+			return "0x1", nil
+		case "eth_getBlockByHash":
+			var requested common.Hash
+			if err := json.Unmarshal(params[0], &requested); err != nil {
+				return nil, err
+			}
+			if requested != hash {
+				return nil, nil
+			}
+			return map[string]interface{}{
+				"parentHash":       common.Hash{},
+				"sha3Uncles":       common.Hash{},
+				"miner":            common.Address{},
+				"stateRoot":        common.Hash{},
+				"transactionsRoot": common.Hash{},
+				"receiptsRoot":     common.Hash{},
+				"logsBloom":        "0x" + strings.Repeat("00", 256),
+				"difficulty":       "0x1",
+				"number":           "0x4c4b40",
+				"gasLimit":         "0x1",
+				"gasUsed":          "0x0",
+				"timestamp":        "0x0",
+				"extraData":        "0x",
+				"mixHash":          common.Hash{},
+				"nonce":            "0x0000000000000000",
+			}, nil
+		}
+		return nil, fmt.Errorf("unsupported method %s", method)
+	})
+	defer server.Close()
+
+	var tests = []struct {
+		block string
+		param string
+	}{
+		{"latest", "latest"},
+		{"pending", "pending"},
+		{"earliest", "0x0"},
+		{"1000", "0x3e8"},
+		{hash.Hex(), "0x4c4b40"},
+	}
+	for _, test := range tests {
+		tag, err := ParseBlockTag(test.block)
+		if err != nil {
+			t.Fatalf("%s: failed to parse block: %v", test.block, err)
+		}
+		if err := tag.Resolve(cli); err != nil {
+			t.Fatalf("%s: failed to resolve block: %v", test.block, err)
+		}
+		blocks = nil
+		if _, err := call(cli, tag, &ethereum.CallMsg{To: &account}); err != nil {
+			t.Fatalf("%s: failed to call: %v", test.block, err)
+		}
+		if balance, err := balanceAt(cli, tag, account); err != nil || balance.Cmp(big.NewInt(1)) != 0 {
+			t.Fatalf("%s: balance mismatch, got %v, %v", test.block, balance, err)
+		}
+		if len(blocks) != 2 || blocks[0] != test.param || blocks[1] != test.param {
+			t.Errorf("%s: block parameter mismatch, want %s, got %v", test.block, test.param, blocks)
+		}
+	}
+	tag, _ := ParseBlockTag("0x" + strings.Repeat("11", 32))
+	if err := tag.Resolve(cli); err == nil {
+		t.Errorf("expected error for unknown block hash")
+	}
+	// The unresolved hash must not fall back to the latest block
+	if err := tag.Resolve(nil); err == nil {
+		t.Errorf("expected error for resolving block without client")
+	}
+	if _, err := call(cli, tag, &ethereum.CallMsg{To: &account}); err == nil || !strings.HasPrefix(err.Error(), errUnresolvedBlock.Error()) {
+		t.Errorf("error mismatch, want %v, got %v", errUnresolvedBlock, err)
+	}
+}
+
+func TestMacroBlockSelection(t *testing.T) {
+	var (
+		sender   = "0xadd0354d4f5c101685509001053730417321db49"
+		receiver = "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+		token    = common.HexToAddress("0xe10f51424adbead82eb4b9ae72c29828dc24188f")
+		blocks   []string
+	)
+	server, cli := newTestRPCServer(t, func(method string, params []json.RawMessage) (interface{}, error) {
+		var block string
+		if err := json.Unmarshal(params[1], &block); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+		switch method {
+		case "eth_call":
+			return "0x" + abiTestWord("14"), nil
+		case "eth_getBalance":
+			return "0x14", nil
+		}
+		return nil, fmt.Errorf("unsupported method %s", method)
+	})
+	defer server.Close()
+
+	parser := NewMacroParser(cli, newTokenRegistry(0, []Token{
+		{Address: token.Hex(), Symbol: "RDN", Decimal: 0},
+	}))
+	parser.block, _ = ParseBlockTag("16")
+
+	// The write macros are sized with the latest state
+	blocks = nil
+	if _, _, _, err := parser.Parse("#TRANSFER RDN 50%", sender, receiver, 0); err != nil {
+		t.Fatalf("failed to parse macro: %v", err)
+	}
+	if _, err := parser.EvaluateValue("$ETHBALANCE / 2", sender, receiver, 0); err != nil {
+		t.Fatalf("failed to evaluate value: %v", err)
+	}
+	if len(blocks) != 2 || blocks[0] != "latest" || blocks[1] != "latest" {
+		t.Errorf("write macro block mismatch, want latest, got %v", blocks)
+	}
+	// The read-only macros are executed at the block
+	blocks = nil
+	result, err := parser.Query("#BALANCEOF RDN $RECEIVER", sender, receiver, 0)
+	if err != nil {
+		t.Fatalf("failed to query macro: %v", err)
+	}
+	if result != "20" {
+		t.Errorf("result mismatch, want 20, got %s", result)
+	}
+	if len(blocks) != 1 || blocks[0] != "0x10" {
+		t.Errorf("read macro block mismatch, want 0x10, got %v", blocks)
+	}
+}
@@ -15,7 +15,14 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"math/big"
+	"os"
+	"text/tabwriter"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -24,16 +31,40 @@ import (
 	"gopkg.in/urfave/cli.v1"
 )
 
+var errConflictData = errors.New("--data can't be used with --method")
+
+var (
+	callMethodFlag = cli.StringFlag{
+		Name:  "method",
+		Usage: "method name in the --abi files, or the function signature with return types, e.g. \"balanceOf(address) returns (uint256)\"",
+	}
+	callArgsFlag = cli.StringSliceFlag{
+		Name:  "args",
+		Usage: "method argument in order, can be repeated. The arguments after the flags are appended",
+	}
+	callJSONFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "print the decoded result in json format",
+	}
+)
+
 var commandCall = cli.Command{
 	Name:        "call",
 	Usage:       "Execute a message call transaction in the remote node's VM",
-	Description: "Call ethereum smart contract in the connected remote node without leaving trace on the blockchain",
+	ArgsUsage:   "[<argument>...]",
+	Description: "Call ethereum smart contract in the connected remote node without leaving trace on the blockchain. The input is encoded and the result is decoded by the abi if --method is specified",
 	Flags: []cli.Flag{
 		clientFlag,
 		senderFlag,
 		receiverFlag,
 		valueFlag,
 		dataFlag,
+		abiFlag,
+		sigdbFlag,
+		blockFlag,
+		callMethodFlag,
+		callArgsFlag,
+		callJSONFlag,
 	},
 	Action: Call,
 }
@@ -45,8 +76,16 @@ func Call(ctx *cli.Context) error {
 		value    = ctx.Int(valueFlag.Name)
 		data     = ctx.String(dataFlag.Name)
 	)
+	functions, err := getCallFunctions(ctx)
+	if err != nil {
+		return err
+	}
+	fn, input, err := packCall(functions, ctx.String(callMethodFlag.Name), append(ctx.StringSlice(callArgsFlag.Name), ctx.Args()...), data)
+	if err != nil {
+		return err
+	}
 	// Construct call message
-	if !CheckArguments(sender, receiver, value, common.FromHex(data)) {
+	if !CheckArguments(sender, receiver, value, input) {
 		return errInvalidArguments
 	}
 	to := common.HexToAddress(receiver)
@@ -54,7 +93,7 @@ func Call(ctx *cli.Context) error {
 		From:  common.HexToAddress(sender),
 		To:    &to,
 		Value: big.NewInt(int64(value)),
-		Data:  common.FromHex(data),
+		Data:  input,
 	}
 
 	// Setup rpc client
@@ -63,16 +102,110 @@ func Call(ctx *cli.Context) error {
 		return err
 	}
 
-	result, err := call(client, callMsg)
+	block, err := getBlockTag(ctx, client)
+	if err != nil {
+		return err
+	}
+	result, err := call(client, block, callMsg)
 	if err != nil {
 		logger.Error(err)
-	} else {
+		return nil
+	}
+	if fn == nil || len(fn.Outputs) == 0 {
 		logger.Noticef("Result=%s", common.Bytes2Hex(result))
+		return nil
 	}
-	return nil
+	values, err := fn.UnpackOutput(result)
+	if err != nil {
+		logger.Errorf("Failed to decode the result of %s: %v", fn.Sig(), err)
+		logger.Noticef("Result=%s", common.Bytes2Hex(result))
+		return nil
+	}
+	return printCallResult(os.Stdout, fn, values, ctx.Bool(callJSONFlag.Name))
 }
 
-func call(client *client.Client, callMsg *ethereum.CallMsg) ([]byte, error) {
+// getCallFunctions returns the functions in the abi files, or the ones in the
+// signature database if no abi is specified.
+func getCallFunctions(ctx *cli.Context) ([]*abiFunction, error) {
+	def, err := getABIDefinition(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(def.Functions) > 0 {
+		return def.Functions, nil
+	}
+	db, err := getSignatureDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return db.Functions(), nil
+}
+
+// packCall returns the invocation data of method with the arguments, or the
+// given data if the method is not specified. The function is used to decode
+// the result, it's looked up by the selector of data if the abi is given.
+func packCall(functions []*abiFunction, method string, args []string, data string) (*abiFunction, []byte, error) {
+	if method == "" {
+		input := common.FromHex(data)
+		if len(input) >= 4 {
+			for _, fn := range functions {
+				if bytes.Equal(fn.Selector(), input[:4]) {
+					return fn, input, nil
+				}
+			}
+		}
+		return nil, input, nil
+	}
+	if data != "" {
+		return nil, nil, errConflictData
+	}
+	fn, err := findABIFunction(functions, method, len(args))
+	if err != nil {
+		return nil, nil, err
+	}
+	input, err := fn.PackString(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", fn.Sig(), err)
+	}
+	return fn, input, nil
+}
+
+// callOutput is the decoded return value of call.
+type callOutput struct {
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// printCallResult prints the decoded return values, one value per line in
+// text format, or an array of named and typed values in json format.
+func printCallResult(out io.Writer, fn *abiFunction, values []interface{}, asJSON bool) error {
+	if asJSON {
+		outputs := make([]callOutput, len(values))
+		for idx, value := range values {
+			outputs[idx] = callOutput{Name: fn.OutputNames[idx], Type: fn.Outputs[idx].String(), Value: jsonABIValue(fn.Outputs[idx], value)}
+		}
+		encoded, err := json.MarshalIndent(outputs, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s\n", encoded)
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	printDecodedValues(w, fn.Outputs, fn.OutputNames, values, nil, -1)
+	return w.Flush()
+}
+
+// call executes the message call at the block, nil for the latest.
+func call(client *client.Client, block *BlockTag, callMsg *ethereum.CallMsg) ([]byte, error) {
 	ctx, _ := makeTimeoutContext(5 * time.Second)
-	return client.Cli.CallContract(ctx, *callMsg, nil)
+	if block != nil && block.Pending {
+		return client.Cli.PendingCallContract(ctx, *callMsg)
+	}
+	number, err := block.number()
+	if err != nil {
+		return nil, err
+	}
+	return client.Cli.CallContract(ctx, *callMsg, number)
 }
@@ -0,0 +1,130 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPackCall(t *testing.T) {
+	functions, err := parseABIFunctions([]byte(`[
+		"function balanceOf(address owner) view returns (uint256 balance)",
+		"function transfer(address to, uint256 value) returns (bool)",
+		"function transfer(address to, uint256 value, bytes data) returns (bool)"
+	]`))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	holder := "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+	var tests = []struct {
+		method string
+		args   []string
+		data   string
+		sig    string // Signature of function used to decode, empty means no decoding
+		input  string
+		err    error
+	}{
+		{"balanceOf", []string{holder}, "", "balanceOf(address)", "70a08231" + abiTestWord(holder[2:]), nil},
+		{"transfer", []string{holder, "1.5ether"}, "", "transfer(address,uint256)", "a9059cbb" + abiTestWord(holder[2:]) + abiTestWord("14d1120d7b160000"), nil},
+		{"transfer(address,uint256)", []string{holder, "1"}, "", "transfer(address,uint256)", "a9059cbb" + abiTestWord(holder[2:]) + abiTestWord("1"), nil},
+		{"totalSupply() returns (uint256 supply)", nil, "", "totalSupply()", "18160ddd", nil},
+		{"", nil, "0x70a08231" + abiTestWord(holder[2:]), "balanceOf(address)", "70a08231" + abiTestWord(holder[2:]), nil},
+		{"", nil, "0x18160ddd", "", "18160ddd", nil},
+		{"balanceOf", []string{holder}, "0x18160ddd", "", "", errConflictData},
+		{"allowance", []string{holder, holder}, "", "", "", errUnknownABIMethod},
+		{"balanceOf", []string{"0x1234"}, "", "", "", errors.New("balanceOf(address): argument 0")},
+	}
+	for _, test := range tests {
+		fn, input, err := packCall(functions, test.method, test.args, test.data)
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.method, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if common.Bytes2Hex(input) != test.input {
+			t.Errorf("%s: input mismatch, want %s, got %x", test.method, test.input, input)
+		}
+		if (fn == nil && test.sig != "") || (fn != nil && fn.Sig() != test.sig) {
+			t.Errorf("%s: function mismatch, want %s, got %v", test.method, test.sig, fn)
+		}
+	}
+}
+
+func TestCallResult(t *testing.T) {
+	var (
+		pair   = common.HexToAddress("0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc")
+		output = abiTestWord("3e8") + abiTestWord("7d0") + abiTestWord("5f5e100")
+	)
+	server, cli := newTestRPCServer(t, testCallHandler(map[common.Address]map[string]string{
+		pair: {"0902f1ac": output},
+	}))
+	defer server.Close()
+
+	fn, input, err := packCall(nil, "getReserves() view returns (uint112 reserve0, uint112 reserve1, uint32)", nil, "")
+	if err != nil {
+		t.Fatalf("failed to pack call: %v", err)
+	}
+	result, err := call(cli, nil, &ethereum.CallMsg{To: &pair, Data: input})
+	if err != nil {
+		t.Fatalf("failed to call: %v", err)
+	}
+	values, err := fn.UnpackOutput(result)
+	if err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	var text bytes.Buffer
+	if err := printCallResult(&text, fn, values, false); err != nil {
+		t.Fatalf("failed to print result: %v", err)
+	}
+	if expect := "reserve0  uint112  1000\nreserve1  uint112  2000\n2         uint32   100000000\n"; text.String() != expect {
+		t.Errorf("text result mismatch, want:\n%s\ngot:\n%s", expect, text.String())
+	}
+	var encoded bytes.Buffer
+	if err := printCallResult(&encoded, fn, values, true); err != nil {
+		t.Fatalf("failed to print result: %v", err)
+	}
+	expect := `[
+  {
+    "name": "reserve0",
+    "type": "uint112",
+    "value": "1000"
+  },
+  {
+    "name": "reserve1",
+    "type": "uint112",
+    "value": "2000"
+  },
+  {
+    "type": "uint32",
+    "value": "100000000"
+  }
+]
+`
+	if encoded.String() != expect {
+		t.Errorf("json result mismatch, want:\n%s\ngot:\n%s", expect, encoded.String())
+	}
+	// The truncated result can't be decoded
+	if _, err := fn.UnpackOutput(result[:64]); err == nil {
+		t.Errorf("truncated result: expected error")
+	}
+}
@@ -17,13 +17,16 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/manifoldco/promptui"
 	"github.com/rjl493456442/ethclient/client"
 	"gopkg.in/urfave/cli.v1"
@@ -38,6 +41,10 @@ var (
 		Name:  "password",
 		Usage: "keyfile associated passphrase",
 	}
+	passphraseMapFlag = cli.StringFlag{
+		Name:  "passwordmap",
+		Usage: "the file that maps account addresses and labels to passphrases, one entry per line",
+	}
 	keystoreFlag = cli.StringFlag{
 		Name:  "keystore",
 		Usage: "keystore directory path",
@@ -63,6 +70,10 @@ var (
 		Name:  "data",
 		Usage: "contract invocation payload",
 	}
+	gasPriceFlag = cli.StringFlag{
+		Name:  "gasprice",
+		Usage: "transaction gas price, the unit can be given as suffix, e.g. 20gwei. If not specified, the suggested gas price of node is used",
+	}
 	syncFlag = cli.BoolFlag{
 		Name:  "sync",
 		Usage: "wait until the sending transaction been mined",
@@ -85,9 +96,47 @@ var (
 		Name:  "sheet",
 		Usage: "excel file sheet id",
 	}
+	formatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "batch file format(" + strings.Join(BatchFormatNames(), ", ") + "). If not specified, detect by the file extension and content",
+	}
+	outputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "result file path, the format is decided by the file extension. If not specified, <batchfile>.results.<ext> is used",
+	}
+	outputFormatFlag = cli.StringFlag{
+		Name:  "outputformat",
+		Usage: "result file format(" + strings.Join(BatchFormatNames(), ", ") + "). If not specified, detect by the file extension",
+	}
+	inplaceFlag = cli.BoolFlag{
+		Name:  "inplace",
+		Usage: "write the transaction hashes back to the batch file instead of a separate result file",
+	}
 	tokenfileFlag = cli.StringFlag{
 		Name:  "tokenfile",
-		Usage: "customized token file path which in json format",
+		Usage: "customized token file path which in json format, merged after the token registry",
+	}
+	tokenDirFlag = cli.StringFlag{
+		Name:  "tokendir",
+		Usage: "token registry directory, the token lists are kept in per chain id sub directories",
+		Value: "tokens",
+	}
+	chainIdFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "chain id of the token registry. If not specified, the network id of the connected node is used",
+	}
+	abiFlag = cli.StringSliceFlag{
+		Name:  "abi",
+		Usage: "contract abi file, which can be json abi, truffle/hardhat artifact or human-readable signatures, or a single human-readable signature. Its methods can be referenced by name, can be repeated",
+	}
+	macroFileFlag = cli.StringSliceFlag{
+		Name:  "macrofile",
+		Usage: "user macro definition file in json format, can be repeated",
+	}
+	blockFlag = cli.StringFlag{
+		Name:  "block",
+		Usage: "block which the calls and balance queries are executed on: number, hash, latest, pending or earliest",
+		Value: "latest",
 	}
 )
 
@@ -173,6 +222,21 @@ func getPassphrase(ctx *cli.Context, confirmation bool) string {
 	return passphrase
 }
 
+// getPassphraseResolver returns a resolver for the passphrase fields of batch file.
+func getPassphraseResolver(ctx *cli.Context) (*passphraseResolver, error) {
+	var labels map[string]string
+	if path := ctx.String(passphraseMapFlag.Name); path != "" {
+		var err error
+		if labels, err = loadPassphraseMap(path); err != nil {
+			return nil, err
+		}
+	}
+	return newPassphraseResolver(labels, func(sender common.Address) string {
+		logger.Noticef("Passphrase required for %s", sender.Hex())
+		return getPassphrase(ctx, false)
+	}), nil
+}
+
 // getClient returns a remote client connected to specified ethereum server.
 func getClient(ctx *cli.Context) (*client.Client, error) {
 	url := ctx.String(clientFlag.Name)
@@ -200,8 +264,113 @@ func getBatchFile(ctx *cli.Context) string {
 }
 
 // getMacroParser returns a macro definition parser.
-func getMacroParser(client *client.Client, path string) (*MacroParser, error) {
-	return NewMacroParser(client, path)
+func getMacroParser(ctx *cli.Context, client *client.Client) (*MacroParser, error) {
+	registry, err := getTokenRegistry(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	mp := NewMacroParser(client, registry)
+	if mp.block, err = getBlockTag(ctx, client); err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+// getBlockTag returns the block specified in command line, the block hash is
+// resolved by the client.
+func getBlockTag(ctx *cli.Context, client *client.Client) (*BlockTag, error) {
+	block, err := ParseBlockTag(ctx.String(blockFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	if err := block.Resolve(client); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// getTokenRegistry opens the token registry of chain, the chain id is queried
+// from the connected node if it's not specified.
+func getTokenRegistry(ctx *cli.Context, client *client.Client) (*TokenRegistry, error) {
+	chainId := ctx.Uint64(chainIdFlag.Name)
+	if chainId == 0 {
+		if client == nil {
+			return nil, errNoChainId
+		}
+		id, err := getChainId(client)
+		if err != nil {
+			return nil, err
+		}
+		chainId = id.Uint64()
+	}
+	var extra []string
+	if path := ctx.String(tokenfileFlag.Name); path != "" {
+		extra = append(extra, path)
+	}
+	return OpenTokenRegistry(ctx.String(tokenDirFlag.Name), chainId, extra...)
+}
+
+// setupMacroParser loads the contract abi files and user macro files specified
+// in command line into macro parser.
+func setupMacroParser(ctx *cli.Context, mp *MacroParser) error {
+	for _, path := range ctx.StringSlice(abiFlag.Name) {
+		if err := mp.LoadABI(path); err != nil {
+			return err
+		}
+	}
+	for _, path := range ctx.StringSlice(macroFileFlag.Name) {
+		if err := mp.LoadMacros(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getABIDefinition loads the abi files specified in command line, the functions
+// are merged and the first constructor is used. The human-readable signature
+// can be given in place of file, e.g. --abi "balanceOf(address) returns (uint256)".
+func getABIDefinition(ctx *cli.Context) (*abiDefinition, error) {
+	merged := new(abiDefinition)
+	for _, path := range ctx.StringSlice(abiFlag.Name) {
+		var (
+			def = new(abiDefinition)
+			err error
+		)
+		if _, statErr := os.Stat(path); statErr != nil && strings.Contains(path, "(") {
+			err = def.addSignatures([]string{path})
+		} else {
+			def, err = loadABIDefinition(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		merged.Functions = append(merged.Functions, def.Functions...)
+		merged.Events = append(merged.Events, def.Events...)
+		if merged.Constructor == nil {
+			merged.Constructor = def.Constructor
+		}
+		if merged.Bytecode == "" {
+			merged.Bytecode = def.Bytecode
+		}
+	}
+	return merged, nil
+}
+
+// getGasPrice returns the gas price specified in command line, nil means the
+// suggested gas price should be used.
+func getGasPrice(ctx *cli.Context) (*big.Int, error) {
+	price := ctx.String(gasPriceFlag.Name)
+	if price == "" {
+		return nil, nil
+	}
+	gasPrice, err := parseABIInteger(price)
+	if err != nil {
+		return nil, err
+	}
+	if gasPrice.Sign() < 0 {
+		return nil, fmt.Errorf("negative gas price %s", price)
+	}
+	return gasPrice, nil
 }
 
 // getSheetId returns excel sheet id from command line input.
@@ -0,0 +1,436 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rjl493456442/ethclient/client"
+	"github.com/rjl493456442/ethclient/resource"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	errNoDecodeInput    = errors.New("no data to decode, specify the hex data or --tx")
+	errContractCreation = errors.New("the transaction creates contract, its input is not calldata")
+	errOutputInTx       = errors.New("the return data is not recorded in transaction, specify it in hex")
+	errNoDecodeMethod   = errors.New("no method specified, use --method")
+	errNoReturnTypes    = errors.New("no return types")
+)
+
+var (
+	decodeTxFlag = cli.StringFlag{
+		Name:  "tx",
+		Usage: "transaction hash, whose input or logs are fetched from the node",
+	}
+	decodeContractFlag = cli.StringFlag{
+		Name:  "contract",
+		Usage: "contract address of the data, the token amounts are scaled if it's a known token",
+	}
+	decodeTopicFlag = cli.StringSliceFlag{
+		Name:  "topic",
+		Usage: "log topic in order, can be repeated",
+	}
+)
+
+// decodeFlags are the flags shared by the decode sub commands.
+var decodeFlags = []cli.Flag{
+	clientFlag,
+	abiFlag,
+	sigdbFlag,
+	decodeContractFlag,
+	tokenfileFlag,
+	tokenDirFlag,
+	chainIdFlag,
+}
+
+var commandDecode = cli.Command{
+	Name:  "decode",
+	Usage: "Decode the contract invocation data, return data and event logs",
+	Subcommands: []cli.Command{
+		{
+			Name:        "calldata",
+			Usage:       "Decode the invocation data",
+			ArgsUsage:   "<hex data>",
+			Description: "Decode the invocation data given in hex or the input of transaction by --tx. The function is matched by selector in the --abi files and the standard token interfaces",
+			Flags:       append([]cli.Flag{decodeTxFlag}, decodeFlags...),
+			Action:      DecodeCalldata,
+		},
+		{
+			Name:        "output",
+			Usage:       "Decode the return data of method",
+			ArgsUsage:   "<hex data>",
+			Description: "Decode the return data of method specified by --method, which is the name in the --abi files or the signature with return types",
+			Flags:       append([]cli.Flag{callMethodFlag}, decodeFlags...),
+			Action:      DecodeOutput,
+		},
+		{
+			Name:        "log",
+			Usage:       "Decode the event logs",
+			ArgsUsage:   "[<hex data>]",
+			Description: "Decode the event log given by --topic and data in hex, or all logs of transaction by --tx. The event is matched by the first topic in the --abi files and the standard token interfaces",
+			Flags:       append([]cli.Flag{decodeTxFlag, decodeTopicFlag}, decodeFlags...),
+			Action:      DecodeLog,
+		},
+	},
+}
+
+// builtinABI is the standard token interfaces, which are used to decode the
+// invocation data and logs without abi files.
+var builtinABI = mustParseABIDefinition(resource.ERC20InterfaceABI, resource.ERC721InterfaceABI, resource.ERC1155InterfaceABI)
+
+func mustParseABIDefinition(definitions ...string) *abiDefinition {
+	merged := new(abiDefinition)
+	for _, definition := range definitions {
+		def, err := parseABIDefinition([]byte(definition))
+		if err != nil {
+			panic(err)
+		}
+		merged.Functions = append(merged.Functions, def.Functions...)
+		merged.Events = append(merged.Events, def.Events...)
+	}
+	return merged
+}
+
+// tokenAmountArguments are the indexes of token amount in the ERC20 functions
+// and events, which are scaled by the token decimals.
+var tokenAmountArguments = map[string]int{
+	"transfer(address,uint256)":             1,
+	"transferFrom(address,address,uint256)": 2,
+	"approve(address,uint256)":              1,
+	"Transfer(address,address,uint256)":     2,
+	"Approval(address,address,uint256)":     2,
+}
+
+// tokenAmountOutputs are the ERC20 functions returning token amount.
+var tokenAmountOutputs = map[string]bool{
+	"balanceOf(address)":         true,
+	"allowance(address,address)": true,
+	"totalSupply()":              true,
+}
+
+// decoder decodes the data with the abi files and the standard token interfaces.
+type decoder struct {
+	functions []*abiFunction
+	events    []*abiEvent
+	registry  *TokenRegistry // Nil means the token amounts are not scaled
+}
+
+// newDecoder returns the decoder with the abi files in command line. The token
+// registry is opened if the network is known by --url or --chainid.
+func newDecoder(ctx *cli.Context, client *client.Client) (*decoder, error) {
+	def, err := getABIDefinition(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d := &decoder{
+		functions: append(def.Functions, builtinABI.Functions...),
+		events:    append(def.Events, builtinABI.Events...),
+	}
+	// The signature database is used if no abi is specified
+	if len(def.Functions) == 0 && len(def.Events) == 0 {
+		db, err := getSignatureDB(ctx)
+		if err != nil {
+			return nil, err
+		}
+		d.functions, d.events = db.Functions(), db.Events()
+	}
+	if client != nil || ctx.IsSet(chainIdFlag.Name) {
+		if d.registry, err = getTokenRegistry(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// getDecodeClient returns the client if --url is specified, otherwise nil.
+func getDecodeClient(ctx *cli.Context) (*client.Client, error) {
+	if ctx.String(clientFlag.Name) == "" {
+		return nil, nil
+	}
+	return getClient(ctx)
+}
+
+// DecodeCalldata decodes the invocation data.
+func DecodeCalldata(ctx *cli.Context) error {
+	client, err := getDecodeClient(ctx)
+	if err != nil {
+		return err
+	}
+	d, err := newDecoder(ctx, client)
+	if err != nil {
+		return err
+	}
+	var (
+		contract = ctx.String(decodeContractFlag.Name)
+		calldata []byte
+	)
+	if hash := ctx.String(decodeTxFlag.Name); hash != "" {
+		if client == nil {
+			return errNoClient
+		}
+		timeout, _ := makeTimeoutContext(5 * time.Second)
+		tx, _, err := client.Cli.TransactionByHash(timeout, common.HexToHash(hash))
+		if err != nil {
+			return err
+		}
+		if tx.To() == nil {
+			return errContractCreation
+		}
+		calldata, contract = tx.Data(), tx.To().Hex()
+	} else if ctx.NArg() == 1 {
+		if calldata, err = decodeHexArgument(ctx.Args().First()); err != nil {
+			return err
+		}
+	} else {
+		return errNoDecodeInput
+	}
+	return d.printCalldata(os.Stdout, contract, calldata)
+}
+
+// DecodeOutput decodes the return data of method.
+func DecodeOutput(ctx *cli.Context) error {
+	if ctx.IsSet(decodeTxFlag.Name) {
+		return errOutputInTx
+	}
+	client, err := getDecodeClient(ctx)
+	if err != nil {
+		return err
+	}
+	d, err := newDecoder(ctx, client)
+	if err != nil {
+		return err
+	}
+	if ctx.NArg() != 1 {
+		return errNoDecodeInput
+	}
+	output, err := decodeHexArgument(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	return d.printOutput(os.Stdout, ctx.String(decodeContractFlag.Name), ctx.String(callMethodFlag.Name), output)
+}
+
+// DecodeLog decodes the event log in command line or the logs of transaction.
+func DecodeLog(ctx *cli.Context) error {
+	client, err := getDecodeClient(ctx)
+	if err != nil {
+		return err
+	}
+	d, err := newDecoder(ctx, client)
+	if err != nil {
+		return err
+	}
+	var logs []*types.Log
+	if hash := ctx.String(decodeTxFlag.Name); hash != "" {
+		if client == nil {
+			return errNoClient
+		}
+		timeout, _ := makeTimeoutContext(5 * time.Second)
+		receipt, err := client.Cli.TransactionReceipt(timeout, common.HexToHash(hash))
+		if err != nil {
+			return err
+		}
+		logs = receipt.Logs
+	} else {
+		log := &types.Log{Address: common.HexToAddress(ctx.String(decodeContractFlag.Name))}
+		for _, topic := range ctx.StringSlice(decodeTopicFlag.Name) {
+			log.Topics = append(log.Topics, common.HexToHash(topic))
+		}
+		if ctx.NArg() == 1 {
+			if log.Data, err = decodeHexArgument(ctx.Args().First()); err != nil {
+				return err
+			}
+		}
+		if len(log.Topics) == 0 && len(log.Data) == 0 {
+			return errNoDecodeInput
+		}
+		logs = append(logs, log)
+	}
+	for idx, log := range logs {
+		if idx > 0 {
+			fmt.Println()
+		}
+		if err := d.printLog(os.Stdout, log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeHexArgument decodes the hex data in command line.
+func decodeHexArgument(arg string) ([]byte, error) {
+	data, err := hexDecode(strings.TrimPrefix(strings.TrimSpace(arg), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex data %s", arg)
+	}
+	return data, nil
+}
+
+// token returns the token at the contract address for scaling the amounts,
+// nil is returned if it's unknown or has no decimals.
+func (d *decoder) token(contract string) *Token {
+	if d.registry == nil || !common.IsHexAddress(contract) {
+		return nil
+	}
+	token, err := d.registry.Lookup(contract)
+	if err != nil || token.Decimal == 0 {
+		return nil
+	}
+	return &token
+}
+
+// printCalldata prints the function and arguments of invocation data.
+func (d *decoder) printCalldata(out io.Writer, contract string, calldata []byte) error {
+	fn, values, err := decodeCalldata(d.functions, calldata)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	if common.IsHexAddress(contract) {
+		fmt.Fprintf(w, "Contract:\t%s\n", common.HexToAddress(contract).Hex())
+	}
+	fmt.Fprintf(w, "Method:\t%s\n", fn.Sig())
+	amount, exist := tokenAmountArguments[fn.Sig()]
+	if !exist {
+		amount = -1
+	}
+	printDecodedValues(w, fn.Inputs, fn.InputNames, values, d.token(contract), amount)
+	return w.Flush()
+}
+
+// printOutput prints the return values of method.
+func (d *decoder) printOutput(out io.Writer, contract string, method string, output []byte) error {
+	fn, err := d.outputFunction(method)
+	if err != nil {
+		return err
+	}
+	values, err := fn.UnpackOutput(output)
+	if err != nil {
+		return err
+	}
+	amount := -1
+	if tokenAmountOutputs[fn.Sig()] {
+		amount = 0
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	printDecodedValues(w, fn.Outputs, fn.OutputNames, values, d.token(contract), amount)
+	return w.Flush()
+}
+
+// outputFunction returns the function whose return data is decoded. The
+// overloaded methods are accepted if their return types are the same.
+func (d *decoder) outputFunction(method string) (*abiFunction, error) {
+	if method == "" {
+		return nil, errNoDecodeMethod
+	}
+	var fn *abiFunction
+	if strings.Contains(method, "(") {
+		var err error
+		if fn, err = findABIFunction(d.functions, method, 0); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, candidate := range d.functions {
+			if candidate.Name != method {
+				continue
+			}
+			if fn != nil && (&abiFunction{Inputs: fn.Outputs}).Sig() != (&abiFunction{Inputs: candidate.Outputs}).Sig() {
+				return nil, fmt.Errorf("%v %s, use the function signature instead", errAmbiguousABIMethod, method)
+			}
+			if fn == nil {
+				fn = candidate
+			}
+		}
+		if fn == nil {
+			return nil, fmt.Errorf("%v %s", errUnknownABIMethod, method)
+		}
+	}
+	if len(fn.Outputs) == 0 {
+		return nil, fmt.Errorf("%v of %s, specify them like \"%s returns (uint256)\"", errNoReturnTypes, fn.Sig(), fn.Sig())
+	}
+	return fn, nil
+}
+
+// printLog prints the event and arguments of log, the unknown log is printed
+// in raw.
+func (d *decoder) printLog(out io.Writer, log *types.Log) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	if log.Address != (common.Address{}) {
+		fmt.Fprintf(w, "Contract:\t%s\n", log.Address.Hex())
+	}
+	ev, inputs, values, err := d.decodeLog(log)
+	if err != nil {
+		fmt.Fprintf(w, "Event:\tunknown\n")
+		for idx, topic := range log.Topics {
+			fmt.Fprintf(w, "Topic %d:\t%s\n", idx, topic.Hex())
+		}
+		fmt.Fprintf(w, "Data:\t0x%x\n", log.Data)
+		return w.Flush()
+	}
+	fmt.Fprintf(w, "Event:\t%s\n", ev.Sig())
+	amount, exist := tokenAmountArguments[ev.Sig()]
+	if !exist || ev.Indexed[amount] {
+		amount = -1
+	}
+	printDecodedValues(w, inputs, ev.Names, values, d.token(log.Address.Hex()), amount)
+	return w.Flush()
+}
+
+// decodeLog decodes the log with the first event whose topic and indexed
+// arguments match, the anonymous events are tried at last.
+func (d *decoder) decodeLog(log *types.Log) (*abiEvent, []*abiType, []interface{}, error) {
+	for _, anonymous := range []bool{false, true} {
+		for _, ev := range d.events {
+			if ev.Anonymous != anonymous {
+				continue
+			}
+			inputs, values, err := ev.UnpackLog(log.Topics, log.Data)
+			if err == nil {
+				return ev, inputs, values, nil
+			}
+		}
+	}
+	if len(log.Topics) == 0 {
+		return nil, nil, nil, errUnknownABITopic
+	}
+	return nil, nil, nil, fmt.Errorf("%v %s", errUnknownABITopic, log.Topics[0].Hex())
+}
+
+// printDecodedValues prints the values one per line with name and type. The
+// token amount at given index is scaled if the token is known.
+func printDecodedValues(w io.Writer, inputs []*abiType, names []string, values []interface{}, token *Token, amount int) {
+	for idx, value := range values {
+		name := names[idx]
+		if name == "" {
+			name = strconv.Itoa(idx)
+		}
+		text := formatABIValue(inputs[idx], value)
+		if token != nil && idx == amount {
+			text = fmt.Sprintf("%s %s (%s)", formatAmount(value.(*big.Int), token.Decimal), token.Symbol, text)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, inputs[idx], text)
+	}
+}
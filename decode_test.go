@@ -0,0 +1,149 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// newTestDecoder returns the decoder with the standard token interfaces, the
+// extra abi and a token registry containing RDN.
+func newTestDecoder(t *testing.T, abi string) *decoder {
+	def, err := parseABIDefinition([]byte(abi))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	return &decoder{
+		functions: append(def.Functions, builtinABI.Functions...),
+		events:    append(def.Events, builtinABI.Events...),
+		registry: newTokenRegistry(0, []Token{
+			{Address: "0x255aa6df07540cb5d3d297f0d0d4d84cb52bc8e6", Symbol: "RDN", Decimal: 18},
+		}),
+	}
+}
+
+func TestDecodeCalldata(t *testing.T) {
+	var (
+		d        = newTestDecoder(t, `["function setName(string name, uint8[] flags)"]`)
+		rdn      = "0x255aa6df07540cb5d3d297f0d0d4d84cb52bc8e6"
+		receiver = "8f0909ccb296ebd319834edb0d5785794b781d7f"
+	)
+	var tests = []struct {
+		contract string
+		calldata string
+		expect   string
+		err      error
+	}{
+		{rdn, "a9059cbb" + abiTestWord(receiver) + abiTestWord("14d1120d7b160000"),
+			"Contract:  0x255Aa6DF07540Cb5d3d297f0D0D4D84cb52bc8e6\nMethod:    transfer(address,uint256)\nto         address  0x8f0909CCB296EBd319834EDB0d5785794B781d7f\ntokens     uint256  1.5 RDN (1500000000000000000)\n", nil},
+		{"", "a9059cbb" + abiTestWord(receiver) + abiTestWord("14d1120d7b160000"),
+			"Method:  transfer(address,uint256)\nto       address  0x8f0909CCB296EBd319834EDB0d5785794B781d7f\ntokens   uint256  1500000000000000000\n", nil},
+		{"", common.Bytes2Hex(crypto.Keccak256([]byte("setName(string,uint8[])"))[:4]) + abiTestWord("40") + abiTestWord("80") + abiTestWord("2") + "6869" + strings.Repeat("0", 60) + abiTestWord("1") + abiTestWord("7"),
+			"Method:  setName(string,uint8[])\nname     string   \"hi\"\nflags    uint8[]  [7]\n", nil},
+		{"", "12345678", "", errUnknownABISelector},
+		{"", "a9059cbb" + abiTestWord(receiver), "", errInvalidABIData},
+		{"", "a905", "", errInvalidABIData},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := d.printCalldata(&out, test.contract, common.FromHex(test.calldata))
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.calldata, test.err, err)
+			continue
+		}
+		if err == nil && out.String() != test.expect {
+			t.Errorf("%s: output mismatch, want:\n%s\ngot:\n%s", test.calldata, test.expect, out.String())
+		}
+	}
+}
+
+func TestDecodeOutput(t *testing.T) {
+	var (
+		d   = newTestDecoder(t, `["function getReserves() view returns (uint112 reserve0, uint112 reserve1, uint32)", "function name() view returns (string)", "function name(uint256) view returns (bytes32)"]`)
+		rdn = "0x255aa6df07540cb5d3d297f0d0d4d84cb52bc8e6"
+	)
+	var tests = []struct {
+		contract string
+		method   string
+		output   string
+		expect   string
+		err      error
+	}{
+		{rdn, "balanceOf", abiTestWord("de0b6b3a7640000"), "balance  uint256  1 RDN (1000000000000000000)\n", nil},
+		{"", "balanceOf", abiTestWord("de0b6b3a7640000"), "balance  uint256  1000000000000000000\n", nil},
+		{"", "getReserves", abiTestWord("1") + abiTestWord("2") + abiTestWord("3"), "reserve0  uint112  1\nreserve1  uint112  2\n2         uint32   3\n", nil},
+		{"", "decimals() returns (uint8)", abiTestWord("12"), "0  uint8  18\n", nil},
+		{"", "name", abiTestWord("20"), "", errAmbiguousABIMethod},
+		{"", "decimals()", abiTestWord("12"), "", errNoReturnTypes},
+		{"", "symbol", abiTestWord("12"), "", errUnknownABIMethod},
+		{"", "", abiTestWord("12"), "", errNoDecodeMethod},
+		{"", "getReserves", abiTestWord("1"), "", errInvalidABIData},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := d.printOutput(&out, test.contract, test.method, common.FromHex(test.output))
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.method, test.err, err)
+			continue
+		}
+		if err == nil && out.String() != test.expect {
+			t.Errorf("%s: output mismatch, want:\n%s\ngot:\n%s", test.method, test.expect, out.String())
+		}
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	var (
+		d        = newTestDecoder(t, `["event Registered(string indexed name, address indexed owner, bytes data)"]`)
+		rdn      = common.HexToAddress("0x255aa6df07540cb5d3d297f0d0d4d84cb52bc8e6")
+		sender   = common.HexToHash("0xadd0354d4f5c101685509001053730417321db49")
+		receiver = common.HexToHash("0x8f0909ccb296ebd319834edb0d5785794b781d7f")
+		transfer = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+		name     = crypto.Keccak256Hash([]byte("alice"))
+	)
+	var tests = []struct {
+		log    *types.Log
+		expect string
+	}{
+		// ERC20 transfer, the amount is scaled
+		{&types.Log{Address: rdn, Topics: []common.Hash{transfer, sender, receiver}, Data: common.FromHex(abiTestWord("14d1120d7b160000"))},
+			"Contract:  0x255Aa6DF07540Cb5d3d297f0D0D4D84cb52bc8e6\nEvent:     Transfer(address,address,uint256)\nfrom       address  0xaDD0354D4F5c101685509001053730417321DB49\nto         address  0x8f0909CCB296EBd319834EDB0d5785794B781d7f\ntokens     uint256  1.5 RDN (1500000000000000000)\n"},
+		// ERC721 transfer, the token id is indexed
+		{&types.Log{Topics: []common.Hash{transfer, sender, receiver, common.HexToHash("0x400")}},
+			"Event:   Transfer(address,address,uint256)\nfrom     address  0xaDD0354D4F5c101685509001053730417321DB49\nto       address  0x8f0909CCB296EBd319834EDB0d5785794B781d7f\ntokenId  uint256  1024\n"},
+		// Indexed string is stored as hash
+		{&types.Log{Topics: []common.Hash{crypto.Keccak256Hash([]byte("Registered(string,address,bytes)")), name, receiver}, Data: common.FromHex(abiTestWord("20") + abiTestWord("1") + "ab" + strings.Repeat("0", 62))},
+			"Event:  Registered(string,address,bytes)\nname    bytes32  " + name.Hex() + "\nowner   address  0x8f0909CCB296EBd319834EDB0d5785794B781d7f\ndata    bytes    0xab\n"},
+		// Unknown log
+		{&types.Log{Topics: []common.Hash{transfer, sender}, Data: []byte{0x1}},
+			"Event:    unknown\nTopic 0:  " + transfer.Hex() + "\nTopic 1:  " + sender.Hex() + "\nData:     0x01\n"},
+	}
+	for idx, test := range tests {
+		var out bytes.Buffer
+		if err := d.printLog(&out, test.log); err != nil {
+			t.Errorf("log %d: failed to print: %v", idx, err)
+			continue
+		}
+		if out.String() != test.expect {
+			t.Errorf("log %d: output mismatch, want:\n%s\ngot:\n%s", idx, test.expect, out.String())
+		}
+	}
+}
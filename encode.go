@@ -0,0 +1,223 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/manifoldco/promptui"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	errNoEncodeMethod     = errors.New("no method specified, use --method or --interactive")
+	errPackedConstructor  = errors.New("constructor arguments can't be encoded in packed mode")
+	errInvalidBytecode    = errors.New("invalid contract bytecode")
+	errNoABIFunctions     = errors.New("no function in the abi")
+	errUnknownABIFunction = errors.New("unknown abi function")
+)
+
+var (
+	encodeConstructorFlag = cli.BoolFlag{
+		Name:  "constructor",
+		Usage: "encode the constructor arguments, which are appended to the contract bytecode if it's available",
+	}
+	encodeBytecodeFlag = cli.StringFlag{
+		Name:  "bytecode",
+		Usage: "contract creation bytecode in hex, or the file containing it. If not specified, the bytecode in the artifact is used",
+	}
+	encodePackedFlag = cli.BoolFlag{
+		Name:  "packed",
+		Usage: "encode the arguments in the packed mode like abi.encodePacked, the selector is not included",
+	}
+	encodeInteractiveFlag = cli.BoolFlag{
+		Name:  "interactive",
+		Usage: "choose the method and input the arguments with prompts, the arguments are validated per type",
+	}
+)
+
+var commandEncode = cli.Command{
+	Name:        "encode",
+	Usage:       "Generate the contract invocation payload",
+	ArgsUsage:   "<argument>...",
+	Description: "Encode the method arguments into the invocation payload, which can be used as --data or in batch file. The arguments follow the syntax of #CALL macro",
+	Flags: []cli.Flag{
+		abiFlag,
+		callMethodFlag,
+		encodeConstructorFlag,
+		encodeBytecodeFlag,
+		encodePackedFlag,
+		encodeInteractiveFlag,
+	},
+	Action: Encode,
+}
+
+// Encode encodes the arguments of method or constructor and prints the payload.
+func Encode(ctx *cli.Context) error {
+	def, err := getABIDefinition(ctx)
+	if err != nil {
+		return err
+	}
+	var (
+		args        = []string(ctx.Args())
+		constructor = ctx.Bool(encodeConstructorFlag.Name)
+		packed      = ctx.Bool(encodePackedFlag.Name)
+		interactive = ctx.Bool(encodeInteractiveFlag.Name)
+	)
+	if constructor && packed {
+		return errPackedConstructor
+	}
+	var fn *abiFunction
+	if constructor {
+		fn = def.Constructor
+		if method := ctx.String(callMethodFlag.Name); method != "" {
+			if fn, err = parseABISignature(method); err != nil {
+				return err
+			}
+		}
+		if fn == nil {
+			// The default constructor has no arguments
+			fn = &abiFunction{Name: "constructor"}
+		}
+	} else {
+		if fn, err = selectFunction(def.Functions, ctx.String(callMethodFlag.Name), len(args), interactive); err != nil {
+			return err
+		}
+	}
+	if interactive {
+		if args, err = promptArguments(fn, args); err != nil {
+			return err
+		}
+	}
+	var encoded []byte
+	switch {
+	case constructor:
+		bytecode, err := getBytecode(ctx.String(encodeBytecodeFlag.Name), def.Bytecode)
+		if err != nil {
+			return err
+		}
+		if encoded, err = fn.PackArguments(args); err != nil {
+			return fmt.Errorf("%s: %v", fn.Sig(), err)
+		}
+		encoded = append(bytecode, encoded...)
+	case packed:
+		encoded, err = fn.PackPacked(args)
+	default:
+		encoded, err = fn.PackString(args)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", fn.Sig(), err)
+	}
+	fmt.Printf("0x%x\n", encoded)
+	return nil
+}
+
+// selectFunction returns the function with given name or signature. In the
+// interactive mode, the function is chosen by prompt if there are several
+// candidates, otherwise they're distinguished by the argument number.
+func selectFunction(functions []*abiFunction, method string, args int, interactive bool) (*abiFunction, error) {
+	if !interactive || strings.Contains(method, "(") {
+		if method == "" {
+			return nil, errNoEncodeMethod
+		}
+		return findABIFunction(functions, method, args)
+	}
+	var candidates []*abiFunction
+	for _, fn := range functions {
+		if method == "" || fn.Name == method {
+			candidates = append(candidates, fn)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		if method == "" {
+			return nil, errNoABIFunctions
+		}
+		return nil, fmt.Errorf("%v %s", errUnknownABIFunction, method)
+	case 1:
+		return candidates[0], nil
+	}
+	items := make([]string, len(candidates))
+	for idx, fn := range candidates {
+		items[idx] = fn.Sig()
+	}
+	prompt := promptui.Select{
+		Label: "Method",
+		Items: items,
+		Size:  10,
+	}
+	idx, _, err := prompt.Run()
+	if err != nil {
+		return nil, err
+	}
+	return candidates[idx], nil
+}
+
+// promptArguments prompts the arguments of function one by one, the given
+// arguments are used as the default values.
+func promptArguments(fn *abiFunction, defaults []string) ([]string, error) {
+	args := make([]string, len(fn.Inputs))
+	for idx, typ := range fn.Inputs {
+		typ := typ
+		label := "argument " + strconv.Itoa(idx)
+		if idx < len(fn.InputNames) && fn.InputNames[idx] != "" {
+			label = fn.InputNames[idx]
+		}
+		prompt := promptui.Prompt{
+			Label: fmt.Sprintf("%s (%s)", label, typ),
+			Validate: func(input string) error {
+				_, err := parseABIValue(typ, input)
+				return err
+			},
+		}
+		if idx < len(defaults) {
+			prompt.Default = defaults[idx]
+		}
+		arg, err := prompt.Run()
+		if err != nil {
+			return nil, err
+		}
+		args[idx] = arg
+	}
+	return args, nil
+}
+
+// getBytecode returns the contract bytecode given in hex or file, the one in
+// artifact is used if it's not specified.
+func getBytecode(bytecode string, artifact string) ([]byte, error) {
+	if bytecode != "" {
+		if _, err := os.Stat(bytecode); err == nil {
+			content, err := ioutil.ReadFile(bytecode)
+			if err != nil {
+				return nil, err
+			}
+			bytecode = string(content)
+		}
+	} else {
+		bytecode = artifact
+	}
+	bytecode = strings.TrimPrefix(strings.TrimSpace(bytecode), "0x")
+	code, err := hexDecode(bytecode)
+	if err != nil {
+		return nil, fmt.Errorf("%v, it must be hex and the libraries must be linked", errInvalidBytecode)
+	}
+	return common.CopyBytes(code), nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestEncodePacked(t *testing.T) {
+	var tests = []struct {
+		signature string
+		args      []string
+		expect    string
+		err       error
+	}{
+		// Example of the solidity documentation
+		{"f(int16,bytes1,uint16,string)", []string{"-1", "0x42", "0x03", "Hello, world!"}, "ffff42000348656c6c6f2c20776f726c6421", nil},
+		{"f(address,bool,bytes)", []string{"0x8f0909ccb296ebd319834edb0d5785794b781d7f", "true", "0xabcd"}, "8f0909ccb296ebd319834edb0d5785794b781d7f01abcd", nil},
+		{"f(uint8[],bytes2)", []string{"[1,2]", "0x1234"}, abiTestWord("1") + abiTestWord("2") + "1234", nil},
+		{"f(string[])", []string{`["a"]`}, "", errInvalidABIType},
+		{"f((uint256,uint256))", []string{"(1,2)"}, "", errInvalidABIType},
+		{"f(uint8[][])", []string{"[[1]]"}, "", errInvalidABIType},
+		{"f(uint8)", []string{"256"}, "", errInvalidABIType},
+	}
+	for _, test := range tests {
+		fn, err := parseABISignature(test.signature)
+		if err != nil {
+			t.Fatalf("%s: failed to parse signature: %v", test.signature, err)
+		}
+		packed, err := fn.PackPacked(test.args)
+		if test.err != nil {
+			if err == nil {
+				t.Errorf("%s %v: expected error", test.signature, test.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %v: failed to encode: %v", test.signature, test.args, err)
+			continue
+		}
+		if got := common.Bytes2Hex(packed); got != test.expect {
+			t.Errorf("%s %v: packed mismatch, want %s, got %s", test.signature, test.args, test.expect, got)
+		}
+	}
+}
+
+func TestEncodeConstructor(t *testing.T) {
+	def, err := parseABIDefinition([]byte(`{
+		"contractName": "Token",
+		"abi": [
+			{"type": "constructor", "inputs": [{"name": "symbol", "type": "string"}, {"name": "supply", "type": "uint256"}]},
+			{"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}], "outputs": [{"name": "", "type": "bool"}]}
+		],
+		"bytecode": "0x6080604052"
+	}`))
+	if err != nil {
+		t.Fatalf("failed to parse artifact: %v", err)
+	}
+	if def.Constructor == nil || def.Constructor.Sig() != "constructor(string,uint256)" || len(def.Functions) != 1 || def.Bytecode != "0x6080604052" {
+		t.Fatalf("artifact mismatch, got %+v", def)
+	}
+	bytecode, err := getBytecode("", def.Bytecode)
+	if err != nil {
+		t.Fatalf("failed to get bytecode: %v", err)
+	}
+	encoded, err := def.Constructor.PackArguments([]string{"RDN", "1e24"})
+	if err != nil {
+		t.Fatalf("failed to encode constructor arguments: %v", err)
+	}
+	expect := "6080604052" + abiTestWord("40") + abiTestWord("d3c21bcecceda1000000") + abiTestWord("3") + "52444e" + strings.Repeat("0", 58)
+	if got := common.Bytes2Hex(append(bytecode, encoded...)); got != expect {
+		t.Errorf("creation data mismatch, want %s, got %s", expect, got)
+	}
+	// The foundry artifact keeps bytecode in object
+	def, err = parseABIDefinition([]byte(`{"abi": ["constructor(address owner) payable"], "bytecode": {"object": "0x60806040"}}`))
+	if err != nil || def.Constructor == nil || def.Constructor.Sig() != "constructor(address)" || def.Bytecode != "0x60806040" {
+		t.Errorf("foundry artifact mismatch, got %+v, %v", def, err)
+	}
+	// The unlinked bytecode can't be used
+	if _, err := getBytecode("0x6080__$1234$__6040", ""); err == nil || !strings.HasPrefix(err.Error(), errInvalidBytecode.Error()) {
+		t.Errorf("unlinked bytecode: error mismatch, want %v, got %v", errInvalidBytecode, err)
+	}
+}
+
+func TestSelectFunction(t *testing.T) {
+	functions, err := parseABIFunctions([]byte(`[
+		"function transfer(address to, uint256 value) returns (bool)",
+		"function approve(address spender, uint256 value) returns (bool)"
+	]`))
+	if err != nil {
+		t.Fatalf("failed to parse abi: %v", err)
+	}
+	var tests = []struct {
+		method      string
+		args        int
+		interactive bool
+		sig         string
+		err         error
+	}{
+		{"transfer", 2, false, "transfer(address,uint256)", nil},
+		{"transfer(address,uint256,bytes)", 3, false, "transfer(address,uint256,bytes)", nil},
+		{"approve", 0, true, "approve(address,uint256)", nil},
+		{"transfer", 1, false, "", errUnknownABIMethod},
+		{"", 2, false, "", errNoEncodeMethod},
+		{"mint", 0, true, "", errUnknownABIFunction},
+	}
+	for _, test := range tests {
+		fn, err := selectFunction(functions, test.method, test.args, test.interactive)
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.method, test.err, err)
+			continue
+		}
+		if err == nil && fn.Sig() != test.sig {
+			t.Errorf("%s: function mismatch, want %s, got %s", test.method, test.sig, fn.Sig())
+		}
+	}
+}
@@ -0,0 +1,488 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"math/big"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rjl493456442/ethclient/client"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	errNoExportAddress   = errors.New("no address to export")
+	errInvalidBlockRange = errors.New("invalid block range")
+	errBlockNotFound     = errors.New("block not found")
+)
+
+// Sheet names of the exported statement workbook.
+const (
+	StatementTxSheet       = "Transactions"
+	StatementTransferSheet = "Token Transfers"
+	StatementFeeSheet      = "Fees"
+)
+
+// Number of blocks queried by a single log filter.
+const exportLogRange = 5000
+
+// transferEventTopic is the topic of ERC20 Transfer(address,address,uint256) event.
+var transferEventTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+var (
+	exportAddressFlag = cli.StringSliceFlag{
+		Name:  "address",
+		Usage: "account address to export, can be repeated. If not specified, all addresses in the keystore addresses file are exported",
+	}
+	exportTokenFlag = cli.StringSliceFlag{
+		Name:  "token",
+		Usage: "token symbol whose transfers are exported, can be repeated. If not specified, all tokens in the token list are exported",
+	}
+	exportFromBlockFlag = cli.Uint64Flag{
+		Name:  "fromblock",
+		Usage: "first block of the exported range",
+	}
+	exportToBlockFlag = cli.Uint64Flag{
+		Name:  "toblock",
+		Usage: "last block of the exported range. If not specified, the latest block is used",
+	}
+	exportOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "exported workbook path",
+		Value: "statement.xlsx",
+	}
+)
+
+var commandExport = cli.Command{
+	Name:        "export",
+	Usage:       "Export account history to excel workbook",
+	Description: "Scan the block range for the transactions and token transfers of accounts, and write the statement to a workbook with transactions, token transfers and fees sheets",
+	Flags: []cli.Flag{
+		clientFlag,
+		keystoreFlag,
+		tokenfileFlag,
+		tokenDirFlag,
+		chainIdFlag,
+		exportAddressFlag,
+		exportTokenFlag,
+		exportFromBlockFlag,
+		exportToBlockFlag,
+		exportOutputFlag,
+	},
+	Action: Export,
+}
+
+// StatementTx is an ether transaction in the statement.
+type StatementTx struct {
+	Block     uint64
+	Time      time.Time
+	Hash      common.Hash
+	From      common.Address
+	To        *common.Address // nil for contract creation
+	Value     *big.Int
+	Direction string
+	Status    string
+	GasUsed   uint64
+	GasPrice  *big.Int
+}
+
+// StatementTransfer is an ERC20 token transfer in the statement.
+type StatementTransfer struct {
+	Block     uint64
+	Index     uint // Log index in the block
+	Time      time.Time
+	Hash      common.Hash
+	Token     Token
+	From      common.Address
+	To        common.Address
+	Amount    *big.Int
+	Direction string
+}
+
+// Statement packages the account history in a block range.
+type Statement struct {
+	Transactions []StatementTx
+	Transfers    []StatementTransfer
+}
+
+// Export exports the account history to excel workbook.
+func Export(ctx *cli.Context) error {
+	addresses, err := getExportAddresses(ctx)
+	if err != nil {
+		return err
+	}
+	client, err := getClient(ctx)
+	if err != nil {
+		return err
+	}
+	tokens, err := getExportTokens(ctx, client)
+	if err != nil {
+		return err
+	}
+	from, to := ctx.Uint64(exportFromBlockFlag.Name), ctx.Uint64(exportToBlockFlag.Name)
+	if !ctx.IsSet(exportToBlockFlag.Name) {
+		timeoutContext, _ := makeTimeoutContext(5 * time.Second)
+		header, err := client.Cli.HeaderByNumber(timeoutContext, nil)
+		if err != nil {
+			return err
+		}
+		to = header.Number.Uint64()
+	}
+	if from > to {
+		return errInvalidBlockRange
+	}
+	statement, err := collectStatement(client, addresses, tokens, from, to)
+	if err != nil {
+		return err
+	}
+	output := ctx.String(exportOutputFlag.Name)
+	if err := writeStatement(output, statement); err != nil {
+		return err
+	}
+	logger.Noticef("Exported %d transactions and %d token transfers to %s", len(statement.Transactions), len(statement.Transfers), output)
+	return nil
+}
+
+// getExportAddresses returns the addresses to export from command line input or
+// the keystore addresses file.
+func getExportAddresses(ctx *cli.Context) (map[common.Address]bool, error) {
+	var (
+		addresses = make(map[common.Address]bool)
+		list      = ctx.StringSlice(exportAddressFlag.Name)
+	)
+	if len(list) == 0 {
+		fd, err := os.Open(path.Join(ctx.String(keystoreFlag.Name), "addresses"))
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+
+		scanner := bufio.NewScanner(fd)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				list = append(list, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	for _, address := range list {
+		if !common.IsHexAddress(address) {
+			return nil, errInvalidArguments
+		}
+		addresses[common.HexToAddress(address)] = true
+	}
+	if len(addresses) == 0 {
+		return nil, errNoExportAddress
+	}
+	return addresses, nil
+}
+
+// getExportTokens returns the tokens to export keyed by the contract address.
+func getExportTokens(ctx *cli.Context, client *client.Client) (map[common.Address]Token, error) {
+	registry, err := getTokenRegistry(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make(map[common.Address]Token)
+	refs := ctx.StringSlice(exportTokenFlag.Name)
+	if len(refs) == 0 {
+		for _, token := range registry.Tokens() {
+			tokens[common.HexToAddress(token.Address)] = token
+		}
+		return tokens, nil
+	}
+	for _, ref := range refs {
+		token, err := registry.Lookup(ref)
+		if err != nil {
+			return nil, err
+		}
+		tokens[common.HexToAddress(token.Address)] = token
+	}
+	return tokens, nil
+}
+
+// direction returns the direction of transfer relative to the exported accounts.
+func direction(addresses map[common.Address]bool, from common.Address, to *common.Address) string {
+	switch {
+	case to != nil && addresses[from] && addresses[*to]:
+		return "self"
+	case addresses[from]:
+		return "out"
+	default:
+		return "in"
+	}
+}
+
+// collectStatement collects the transactions and token transfers of given
+// addresses in the block range [from, to].
+func collectStatement(client *client.Client, addresses map[common.Address]bool, tokens map[common.Address]Token, from, to uint64) (*Statement, error) {
+	statement := new(Statement)
+
+	// Collect the token transfers first, so that the timestamps can be filled
+	// during the block scanning.
+	transfers, err := collectTransfers(client, addresses, tokens, from, to)
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[uint64][]int)
+	for idx, transfer := range transfers {
+		pending[transfer.Block] = append(pending[transfer.Block], idx)
+	}
+	chainId, err := getChainId(client)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.NewEIP155Signer(chainId)
+
+	for number := from; number <= to; number++ {
+		if number%1000 == 0 {
+			logger.Infof("Scanning block %d, %d blocks left", number, to-number)
+		}
+		timeoutContext, _ := makeTimeoutContext(10 * time.Second)
+		block, err := client.Cli.BlockByNumber(timeoutContext, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, errBlockNotFound
+		}
+		timestamp := time.Unix(block.Time().Int64(), 0).UTC()
+		for _, idx := range pending[number] {
+			transfers[idx].Time = timestamp
+		}
+		for _, tx := range block.Transactions() {
+			var sender common.Address
+			if tx.Protected() {
+				sender, err = types.Sender(signer, tx)
+			} else {
+				sender, err = types.Sender(types.HomesteadSigner{}, tx)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !addresses[sender] && (tx.To() == nil || !addresses[*tx.To()]) {
+				continue
+			}
+			entry := StatementTx{
+				Block:     number,
+				Time:      timestamp,
+				Hash:      tx.Hash(),
+				From:      sender,
+				To:        tx.To(),
+				Value:     tx.Value(),
+				Direction: direction(addresses, sender, tx.To()),
+				GasPrice:  tx.GasPrice(),
+			}
+			timeoutContext, _ := makeTimeoutContext(5 * time.Second)
+			receipt, err := client.Cli.TransactionReceipt(timeoutContext, tx.Hash())
+			if err != nil {
+				return nil, err
+			}
+			entry.GasUsed = receipt.GasUsed
+			// The status is only available after byzantium fork
+			if len(receipt.PostState) == 0 {
+				if receipt.Status == types.ReceiptStatusSuccessful {
+					entry.Status = "success"
+				} else {
+					entry.Status = "failed"
+				}
+			}
+			statement.Transactions = append(statement.Transactions, entry)
+		}
+	}
+	statement.Transfers = transfers
+	return statement, nil
+}
+
+// collectTransfers collects the token Transfer logs from or to the given addresses,
+// the transfers are sorted by block and log index.
+func collectTransfers(client *client.Client, addresses map[common.Address]bool, tokens map[common.Address]Token, from, to uint64) ([]StatementTransfer, error) {
+	var (
+		contracts []common.Address
+		accounts  []common.Hash
+		transfers []StatementTransfer
+	)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	for address := range tokens {
+		contracts = append(contracts, address)
+	}
+	for address := range addresses {
+		accounts = append(accounts, common.BytesToHash(address.Bytes()))
+	}
+	// The filters are sorted as well, so that the queries are deterministic
+	sort.Slice(contracts, func(i, j int) bool { return bytes.Compare(contracts[i].Bytes(), contracts[j].Bytes()) < 0 })
+	sort.Slice(accounts, func(i, j int) bool { return bytes.Compare(accounts[i].Bytes(), accounts[j].Bytes()) < 0 })
+	for begin := from; begin <= to; begin += exportLogRange {
+		end := begin + exportLogRange - 1
+		if end > to {
+			end = to
+		}
+		seen := make(map[common.Hash]map[uint]bool)
+		// Query the outgoing and incoming transfers respectively
+		for _, topics := range [][][]common.Hash{
+			{{transferEventTopic}, accounts},
+			{{transferEventTopic}, nil, accounts},
+		} {
+			timeoutContext, _ := makeTimeoutContext(30 * time.Second)
+			logs, err := client.Cli.FilterLogs(timeoutContext, ethereum.FilterQuery{
+				FromBlock: new(big.Int).SetUint64(begin),
+				ToBlock:   new(big.Int).SetUint64(end),
+				Addresses: contracts,
+				Topics:    topics,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, log := range logs {
+				if seen[log.TxHash][log.Index] {
+					continue
+				}
+				if seen[log.TxHash] == nil {
+					seen[log.TxHash] = make(map[uint]bool)
+				}
+				seen[log.TxHash][log.Index] = true
+
+				transfer, ok := decodeTransferLog(log, tokens, addresses)
+				if ok {
+					transfers = append(transfers, transfer)
+				}
+			}
+		}
+	}
+	// The outgoing and incoming transfers are interleaved
+	sort.SliceStable(transfers, func(i, j int) bool {
+		if transfers[i].Block != transfers[j].Block {
+			return transfers[i].Block < transfers[j].Block
+		}
+		return transfers[i].Index < transfers[j].Index
+	})
+	return transfers, nil
+}
+
+// decodeTransferLog decodes the ERC20 Transfer log. ERC721 Transfer event shares
+// the same signature but with indexed token id, it's skipped.
+func decodeTransferLog(log types.Log, tokens map[common.Address]Token, addresses map[common.Address]bool) (StatementTransfer, bool) {
+	if len(log.Topics) != 3 || log.Topics[0] != transferEventTopic || len(log.Data) != 32 || log.Removed {
+		return StatementTransfer{}, false
+	}
+	token, exist := tokens[log.Address]
+	if !exist {
+		return StatementTransfer{}, false
+	}
+	from := common.BytesToAddress(log.Topics[1].Bytes())
+	to := common.BytesToAddress(log.Topics[2].Bytes())
+	return StatementTransfer{
+		Block:     log.BlockNumber,
+		Index:     log.Index,
+		Hash:      log.TxHash,
+		Token:     token,
+		From:      from,
+		To:        to,
+		Amount:    new(big.Int).SetBytes(log.Data),
+		Direction: direction(addresses, from, &to),
+	}, true
+}
+
+// getChainId returns the chain id of connected network.
+func getChainId(client *client.Client) (*big.Int, error) {
+	timeoutContext, _ := makeTimeoutContext(5 * time.Second)
+	return client.Cli.NetworkID(timeoutContext)
+}
+
+// formatAmount formats the amount in the smallest unit into human units with
+// given decimals, e.g. 1500000000000000000 with 18 decimals is "1.5".
+// The result is exact, trailing zeros are removed.
+func formatAmount(amount *big.Int, decimals int) string {
+	if amount == nil {
+		return "0"
+	}
+	var (
+		negative = amount.Sign() < 0
+		digits   = new(big.Int).Abs(amount).String()
+	)
+	if decimals > 0 {
+		if len(digits) <= decimals {
+			digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+		}
+		integer, fraction := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+		digits = integer
+		if fraction != "" {
+			digits += "." + fraction
+		}
+	}
+	if negative {
+		return "-" + digits
+	}
+	return digits
+}
+
+// writeStatement writes the statement to a workbook with transactions, token
+// transfers and fees sheets. Amounts are written as exact decimal strings in
+// human units, since the float cells can't represent 18 decimals.
+func writeStatement(filename string, statement *Statement) error {
+	fd := excelize.NewFile()
+	fd.SetSheetName(DefaultSheet, StatementTxSheet)
+	fd.NewSheet(StatementTransferSheet)
+	fd.NewSheet(StatementFeeSheet)
+
+	const layout = "2006-01-02 15:04:05"
+	setRow := func(sheet string, row int, values ...interface{}) {
+		for idx, value := range values {
+			fd.SetCellValue(sheet, excelize.ToAlphaString(idx)+strconv.Itoa(row), value)
+		}
+	}
+	setRow(StatementTxSheet, 1, "Block", "Time(UTC)", "Hash", "From", "To", "Direction", "Value(ETH)", "Status")
+	setRow(StatementTransferSheet, 1, "Block", "Time(UTC)", "Hash", "Token", "Contract", "From", "To", "Direction", "Amount")
+	setRow(StatementFeeSheet, 1, "Block", "Time(UTC)", "Hash", "From", "Gas Used", "Gas Price(Gwei)", "Fee(ETH)")
+
+	fees := 1
+	for idx, tx := range statement.Transactions {
+		to := ""
+		if tx.To != nil {
+			to = tx.To.Hex()
+		}
+		setRow(StatementTxSheet, idx+2, tx.Block, tx.Time.Format(layout), tx.Hash.Hex(), tx.From.Hex(), to, tx.Direction, formatAmount(tx.Value, 18), tx.Status)
+
+		// Fees are paid by the sender only
+		if tx.Direction == "in" {
+			continue
+		}
+		fees += 1
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(tx.GasUsed), tx.GasPrice)
+		setRow(StatementFeeSheet, fees, tx.Block, tx.Time.Format(layout), tx.Hash.Hex(), tx.From.Hex(), tx.GasUsed, formatAmount(tx.GasPrice, 9), formatAmount(fee, 18))
+	}
+	for idx, transfer := range statement.Transfers {
+		setRow(StatementTransferSheet, idx+2, transfer.Block, transfer.Time.Format(layout), transfer.Hash.Hex(), transfer.Token.Symbol,
+			common.HexToAddress(transfer.Token.Address).Hex(), transfer.From.Hex(), transfer.To.Hex(), transfer.Direction, formatAmount(transfer.Amount, transfer.Token.Decimal))
+	}
+	return fd.SaveAs(filename)
+}
@@ -0,0 +1,185 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestFormatAmount(t *testing.T) {
+	var tests = []struct {
+		amount   string
+		decimals int
+		want     string
+	}{
+		{"0", 18, "0"},
+		{"1500000000000000000", 18, "1.5"},
+		{"1", 18, "0.000000000000000001"},
+		{"123456789012345678901234567890", 18, "123456789012.34567890123456789"},
+		{"-2500", 3, "-2.5"},
+		{"1000", 0, "1000"},
+	}
+	for _, test := range tests {
+		amount, _ := new(big.Int).SetString(test.amount, 10)
+		if got := formatAmount(amount, test.decimals); got != test.want {
+			t.Errorf("formatAmount(%s, %d) mismatch, want %s, got %s", test.amount, test.decimals, test.want, got)
+		}
+	}
+}
+
+func TestDecodeTransferLog(t *testing.T) {
+	var (
+		token    = Token{Address: "0x86fa049857e0209aa7d9e616f7eb3b3b78ecfdb0", Symbol: "EOS", Decimal: 18}
+		contract = common.HexToAddress(token.Address)
+		alice    = common.HexToAddress("0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e")
+		bob      = common.HexToAddress("0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf")
+		tokens   = map[common.Address]Token{contract: token}
+		accounts = map[common.Address]bool{alice: true}
+	)
+	log := types.Log{
+		Address:     contract,
+		Topics:      []common.Hash{transferEventTopic, common.BytesToHash(bob.Bytes()), common.BytesToHash(alice.Bytes())},
+		Data:        common.LeftPadBytes(big.NewInt(2000).Bytes(), 32),
+		BlockNumber: 100,
+	}
+	transfer, ok := decodeTransferLog(log, tokens, accounts)
+	if !ok {
+		t.Fatal("failed to decode transfer log")
+	}
+	if transfer.From != bob || transfer.To != alice || transfer.Amount.Int64() != 2000 || transfer.Direction != "in" || transfer.Block != 100 {
+		t.Errorf("transfer mismatch, got %+v", transfer)
+	}
+	// ERC721 transfer with indexed token id is skipped
+	log.Topics = append(log.Topics, common.Hash{})
+	log.Data = nil
+	if _, ok := decodeTransferLog(log, tokens, accounts); ok {
+		t.Error("expect erc721 transfer to be skipped")
+	}
+}
+
+func TestCollectTransfers(t *testing.T) {
+	var (
+		token    = Token{Address: "0x86fa049857e0209aa7d9e616f7eb3b3b78ecfdb0", Symbol: "EOS", Decimal: 18}
+		contract = common.HexToAddress(token.Address)
+		alice    = common.HexToAddress("0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e")
+		bob      = common.HexToAddress("0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf")
+	)
+	transfer := func(block uint64, index uint, from, to common.Address) types.Log {
+		return types.Log{
+			Address:     contract,
+			Topics:      []common.Hash{transferEventTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+			Data:        common.LeftPadBytes(big.NewInt(int64(index)).Bytes(), 32),
+			BlockNumber: block,
+			TxHash:      common.BigToHash(new(big.Int).SetUint64(block)),
+			Index:       index,
+		}
+	}
+	server, cli := newTestRPCServer(t, func(method string, params []json.RawMessage) (interface{}, error) {
+		if method != "eth_getLogs" {
+			return nil, fmt.Errorf("unsupported method %s", method)
+		}
+		var filter struct {
+			Topics []interface{} `json:"topics"`
+		}
+		if err := json.Unmarshal(params[0], &filter); err != nil {
+			return nil, err
+		}
+		// The self transfer is returned by both queries
+		if filter.Topics[1] != nil {
+			return []types.Log{transfer(10, 3, alice, bob), transfer(5, 1, alice, alice)}, nil
+		}
+		return []types.Log{transfer(5, 7, bob, alice), transfer(5, 1, alice, alice)}, nil
+	})
+	defer server.Close()
+
+	transfers, err := collectTransfers(cli, map[common.Address]bool{alice: true}, map[common.Address]Token{contract: token}, 0, 100)
+	if err != nil {
+		t.Fatalf("failed to collect transfers: %v", err)
+	}
+	var got []string
+	for _, transfer := range transfers {
+		got = append(got, fmt.Sprintf("%d:%d:%s", transfer.Block, transfer.Index, transfer.Direction))
+	}
+	if want := []string{"5:1:self", "5:7:in", "10:3:out"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("transfers mismatch, want %v, got %v", want, got)
+	}
+}
+
+func TestWriteStatement(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		alice     = common.HexToAddress("0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e")
+		bob       = common.HexToAddress("0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf")
+		timestamp = time.Date(2018, 4, 2, 12, 0, 0, 0, time.UTC)
+	)
+	statement := &Statement{
+		Transactions: []StatementTx{
+			{Block: 100, Time: timestamp, From: alice, To: &bob, Value: big.NewInt(1e18), Direction: "out", Status: "success", GasUsed: 21000, GasPrice: big.NewInt(2e9)},
+			{Block: 101, Time: timestamp, From: bob, To: &alice, Value: big.NewInt(5e17), Direction: "in", Status: "success", GasUsed: 21000, GasPrice: big.NewInt(2e9)},
+		},
+		Transfers: []StatementTransfer{
+			{Block: 102, Time: timestamp, Token: Token{Address: "0x86fa049857e0209aa7d9e616f7eb3b3b78ecfdb0", Symbol: "EOS", Decimal: 18}, From: bob, To: alice, Amount: big.NewInt(25e17), Direction: "in"},
+		},
+	}
+	filename := path.Join(dir, "statement.xlsx")
+	if err := writeStatement(filename, statement); err != nil {
+		t.Fatal(err)
+	}
+	fd, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		sheet string
+		rows  int
+		cell  string
+		want  string
+	}{
+		{StatementTxSheet, 3, "G2", "1"},
+		{StatementTxSheet, 3, "G3", "0.5"},
+		{StatementTransferSheet, 2, "I2", "2.5"},
+		{StatementTransferSheet, 2, "D2", "EOS"},
+		{StatementFeeSheet, 2, "G2", "0.000042"},
+		{StatementFeeSheet, 2, "F2", "2"},
+	}
+	for _, test := range tests {
+		if rows := fd.GetRows(test.sheet); len(rows) != test.rows {
+			t.Errorf("%s: row number mismatch, want %d, got %d", test.sheet, test.rows, len(rows))
+		}
+		if got := fd.GetCellValue(test.sheet, test.cell); got != test.want {
+			t.Errorf("%s!%s: value mismatch, want %s, got %s", test.sheet, test.cell, test.want, got)
+		}
+	}
+	if header := fd.GetRows(StatementTxSheet)[0]; !reflect.DeepEqual(header[:3], []string{"Block", "Time(UTC)", "Hash"}) {
+		t.Errorf("header mismatch, got %v", header)
+	}
+}
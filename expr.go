@@ -0,0 +1,391 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Macro arguments and the value column of batch file can be expressions which
+// are evaluated at send time. The supported variables are:
+//
+//    $SENDER              the sender of row
+//    $RECEIVER            the receiver of row
+//    $ROW                 the row number in batch file
+//    $BALANCE(<symbol>)   the token balance of sender in token units, the holder
+//                         can be given as the second argument
+//    $ETHBALANCE          the ether balance of sender in wei, the holder can be
+//                         given as the argument, e.g. $ETHBALANCE($RECEIVER)
+//
+// Numbers are exact decimals with optional ether unit suffix, e.g. 1.5, 20gwei.
+// The operators +, -, *, / and parentheses follow the usual precedence, and
+// min(...) and max(...) accept any number of arguments.
+
+var (
+	errInvalidExpression = errors.New("invalid expression")
+	errUnknownVariable   = errors.New("unknown variable")
+)
+
+// exprValue is the result of expression, either a number or an address.
+type exprValue struct {
+	num  *big.Rat
+	addr *common.Address
+}
+
+// macroEnv is the context of expression evaluation.
+type macroEnv struct {
+	sender   common.Address
+	receiver common.Address
+	row      int
+
+	tokenBalance func(symbol string, holder common.Address) (*big.Rat, error)
+	ethBalance   func(holder common.Address) (*big.Int, error)
+}
+
+// isMacroExpression reports whether the argument is an expression which should
+// be evaluated, plain literals are left as they are.
+func isMacroExpression(arg string) bool {
+	lower := strings.ToLower(arg)
+	return strings.Contains(arg, "$") || strings.HasPrefix(lower, "min(") || strings.HasPrefix(lower, "max(")
+}
+
+// evalExpression evaluates the expression in given environment.
+func evalExpression(expr string, env *macroEnv) (exprValue, error) {
+	p := &exprParser{input: expr, env: env}
+	value, err := p.parseSum()
+	if err != nil {
+		return exprValue{}, err
+	}
+	if p.skipSpace(); p.pos < len(p.input) {
+		return exprValue{}, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	return value, nil
+}
+
+// evalNumber evaluates the expression which must result in a number.
+func evalNumber(expr string, env *macroEnv) (*big.Rat, error) {
+	value, err := evalExpression(expr, env)
+	if err != nil {
+		return nil, err
+	}
+	if value.num == nil {
+		return nil, fmt.Errorf("%v %s: result is not a number", errInvalidExpression, expr)
+	}
+	return value.num, nil
+}
+
+// formatExprValue formats the result of expression as the macro argument. The
+// number is formatted in decimal, which is truncated to 18 fractional digits if
+// it's not finite.
+func formatExprValue(value exprValue) string {
+	if value.addr != nil {
+		return value.addr.Hex()
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	scaled := new(big.Rat).Mul(value.num, new(big.Rat).SetInt(unit))
+	return formatAmount(new(big.Int).Quo(scaled.Num(), scaled.Denom()), 18)
+}
+
+// exprParser is a recursive descent parser which evaluates the expression while
+// parsing.
+type exprParser struct {
+	input string
+	pos   int
+	env   *macroEnv
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%v %s: %s", errInvalidExpression, p.input, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos += 1
+	}
+}
+
+// consume skips the given character if it's the next one.
+func (p *exprParser) consume(c byte) bool {
+	if p.skipSpace(); p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos += 1
+		return true
+	}
+	return false
+}
+
+// ident reads the identifier, which consists of letters, digits and underscores.
+func (p *exprParser) ident() string {
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.') {
+			break
+		}
+		p.pos += 1
+	}
+	return p.input[start:p.pos]
+}
+
+// parseSum parses the terms separated by + and -.
+func (p *exprParser) parseSum() (exprValue, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return exprValue{}, err
+	}
+	for {
+		var add bool
+		if p.consume('+') {
+			add = true
+		} else if !p.consume('-') {
+			return left, nil
+		}
+		right, err := p.parseProduct()
+		if err != nil {
+			return exprValue{}, err
+		}
+		if left.num == nil || right.num == nil {
+			return exprValue{}, p.errorf("arithmetic on address")
+		}
+		if add {
+			left.num = new(big.Rat).Add(left.num, right.num)
+		} else {
+			left.num = new(big.Rat).Sub(left.num, right.num)
+		}
+	}
+}
+
+// parseProduct parses the factors separated by * and /.
+func (p *exprParser) parseProduct() (exprValue, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return exprValue{}, err
+	}
+	for {
+		var mul bool
+		if p.consume('*') {
+			mul = true
+		} else if !p.consume('/') {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return exprValue{}, err
+		}
+		if left.num == nil || right.num == nil {
+			return exprValue{}, p.errorf("arithmetic on address")
+		}
+		if mul {
+			left.num = new(big.Rat).Mul(left.num, right.num)
+		} else {
+			if right.num.Sign() == 0 {
+				return exprValue{}, p.errorf("division by zero")
+			}
+			left.num = new(big.Rat).Quo(left.num, right.num)
+		}
+	}
+}
+
+// parseUnary parses the negation.
+func (p *exprParser) parseUnary() (exprValue, error) {
+	if p.consume('-') {
+		value, err := p.parseUnary()
+		if err != nil {
+			return exprValue{}, err
+		}
+		if value.num == nil {
+			return exprValue{}, p.errorf("arithmetic on address")
+		}
+		return exprValue{num: new(big.Rat).Neg(value.num)}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses the numbers, variables, function calls and parentheses.
+func (p *exprParser) parsePrimary() (exprValue, error) {
+	if p.consume('(') {
+		value, err := p.parseSum()
+		if err != nil {
+			return exprValue{}, err
+		}
+		if !p.consume(')') {
+			return exprValue{}, p.errorf("missing )")
+		}
+		return value, nil
+	}
+	if p.consume('$') {
+		return p.parseVariable()
+	}
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return exprValue{}, p.errorf("unexpected end")
+	}
+	if c := p.input[p.pos]; c >= '0' && c <= '9' || c == '.' {
+		return p.parseNumber()
+	}
+	name := strings.ToLower(p.ident())
+	if name != "min" && name != "max" {
+		return exprValue{}, p.errorf("unknown function %q", name)
+	}
+	args, err := p.parseArguments()
+	if err != nil {
+		return exprValue{}, err
+	}
+	if len(args) == 0 {
+		return exprValue{}, p.errorf("%s requires arguments", name)
+	}
+	var result *big.Rat
+	for _, arg := range args {
+		if arg.num == nil {
+			return exprValue{}, p.errorf("%s of address", name)
+		}
+		if result == nil {
+			result = arg.num
+		} else if cmp := arg.num.Cmp(result); (name == "min" && cmp < 0) || (name == "max" && cmp > 0) {
+			result = arg.num
+		}
+	}
+	return exprValue{num: result}, nil
+}
+
+// parseNumber parses the decimal or hex number with optional unit suffix.
+func (p *exprParser) parseNumber() (exprValue, error) {
+	literal := p.ident()
+	if strings.HasPrefix(literal, "0x") || strings.HasPrefix(literal, "0X") {
+		v, ok := new(big.Int).SetString(literal[2:], 16)
+		if !ok {
+			return exprValue{}, p.errorf("invalid number %s", literal)
+		}
+		return exprValue{num: new(big.Rat).SetInt(v)}, nil
+	}
+	var (
+		digits = strings.TrimRight(literal, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+		unit   = strings.ToLower(literal[len(digits):])
+	)
+	// The unit can be separated by space, e.g. 1.5 ether
+	if unit == "" {
+		save := p.pos
+		if p.skipSpace(); p.pos < len(p.input) {
+			if word := strings.ToLower(p.ident()); word != "" {
+				if _, exist := abiUnits[word]; exist {
+					unit = word
+				}
+			}
+		}
+		if unit == "" {
+			p.pos = save
+		}
+	}
+	num, ok := parseDecimal(digits)
+	if !ok {
+		return exprValue{}, p.errorf("invalid number %s", literal)
+	}
+	if unit != "" {
+		decimals, exist := abiUnits[unit]
+		if !exist {
+			return exprValue{}, p.errorf("unknown unit %s", unit)
+		}
+		num.Mul(num, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	}
+	return exprValue{num: num}, nil
+}
+
+// parseArguments parses the parenthesized and comma separated arguments.
+func (p *exprParser) parseArguments() ([]exprValue, error) {
+	if !p.consume('(') {
+		return nil, p.errorf("missing (")
+	}
+	var args []exprValue
+	if p.consume(')') {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.consume(')') {
+			return args, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("missing )")
+		}
+	}
+}
+
+// parseVariable parses the variable after $.
+func (p *exprParser) parseVariable() (exprValue, error) {
+	name := strings.ToUpper(p.ident())
+	switch name {
+	case "SENDER":
+		return exprValue{addr: &p.env.sender}, nil
+	case "RECEIVER":
+		return exprValue{addr: &p.env.receiver}, nil
+	case "ROW":
+		return exprValue{num: new(big.Rat).SetInt64(int64(p.env.row))}, nil
+	case "ETHBALANCE":
+		holder := p.env.sender
+		if p.skipSpace(); p.pos < len(p.input) && p.input[p.pos] == '(' {
+			args, err := p.parseArguments()
+			if err != nil {
+				return exprValue{}, err
+			}
+			if len(args) != 1 || args[0].addr == nil {
+				return exprValue{}, p.errorf("$ETHBALANCE requires an address argument")
+			}
+			holder = *args[0].addr
+		}
+		balance, err := p.env.ethBalance(holder)
+		if err != nil {
+			return exprValue{}, err
+		}
+		return exprValue{num: new(big.Rat).SetInt(balance)}, nil
+	case "BALANCE":
+		// The first argument is the token symbol rather than expression
+		if !p.consume('(') {
+			return exprValue{}, p.errorf("$BALANCE requires the token symbol")
+		}
+		p.skipSpace()
+		symbol := p.ident()
+		if symbol == "" {
+			return exprValue{}, p.errorf("$BALANCE requires the token symbol")
+		}
+		holder := p.env.sender
+		if p.consume(',') {
+			arg, err := p.parseSum()
+			if err != nil {
+				return exprValue{}, err
+			}
+			if arg.addr == nil {
+				return exprValue{}, p.errorf("the holder of $BALANCE is not an address")
+			}
+			holder = *arg.addr
+		}
+		if !p.consume(')') {
+			return exprValue{}, p.errorf("missing )")
+		}
+		balance, err := p.env.tokenBalance(symbol, holder)
+		if err != nil {
+			return exprValue{}, err
+		}
+		return exprValue{num: balance}, nil
+	}
+	return exprValue{}, fmt.Errorf("%v $%s", errUnknownVariable, name)
+}
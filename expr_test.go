@@ -0,0 +1,128 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func newTestMacroEnv() *macroEnv {
+	var (
+		sender   = common.HexToAddress("0xadd0354d4f5c101685509001053730417321db49")
+		receiver = common.HexToAddress("0x8f0909ccb296ebd319834edb0d5785794b781d7f")
+	)
+	return &macroEnv{
+		sender:   sender,
+		receiver: receiver,
+		row:      7,
+		tokenBalance: func(symbol string, holder common.Address) (*big.Rat, error) {
+			if strings.ToLower(symbol) != "eos" {
+				return nil, errUnrecognizableTokenSymbol
+			}
+			if holder == receiver {
+				return big.NewRat(1, 2), nil
+			}
+			return big.NewRat(2501, 100), nil
+		},
+		ethBalance: func(holder common.Address) (*big.Int, error) {
+			if holder == receiver {
+				return big.NewInt(0), nil
+			}
+			return big.NewInt(1e18), nil
+		},
+	}
+}
+
+func TestEvalExpression(t *testing.T) {
+	var tests = []struct {
+		expr   string
+		expect string
+		err    bool
+	}{
+		{"$SENDER", common.HexToAddress("0xadd0354d4f5c101685509001053730417321db49").Hex(), false},
+		{"$receiver", common.HexToAddress("0x8f0909ccb296ebd319834edb0d5785794b781d7f").Hex(), false},
+		{"$ROW * 10 + 1", "71", false},
+		{"$BALANCE(EOS) - 10", "15.01", false},
+		{"$BALANCE(eos, $RECEIVER)", "0.5", false},
+		{"min($BALANCE(EOS), 100, 20.5)", "20.5", false},
+		{"max(1, (2 + 3) * 2, -4)", "10", false},
+		{"$ETHBALANCE - 21000 * 20gwei", "999580000000000000", false},
+		{"$ETHBALANCE($RECEIVER) + 1.5 ether", "1500000000000000000", false},
+		{"-$ROW / 2", "-3.5", false},
+		{"1 / 3", "0.333333333333333333", false},
+		{"0x10 + 1", "17", false},
+		{"$SENDER + 1", "", true},
+		{"$BALANCE(DAI)", "", true},
+		{"$UNKNOWN", "", true},
+		{"$ROW / 0", "", true},
+		{"min()", "", true},
+		{"($ROW + 1", "", true},
+		{"$ROW 1", "", true},
+		{"avg($ROW)", "", true},
+	}
+	for _, test := range tests {
+		value, err := evalExpression(test.expr, newTestMacroEnv())
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expect error, got %s", test.expr, formatExprValue(value))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.expr, err)
+			continue
+		}
+		if got := formatExprValue(value); got != test.expect {
+			t.Errorf("%s: result mismatch, want %s, got %s", test.expr, test.expect, got)
+		}
+	}
+}
+
+func TestSplitMacroFields(t *testing.T) {
+	var tests = []struct {
+		input  string
+		fields []string
+	}{
+		{"#TRANSFER EOS  $BALANCE(EOS) - 10", []string{"#TRANSFER", "EOS", "$BALANCE(EOS) - 10"}},
+		{"#TRANSFER EOS min($BALANCE(EOS), 10) /2", []string{"#TRANSFER", "EOS", "min($BALANCE(EOS), 10) /2"}},
+		{`#CALL RDN "f(int256,int256)" 5 -1`, []string{"#CALL", "RDN", `"f(int256,int256)"`, "5", "-1"}},
+		{`#CALL RDN setName "a - b" -`, []string{"#CALL", "RDN", "setName", `"a - b"`, "-"}},
+	}
+	for _, test := range tests {
+		fields, err := splitMacroFields(test.input)
+		if err != nil {
+			t.Fatalf("%s: failed to split: %v", test.input, err)
+		}
+		if strings.Join(fields, "|") != strings.Join(test.fields, "|") {
+			t.Errorf("%s: fields mismatch, want %q, got %q", test.input, test.fields, fields)
+		}
+	}
+}
+
+func TestParseValueField(t *testing.T) {
+	if value, expr, err := parseValueField("100"); value != 100 || expr != "" || err != nil {
+		t.Errorf("plain value mismatch, got %d %q %v", value, expr, err)
+	}
+	if value, expr, err := parseValueField("$ETHBALANCE - 1ether"); value != 0 || expr != "$ETHBALANCE - 1ether" || err != nil {
+		t.Errorf("expression value mismatch, got %d %q %v", value, expr, err)
+	}
+	if _, _, err := parseValueField("1ether"); err == nil {
+		t.Errorf("expect error for non-expression value")
+	}
+}
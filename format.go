@@ -0,0 +1,216 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	errUnknownBatchFormat = errors.New("unknown batch file format")
+)
+
+// BatchFormat describes a batch file format which can be used by sendBatch.
+// New formats can be plugged in by RegisterBatchFormat.
+type BatchFormat struct {
+	Name       string                 // Format name used by the --format flag
+	Extensions []string               // File extensions with the leading dot
+	Sniff      func(head []byte) bool // Content detection with the leading bytes of file, optional
+	Open       func(filename string, opts FormatOptions) (RWriter, error)
+
+	// OpenResults opens the result file in this format, optional
+	OpenResults func(filename string, opts FormatOptions) (ResultWriter, error)
+}
+
+// FormatOptions packages the format specific options for opening batch file.
+type FormatOptions struct {
+	Sheet string // Sheet name for spreadsheet formats, also used by result file
+}
+
+// sniffLength is the maximum number of leading bytes used for content detection.
+const sniffLength = 512
+
+var (
+	// batchFormats is the registry of all supported batch file formats.
+	// Note, the order matters for the content detection.
+	batchFormats = []*BatchFormat{
+		{
+			Name:       "ods",
+			Extensions: []string{".ods"},
+			Sniff: func(head []byte) bool {
+				// The first entry of OpenDocument package is the uncompressed mimetype.
+				return bytes.HasPrefix(head, []byte("PK\x03\x04")) && bytes.Contains(head, []byte("mimetypeapplication/vnd.oasis.opendocument.spreadsheet"))
+			},
+			Open: func(filename string, opts FormatOptions) (RWriter, error) {
+				return NewODSRWriter(filename, opts.Sheet)
+			},
+			OpenResults: func(filename string, opts FormatOptions) (ResultWriter, error) {
+				return NewODSResultWriter(filename, opts.Sheet)
+			},
+		},
+		{
+			Name:       "xlsx",
+			Extensions: []string{".xlsx"},
+			Sniff: func(head []byte) bool {
+				return bytes.HasPrefix(head, []byte("PK\x03\x04"))
+			},
+			Open: func(filename string, opts FormatOptions) (RWriter, error) {
+				return NewExcelRWriter(filename, opts.Sheet)
+			},
+			OpenResults: func(filename string, opts FormatOptions) (ResultWriter, error) {
+				return NewExcelResultWriter(filename, opts.Sheet)
+			},
+		},
+		{
+			Name:       "json",
+			Extensions: []string{".json"},
+			Sniff: func(head []byte) bool {
+				return bytes.HasPrefix(bytes.TrimSpace(head), []byte("["))
+			},
+			Open: func(filename string, opts FormatOptions) (RWriter, error) {
+				return NewJSONRWriter(filename)
+			},
+			OpenResults: func(filename string, opts FormatOptions) (ResultWriter, error) {
+				return NewJSONResultWriter(filename)
+			},
+		},
+		{
+			Name:       "jsonl",
+			Extensions: []string{".jsonl", ".ndjson"},
+			Sniff: func(head []byte) bool {
+				return bytes.HasPrefix(bytes.TrimSpace(head), []byte("{"))
+			},
+			Open: func(filename string, opts FormatOptions) (RWriter, error) {
+				return NewJSONLRWriter(filename)
+			},
+			OpenResults: func(filename string, opts FormatOptions) (ResultWriter, error) {
+				return NewJSONLResultWriter(filename)
+			},
+		},
+		{
+			Name:       "csv",
+			Extensions: []string{".csv"},
+			Sniff: func(head []byte) bool {
+				// Raw text file doesn't support comment and header, so if the file starts
+				// with either of them, it must be a csv file.
+				head = bytes.TrimSpace(head)
+				return bytes.HasPrefix(head, []byte("#")) || bytes.HasPrefix(bytes.ToLower(head), []byte("from"))
+			},
+			Open: func(filename string, opts FormatOptions) (RWriter, error) {
+				return NewCSVRWriter(filename)
+			},
+			OpenResults: func(filename string, opts FormatOptions) (ResultWriter, error) {
+				return NewCSVResultWriter(filename)
+			},
+		},
+		{
+			Name:       "rawtext",
+			Extensions: []string{".txt"},
+			Open: func(filename string, opts FormatOptions) (RWriter, error) {
+				return NewRawTextRWriter(filename)
+			},
+			OpenResults: func(filename string, opts FormatOptions) (ResultWriter, error) {
+				return NewRawTextResultWriter(filename)
+			},
+		},
+	}
+
+	// defaultBatchFormat is used if the format can't be detected.
+	defaultBatchFormat = "rawtext"
+)
+
+// RegisterBatchFormat adds a new batch file format to the registry. The format
+// registered with the existent name will replace the old one.
+func RegisterBatchFormat(format *BatchFormat) {
+	for idx, f := range batchFormats {
+		if f.Name == format.Name {
+			batchFormats[idx] = format
+			return
+		}
+	}
+	batchFormats = append(batchFormats, format)
+}
+
+// BatchFormatNames returns the names of all registered batch file formats.
+func BatchFormatNames() []string {
+	var names []string
+	for _, format := range batchFormats {
+		names = append(names, format.Name)
+	}
+	return names
+}
+
+// lookupBatchFormat returns the batch file format with given name.
+func lookupBatchFormat(name string) (*BatchFormat, error) {
+	for _, format := range batchFormats {
+		if format.Name == strings.ToLower(name) {
+			return format, nil
+		}
+	}
+	return nil, errUnknownBatchFormat
+}
+
+// detectBatchFormat detects the format of given batch file by the file extension
+// first and then by the file content. If both fail, the default format is returned.
+func detectBatchFormat(filename string) (*BatchFormat, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, format := range batchFormats {
+		for _, e := range format.Extensions {
+			if e == ext {
+				return format, nil
+			}
+		}
+	}
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(fd, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	for _, format := range batchFormats {
+		if format.Sniff != nil && format.Sniff(head[:n]) {
+			return format, nil
+		}
+	}
+	return lookupBatchFormat(defaultBatchFormat)
+}
+
+// OpenBatchFile opens the batch file with specified format. If the format is
+// not specified, it will be detected automatically.
+func OpenBatchFile(filename string, name string, opts FormatOptions) (RWriter, error) {
+	var (
+		format *BatchFormat
+		err    error
+	)
+	if name != "" {
+		format, err = lookupBatchFormat(name)
+	} else {
+		format, err = detectBatchFormat(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return format.Open(filename, opts)
+}
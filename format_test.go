@@ -0,0 +1,98 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestDetectBatchFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-format")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var tests = []struct {
+		fixture string
+		format  string
+	}{
+		{"raw_text", "rawtext"},
+		{"excel.xlsx", "xlsx"},
+		{"batch.ods", "ods"},
+		{"batch.json", "json"},
+		{"batch.jsonl", "jsonl"},
+		{"batch.csv", "csv"},
+	}
+	for _, test := range tests {
+		// Detect by the file extension
+		format, err := detectBatchFormat(path.Join("test", test.fixture))
+		if err != nil {
+			t.Fatalf("%s: %v", test.fixture, err)
+		}
+		if format.Name != test.format {
+			t.Errorf("%s: format mismatch, want %s, got %s", test.fixture, test.format, format.Name)
+		}
+		// Detect by the file content
+		content, err := ioutil.ReadFile(path.Join("test", test.fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		batchfile := path.Join(dir, "batch")
+		if err := ioutil.WriteFile(batchfile, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if format, err = detectBatchFormat(batchfile); err != nil {
+			t.Fatalf("%s: %v", test.fixture, err)
+		}
+		if format.Name != test.format {
+			t.Errorf("%s: sniffed format mismatch, want %s, got %s", test.fixture, test.format, format.Name)
+		}
+	}
+}
+
+func TestOpenBatchFile(t *testing.T) {
+	// Format override takes precedence over the detection
+	rw, err := OpenBatchFile(path.Join("test", "raw_text"), "csv", FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rw.(*CSVRWriter); !ok {
+		t.Errorf("format override mismatch, got %T", rw)
+	}
+	params, err := rw.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 3 || rw.Axis(params[2].Row) != "3" {
+		t.Errorf("row address mismatch")
+	}
+	if _, err := OpenBatchFile(path.Join("test", "raw_text"), "pdf", FormatOptions{}); err != errUnknownBatchFormat {
+		t.Errorf("error mismatch, want %v, got %v", errUnknownBatchFormat, err)
+	}
+	rw, err = OpenBatchFile(path.Join("test", "excel.xlsx"), "", FormatOptions{Sheet: DefaultSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params, err = rw.ReadAll(); err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 3 || rw.Axis(params[0].Row) != "F2" {
+		t.Errorf("row address mismatch")
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	errRowOrder         = errors.New("result row is not in increasing order")
+	errCorruptedJournal = errors.New("corrupted result journal")
+)
+
+// journalSuffix is appended to the batch file name to get the journal path.
+const journalSuffix = ".journal"
+
+// resultJournal is an append-only log of the results written to a batch file.
+// Every result is synced to disk as soon as it's written, so all results
+// survive the interruption and are applied to the batch file either when the
+// writer is flushed or the next time the batch file is opened.
+//
+// If the journal is ordered, the axis must be increasing row numbers. It enables
+// the journal to be merged with the batch file in a streaming way.
+//
+// The results of read-only macros are marked with the trailing "called" field,
+// so that they can be told apart from the transaction hashes.
+//
+// The journal file is created lazily when the first result is written.
+type resultJournal struct {
+	path    string
+	fd      *os.File
+	ordered bool
+	last    int
+	size    int
+}
+
+func newResultJournal(filename string, ordered bool) *resultJournal {
+	return &resultJournal{
+		path:    filename + journalSuffix,
+		ordered: ordered,
+	}
+}
+
+// journalCalled marks the entry recorded for the result of read-only macro.
+const journalCalled = "called"
+
+// append writes the result entry to the end of journal and syncs it to disk.
+func (journal *resultJournal) append(axis string, value string, called bool) error {
+	if journal.ordered {
+		row, err := strconv.Atoi(axis)
+		if err != nil {
+			return err
+		}
+		if journal.size > 0 && row <= journal.last {
+			return errRowOrder
+		}
+		journal.last = row
+	}
+	if journal.fd == nil {
+		fd, err := os.OpenFile(journal.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		journal.fd = fd
+	}
+	line := fmt.Sprintf("%s\t%s", axis, strconv.Quote(value))
+	if called {
+		line += "\t" + journalCalled
+	}
+	if _, err := fmt.Fprintln(journal.fd, line); err != nil {
+		return err
+	}
+	journal.size += 1
+	return journal.fd.Sync()
+}
+
+// close closes the journal file. The recorded results are still kept in disk.
+func (journal *resultJournal) close() error {
+	if journal.fd == nil {
+		return nil
+	}
+	err := journal.fd.Close()
+	journal.fd = nil
+	return err
+}
+
+// journalEntry is a single result recorded in the journal.
+type journalEntry struct {
+	axis   string
+	row    int // Only available for ordered journal
+	value  string
+	called bool // Whether the value is the result of read-only macro
+}
+
+// journalCursor iterates the entries of a journal file sequentially.
+type journalCursor struct {
+	fd      *os.File
+	scanner *bufio.Scanner
+	ordered bool
+	entry   *journalEntry
+	err     error
+}
+
+func newJournalCursor(path string, ordered bool) (*journalCursor, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	cursor := &journalCursor{
+		fd:      fd,
+		scanner: bufio.NewScanner(fd),
+		ordered: ordered,
+	}
+	cursor.next()
+	return cursor, nil
+}
+
+// next moves the cursor to the next entry. The entry is nil if iteration is
+// finished or an error occurs.
+func (cursor *journalCursor) next() {
+	cursor.entry = nil
+	if cursor.err != nil || !cursor.scanner.Scan() {
+		if cursor.err == nil {
+			cursor.err = cursor.scanner.Err()
+		}
+		return
+	}
+	parts := strings.SplitN(cursor.scanner.Text(), "\t", 3)
+	if len(parts) < 2 || (len(parts) == 3 && parts[2] != journalCalled) {
+		// The last entry might be partially written due to interruption.
+		cursor.err = errCorruptedJournal
+		return
+	}
+	value, err := strconv.Unquote(parts[1])
+	if err != nil {
+		cursor.err = errCorruptedJournal
+		return
+	}
+	entry := &journalEntry{axis: parts[0], value: value, called: len(parts) == 3}
+	if cursor.ordered {
+		if entry.row, err = strconv.Atoi(parts[0]); err != nil {
+			cursor.err = errCorruptedJournal
+			return
+		}
+	}
+	cursor.entry = entry
+}
+
+// match returns the current entry if it's recorded for the given row, and
+// moves the cursor forward.
+func (cursor *journalCursor) match(row int) *journalEntry {
+	if entry := cursor.entry; entry != nil && entry.row == row {
+		cursor.next()
+		return entry
+	}
+	return nil
+}
+
+func (cursor *journalCursor) close() error {
+	return cursor.fd.Close()
+}
+
+// journaledWriter is the base of batch file writers. All results are recorded
+// to the journal first and merged into the batch file by the apply function.
+type journaledWriter struct {
+	filename string
+	ordered  bool
+	journal  *resultJournal
+	apply    func(filename string, cursor *journalCursor) error
+}
+
+func newJournaledWriter(filename string, ordered bool, apply func(filename string, cursor *journalCursor) error) (*journaledWriter, error) {
+	return &journaledWriter{
+		filename: filename,
+		ordered:  ordered,
+		journal:  newResultJournal(filename, ordered),
+		apply:    apply,
+	}, nil
+}
+
+// Recover merges the results left by the interrupted run into the file. It's
+// called explicitly by the owner, since the batch file is only rewritten with
+// the results in inplace mode.
+func (writer *journaledWriter) Recover() error {
+	if _, err := os.Stat(writer.filename + journalSuffix); err != nil {
+		return nil
+	}
+	logger.Warningf("Recover the results of interrupted run from %s", writer.filename+journalSuffix)
+	return writer.applyJournal()
+}
+
+// replayJournal writes the results left in the batch file journal by the
+// interrupted inplace run to the result file, and removes the journal. The
+// batch file itself is left untouched.
+func replayJournal(batchfile string, results ResultWriter) error {
+	path := batchfile + journalSuffix
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	logger.Warningf("Replay the results of interrupted run from %s", path)
+	cursor, err := newJournalCursor(path, false)
+	if err != nil {
+		return err
+	}
+	err = func() error {
+		defer cursor.close()
+		for ; cursor.entry != nil; cursor.next() {
+			// The axis is the row number, optionally prefixed by the column letter
+			row, err := strconv.Atoi(strings.TrimLeft(cursor.entry.axis, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+			if err != nil {
+				return errCorruptedJournal
+			}
+			result := BatchResult{Row: row, Hash: cursor.entry.value, Status: ResultSent}
+			if cursor.entry.called {
+				result = BatchResult{Row: row, Status: ResultCalled, Result: cursor.entry.value}
+			}
+			if err := results.WriteResult(result); err != nil {
+				return err
+			}
+		}
+		return results.Flush()
+	}()
+	if err != nil {
+		return err
+	}
+	if cursor.err != nil {
+		logger.Warningf("Drop the corrupted results in %s: %v", path, cursor.err)
+	}
+	return os.Remove(path)
+}
+
+// applyJournal merges the results in journal into the batch file and removes
+// the journal afterwards. Corrupted tail of the journal is dropped.
+func (writer *journaledWriter) applyJournal() error {
+	path := writer.filename + journalSuffix
+	cursor, err := newJournalCursor(path, writer.ordered)
+	if err != nil {
+		return err
+	}
+	err = writer.apply(writer.filename, cursor)
+	cursor.close()
+	if err != nil {
+		return err
+	}
+	if cursor.err != nil {
+		logger.Warningf("Drop the corrupted results in %s: %v", path, cursor.err)
+	} else if cursor.entry != nil {
+		return errRowIndexExceed
+	}
+	return os.Remove(path)
+}
+
+func (writer *journaledWriter) WriteString(axis string, value string) error {
+	return writer.journal.append(axis, value, false)
+}
+
+// WriteCalled records the result of read-only macro, it takes the place of the
+// transaction hash in the batch file.
+func (writer *journaledWriter) WriteCalled(axis string, value string) error {
+	return writer.journal.append(axis, value, true)
+}
+
+// Flush merges all recorded results into the batch file.
+func (writer *journaledWriter) Flush() error {
+	if writer.journal.size == 0 {
+		return nil
+	}
+	if err := writer.journal.close(); err != nil {
+		return err
+	}
+	if err := writer.applyJournal(); err != nil {
+		return err
+	}
+	writer.journal = newResultJournal(writer.filename, writer.ordered)
+	return nil
+}
+
+// Close releases the journal file. The results not flushed yet are kept in
+// the journal and recovered the next time the batch file is opened.
+func (writer *journaledWriter) Close() error {
+	return writer.journal.close()
+}
+
+// rewriteFile replaces the content of given file atomically with the content
+// produced by the write function.
+func rewriteFile(filename string, write func(w *bufio.Writer) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := write(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if info, err := os.Stat(filename); err == nil {
+		os.Chmod(tmp.Name(), info.Mode())
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -17,18 +17,23 @@
 //
 // With marco definition, user can customize invocation data easily but with limitation of macro types.
 // Current support macro definitions:
-// #TRANSFER  <token symbol> <token number>|<token percentage>
-// #BALANCEOF <token symbol> <address>
+// #TRANSFER     <token symbol> <token number>|<token percentage>
+// #BALANCEOF    <token symbol> <address>
+// #APPROVE      <token symbol> <token number>|MAX
+// #ALLOWANCE    <token symbol> <owner address> <spender address>
+// #TRANSFERFROM <token symbol> <from address> <token number>|<token percentage>
+// #CALL         <contract address>|<token symbol> "<function signature>"|<method name> <arguments...>
+// #SEND         ETH <ether number>|<ether percentage>|ALL
+// #NFTTRANSFER  <collection address>|<collection alias> <token id>
+// #NFTOWNER     <collection address>|<collection alias> <token id>
+// #1155TRANSFER <collection address>|<collection alias> <token id> <amount>
+// #1155BALANCE  <collection address>|<collection alias> <token id> <holder address>
 package main
 
 import (
-	"encoding/json"
 	"errors"
-	"io/ioutil"
-	"math"
+	"fmt"
 	"math/big"
-	"os"
-	"os/exec"
 	"strconv"
 	"strings"
 
@@ -40,268 +45,689 @@ import (
 )
 
 const (
-	ethTokenJsonURL = "https://raw.githubusercontent.com/kvhnuke/etherwallet/mercury/app/scripts/tokens/ethTokens.json"
-	ethTokenFile    = "ethToken.json"
+	MacroTransfer     = "transfer"
+	MacroBalanceOf    = "balanceof"
+	MacroApprove      = "approve"
+	MacroAllowance    = "allowance"
+	MacroTransferFrom = "transferfrom"
+	MacroCall         = "call"
+	MacroSend         = "send"
+	MacroNFTTransfer  = "nfttransfer"
+	MacroNFTOwner     = "nftowner"
+	Macro1155Transfer = "1155transfer"
+	Macro1155Balance  = "1155balance"
 )
 
-const (
-	MacroTransfer  = "transfer"
-	MacroBalanceOf = "balanceof"
-)
+// maxAllowance is the maximum uint256 value used by the MAX approval.
+var maxAllowance = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
 
 var (
-	errNoDownloadToolInstalled   = errors.New("no download tool installed")
 	errInvalidMacroDefinition    = errors.New("invalid macro definition")
 	errInvalidMacroArgument      = errors.New("invalid macro argument")
 	errUndefinedMacro            = errors.New("undefined macro definition")
 	errUnrecognizableTokenSymbol = errors.New("the given token symbol is unrecognizable")
+	errTooPreciseAmount          = errors.New("token amount exceeds the token precision")
+	errValueMacro                = errors.New("#SEND sets the transaction value rather than data")
+	errNoClient                  = errors.New("ethereum client is required, specify it by --url")
 )
 
 var (
 	macroSet = NewMacroSet()
 )
 
-// Token packages all fields of a ECR20 token
-type Token struct {
-	Address string `json:"address"`
-	Symbol  string `json:"symbol"`
-	Decimal int    `json:"decimal"`
-	Type    string `json:"type"`
-}
-
-// ReadTokenList reads all available token information from the given file or fetch from the share json file in github.
-func ReadTokenList(path string) ([]Token, error) {
-	var (
-		tokens  []Token
-		content []byte
-		err     error
-	)
-	// If token list file is not been specified, fetch token list from the github
-	if _, err := os.Stat(path); err != nil && os.IsNotExist(err) {
-		content, err = downloadTokenList()
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		content, err = ioutil.ReadFile(path)
-		if err != nil {
-			return nil, err
-		}
-	}
-	err = json.Unmarshal(content, &tokens)
-	if err != nil {
-		return nil, err
-	}
-	return tokens, nil
-}
-
-// downloadTokenList downloads token list from the given url.
-func downloadTokenList() ([]byte, error) {
-	var (
-		toolType    int = 0
-		downloadCmd *exec.Cmd
-	)
-	// Make sure the download tool has been installed
-	isWgetInstalled := func() bool {
-		cmd := exec.Command("wget", "--help")
-		if err := cmd.Run(); err != nil {
-			return false
-		}
-		downloadCmd = exec.Command("wget", "-O", ethTokenFile, ethTokenJsonURL)
-		return true
-	}
-	isCurlInstalled := func() bool {
-		cmd := exec.Command("curl", "--help")
-		if err := cmd.Run(); err != nil {
-			return false
-		}
-		downloadCmd = exec.Command("curl", "-o", ethTokenFile, ethTokenJsonURL)
-		return true
-	}
-	if isWgetInstalled() {
-		toolType = 1
-	} else if isCurlInstalled() {
-		toolType = 2
-	}
-
-	if toolType == 0 {
-		return nil, errNoDownloadToolInstalled
-	}
-
-	if err := downloadCmd.Run(); err != nil {
-		return nil, err
-	}
-
-	return ioutil.ReadFile(ethTokenFile)
-}
-
 type Macro struct {
 	ArgNumber int
+	Args      []string // Argument usages reported in the errors
+	ReadOnly  bool     // Read-only macro is executed as call rather than sent as transaction
+	Output    string   // Output type of read-only macro, uint256 is scaled by the decimal
 }
 
 func NewMacroSet() map[string]Macro {
 	return map[string]Macro{
 		MacroTransfer: {
 			ArgNumber: 2,
+			Args:      []string{"<token symbol>", "<token number>|<token percentage>"},
 		},
 		MacroBalanceOf: {
 			ArgNumber: 2,
+			Args:      []string{"<token symbol>", "<holder address>"},
+			ReadOnly:  true,
+			Output:    "uint256",
+		},
+		MacroApprove: {
+			ArgNumber: 2,
+			Args:      []string{"<token symbol>", "<token number>|MAX"},
+		},
+		MacroAllowance: {
+			ArgNumber: 3,
+			Args:      []string{"<token symbol>", "<owner address>", "<spender address>"},
+			ReadOnly:  true,
+			Output:    "uint256",
+		},
+		MacroTransferFrom: {
+			ArgNumber: 3,
+			Args:      []string{"<token symbol>", "<from address>", "<token number>|<token percentage>"},
+		},
+		// The minimal argument number, the actual number is determined by the signature
+		MacroCall: {
+			ArgNumber: 2,
+			Args:      []string{"<contract>", "<function signature>|<method name>", "<arguments...>"},
+		},
+		MacroSend: {
+			ArgNumber: 2,
+			Args:      []string{"ETH", "<ether number>|<ether percentage>|ALL"},
+		},
+		MacroNFTTransfer: {
+			ArgNumber: 2,
+			Args:      []string{"<collection>", "<token id>"},
+		},
+		MacroNFTOwner: {
+			ArgNumber: 2,
+			Args:      []string{"<collection>", "<token id>"},
+			ReadOnly:  true,
+			Output:    "address",
+		},
+		Macro1155Transfer: {
+			ArgNumber: 3,
+			Args:      []string{"<collection>", "<token id>", "<amount>"},
+		},
+		Macro1155Balance: {
+			ArgNumber: 3,
+			Args:      []string{"<collection>", "<token id>", "<holder address>"},
+			ReadOnly:  true,
+			Output:    "uint256",
 		},
 	}
 }
 
 type MacroParser struct {
-	client *client.Client
-	tokens map[string]Token
+	client    *client.Client
+	registry  *TokenRegistry
+	functions []*abiFunction              // Functions loaded from abi files, referenced by name in #CALL
+	macros    map[string]*MacroDefinition // User macros loaded from macro files
+	block     *BlockTag                   // Block of read-only macros, nil for the latest
+	reading   bool                        // Whether a read-only macro is being parsed, see stateBlock
 }
 
-func NewMacroParser(client *client.Client, path string) (*MacroParser, error) {
-	tokenList, err := ReadTokenList(path)
-	if err != nil {
-		return nil, err
+func NewMacroParser(client *client.Client, registry *TokenRegistry) *MacroParser {
+	return &MacroParser{
+		client:   client,
+		registry: registry,
 	}
+}
 
-	tokens := map[string]Token{}
-	for _, token := range tokenList {
-		tokens[strings.ToLower(token.Symbol)] = token
+// LoadABI loads the functions defined in the json abi file, so that they can be
+// referenced by method name in #CALL macro.
+func (mp *MacroParser) LoadABI(path string) error {
+	functions, err := loadABIFunctions(path)
+	if err != nil {
+		return err
 	}
+	mp.functions = append(mp.functions, functions...)
+	return nil
+}
 
-	parser := &MacroParser{
-		client: client,
-		tokens: tokens,
+// LoadMacros loads the user macros defined in the macro file, which can be used
+// alongside the built-in macros.
+func (mp *MacroParser) LoadMacros(path string) error {
+	definitions, err := loadMacroFile(path, mp.registry)
+	if err != nil {
+		return err
 	}
-	return parser, nil
+	if mp.macros == nil {
+		mp.macros = make(map[string]*MacroDefinition)
+	}
+	for _, def := range definitions {
+		name := strings.ToLower(def.Name)
+		if _, exist := mp.macros[name]; exist {
+			return fmt.Errorf("%s: %v, macro %q is already defined", path, errInvalidMacroFile, def.Name)
+		}
+		mp.macros[name] = def
+	}
+	return nil
 }
 
 // isMacroDefinition checks whether the given string is a macro definition.
 func (mp *MacroParser) isMacroDefinition(input string) bool {
-	lines := strings.Split(input, " ")
-	return len(lines) >= 1 && strings.HasPrefix(lines[0], "#")
+	return strings.HasPrefix(strings.TrimLeft(input, " \t\r\n"), "#")
 }
 
-// Parse parses the given macro definition string and returns a valid contract invocation data.
-func (mp *MacroParser) Parse(input, sender, receiver string) (common.Address, string, int, error) {
-	lines := strings.Split(input, " ")
-	for idx, line := range lines {
-		lines[idx] = strings.Trim(line, " ")
+// isReadMacro checks whether the given string is a read-only macro, which should
+// be executed as call and whose result is recorded instead of sending. The user
+// macro is read-only if its function is view or pure.
+func (mp *MacroParser) isReadMacro(input string) bool {
+	fields, err := splitMacroFields(input)
+	if err != nil {
+		return false
+	}
+	name := strings.ToLower(fields[0][1:])
+	if macro, exist := macroSet[name]; exist {
+		return macro.ReadOnly
+	}
+	if def, exist := mp.macros[name]; exist {
+		return def.fn.Constant
+	}
+	return false
+}
+
+// Query executes the read-only macro as call in the context of given row and
+// returns the decoded result, the amounts are scaled by the token decimals.
+func (mp *MacroParser) Query(input, sender, receiver string, row int) (string, error) {
+	if !mp.isReadMacro(input) {
+		return "", errInvalidMacroDefinition
+	}
+	// The balances in arguments are looked up at the same block as the call
+	reader := *mp
+	reader.reading = true
+	to, payload, decimal, err := reader.Parse(input, sender, receiver, row)
+	if err != nil {
+		return "", err
+	}
+	output, err := call(mp.client, mp.block, &ethereum.CallMsg{
+		From: common.HexToAddress(sender),
+		To:   &to,
+		Data: common.FromHex(payload),
+	})
+	if err != nil {
+		return "", err
+	}
+	fields, err := splitMacroFields(input)
+	if err != nil {
+		return "", err
+	}
+	name := strings.ToLower(fields[0][1:])
+	if macro, exist := macroSet[name]; exist {
+		return decodeMacroResult(macro.Output, output, decimal)
 	}
+	return decodeFunctionResult(mp.macros[name].fn, output)
+}
 
-	if len(lines) < 1 {
-		return common.Address{}, "", 0, errInvalidMacroDefinition
+// decodeFunctionResult decodes the call output of user macro with the return
+// types of its function, the output is returned in hex if they're not declared.
+func decodeFunctionResult(fn *abiFunction, output []byte) (string, error) {
+	if len(fn.Outputs) == 0 {
+		return common.ToHex(output), nil
+	}
+	values, err := fn.UnpackOutput(output)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s result 0x%x: %v", fn.Sig(), output, err)
+	}
+	results := make([]string, len(values))
+	for idx, value := range values {
+		results[idx] = formatABIValue(fn.Outputs[idx], value)
 	}
+	return strings.Join(results, " "), nil
+}
 
-	keyword := lines[0]
-	if !strings.HasPrefix(keyword, "#") {
-		return common.Address{}, "", 0, errInvalidMacroDefinition
+// decodeMacroResult decodes the call output of read-only macro with given type.
+func decodeMacroResult(typ string, output []byte, decimal int) (string, error) {
+	if len(output) < 32 {
+		return "", fmt.Errorf("invalid %s result 0x%x", typ, output)
 	}
+	switch typ {
+	case "uint256":
+		return formatAmount(new(big.Int).SetBytes(output[:32]), decimal), nil
+	case "address":
+		return common.BytesToAddress(output[:32]).Hex(), nil
+	}
+	return "", fmt.Errorf("unsupported result type %s", typ)
+}
+
+// isValueMacro checks whether the given string is a macro which sets the
+// transaction value instead of the invocation data.
+func (mp *MacroParser) isValueMacro(input string) bool {
+	lines := strings.Fields(input)
+	return len(lines) >= 1 && strings.ToLower(lines[0]) == "#"+MacroSend
+}
+
+// parseFields splits the macro into fields and substitutes the expression
+// arguments in the context of given row, quoted strings are left as they are.
+// The argument number of built-in macro is checked here, so that the error
+// points to the missing or unexpected argument.
+func (mp *MacroParser) parseFields(input, sender, receiver string, row int) ([]string, *macroEnv, error) {
+	syntax, err := parseMacro(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := strings.ToLower(syntax.keyword[1:])
+	if macro, exist := macroSet[name]; exist {
+		keyword := strings.ToUpper(syntax.keyword)
+		switch {
+		case len(syntax.args) < macro.ArgNumber:
+			return nil, nil, &MacroSyntaxError{Kind: errInvalidMacroArgument, Column: syntax.end,
+				Msg: fmt.Sprintf("expected %s, usage: %s %s", macro.Args[len(syntax.args)], keyword, strings.Join(macro.Args, " "))}
+		case len(syntax.args) > macro.ArgNumber && name != MacroCall:
+			extra := syntax.args[macro.ArgNumber]
+			return nil, nil, &MacroSyntaxError{Kind: errInvalidMacroArgument, Column: extra.column,
+				Msg: fmt.Sprintf("unexpected argument %q, usage: %s %s", extra.text, keyword, strings.Join(macro.Args, " "))}
+		}
+	}
+	lines := []string{syntax.keyword}
+	for _, arg := range syntax.args {
+		lines = append(lines, arg.text)
+	}
+	env := mp.newEnv(sender, receiver, row)
+	for idx := 1; idx < len(lines); idx++ {
+		if strings.HasPrefix(lines[idx], "\"") || !isMacroExpression(lines[idx]) {
+			continue
+		}
+		value, err := evalExpression(lines[idx], env)
+		if err != nil {
+			return nil, nil, err
+		}
+		lines[idx] = formatExprValue(value)
+	}
+	return lines, env, nil
+}
+
+// Parse parses the given macro definition string and returns a valid contract invocation data.
+// The expression arguments are evaluated in the context of given row.
+func (mp *MacroParser) Parse(input, sender, receiver string, row int) (common.Address, string, int, error) {
+	lines, env, err := mp.parseFields(input, sender, receiver, row)
+	if err != nil {
+		return common.Address{}, "", 0, err
+	}
+	keyword := lines[0]
 	switch strings.ToLower(keyword[1:]) {
 	case MacroTransfer:
 		addr, payload, err := mp.parseTransfer(lines[1:], sender, receiver)
 		return addr, payload, 0, err
 	case MacroBalanceOf:
 		return mp.parseBalanceOf(lines[1:])
+	case MacroApprove:
+		addr, payload, err := mp.parseApprove(lines[1:], receiver)
+		return addr, payload, 0, err
+	case MacroAllowance:
+		return mp.parseAllowance(lines[1:])
+	case MacroTransferFrom:
+		addr, payload, err := mp.parseTransferFrom(lines[1:], sender, receiver)
+		return addr, payload, 0, err
+	case MacroCall:
+		addr, payload, err := mp.parseCall(lines[1:])
+		return addr, payload, 0, err
+	case MacroSend:
+		return common.Address{}, "", 0, errValueMacro
+	case MacroNFTTransfer:
+		addr, payload, err := mp.parseNFTTransfer(lines[1:], sender, receiver)
+		return addr, payload, 0, err
+	case MacroNFTOwner:
+		addr, payload, err := mp.parseNFTOwner(lines[1:])
+		return addr, payload, 0, err
+	case Macro1155Transfer:
+		addr, payload, err := mp.parse1155Transfer(lines[1:], sender, receiver)
+		return addr, payload, 0, err
+	case Macro1155Balance:
+		addr, payload, err := mp.parse1155Balance(lines[1:])
+		return addr, payload, 0, err
 	default:
+		if def, exist := mp.macros[strings.ToLower(keyword[1:])]; exist {
+			addr, payload, err := mp.parseUserMacro(def, lines[1:], env)
+			return addr, payload, 0, err
+		}
 		return common.Address{}, "", 0, errUndefinedMacro
 	}
 }
 
+// ParseValue parses the send macro, which sets the transaction value instead of
+// the invocation data.
+// Send macro syntax:
+// #SEND ETH (<Ether number> Or <Ether percentage> Or ALL)
+// Return value:
+// value in wei, whether the whole balance should be swept and error
+//
+// The ether number is exact within 18 decimals, the percentage is relative to
+// the ether balance of sender and rounded down to wei. ALL sweeps the balance
+// minus the transaction fee, which is only known once the gas is estimated, so
+// the value is left to the sender.
+func (mp *MacroParser) ParseValue(input, sender, receiver string, row int) (*big.Int, bool, error) {
+	lines, env, err := mp.parseFields(input, sender, receiver, row)
+	if err != nil {
+		return nil, false, err
+	}
+	if strings.ToLower(lines[0][1:]) != MacroSend {
+		return nil, false, errInvalidMacroDefinition
+	}
+	if len(lines)-1 != macroSet[MacroSend].ArgNumber || strings.ToUpper(lines[1]) != "ETH" {
+		return nil, false, errInvalidMacroArgument
+	}
+	arg := lines[2]
+	if strings.ToUpper(arg) == "ALL" {
+		return nil, true, nil
+	}
+	if !strings.HasSuffix(arg, "%") {
+		value, err := parseTokenAmount(arg, 18)
+		return value, false, err
+	}
+	percentage, ok := parseDecimal(arg[:len(arg)-1])
+	if !ok || percentage.Cmp(new(big.Rat).SetInt64(100)) > 0 {
+		return nil, false, errInvalidMacroArgument
+	}
+	balance, err := env.ethBalance(env.sender)
+	if err != nil {
+		return nil, false, err
+	}
+	value := new(big.Rat).Mul(new(big.Rat).SetInt(balance), percentage)
+	value.Quo(value, new(big.Rat).SetInt64(100))
+	return new(big.Int).Quo(value.Num(), value.Denom()), false, nil
+}
+
 // parseTransfer parses transfer macro.
 // Transfer macro syntax:
 // #TRANSFER <Token symbol> (<Token number> Or <Token percentage>)
 // Return value:
 // contract address, invocation data and error
 func (mp *MacroParser) parseTransfer(lines []string, sender, receiver string) (common.Address, string, error) {
-	var (
-		address string
-		decimal int
-		amount  *big.Int
-		err     error
-	)
 	if len(lines) != macroSet[MacroTransfer].ArgNumber {
 		return common.Address{}, "", errInvalidMacroArgument
 	}
-	symbol := lines[0]
-	if token, exist := mp.tokens[strings.ToLower(symbol)]; exist {
-		address = token.Address
-		decimal = token.Decimal
-	} else {
-		return common.Address{}, "", errUnrecognizableTokenSymbol
+	token, err := mp.lookupToken(lines[0])
+	if err != nil {
+		return common.Address{}, "", err
 	}
-	// Assemble the payload
-	parsed, err := abi.JSON(strings.NewReader(resource.ERC20InterfaceABI))
+	amount, err := mp.parseAmount(lines[1], token, sender, sender)
 	if err != nil {
 		return common.Address{}, "", err
 	}
+	return mp.pack(token, "transfer", common.HexToAddress(receiver), amount)
+}
 
-	if strings.HasSuffix(lines[1], "%") {
-		percantange, err := strconv.ParseFloat(lines[1][:len(lines[1])-1], 64)
-		if err != nil {
-			return common.Address{}, "", err
-		}
-		// Fetch the balance
-		// Spawn a balance query
-		query, err := parsed.Pack("balanceOf", common.HexToAddress(sender))
-		if err != nil {
-			return common.Address{}, "", err
-		}
-		to := common.HexToAddress(address)
-		result, err := call(mp.client, &ethereum.CallMsg{
-			From: common.HexToAddress(sender),
-			To:   &to,
-			Data: query,
-		})
+// parseBalanceOf parses balanceOf macro.
+// BalanceOf macro syntax:
+// #BALANCEOF <Token symbol> <token holder address>
+// Return value:
+// contract address, invocation data, decimal and error
+func (mp *MacroParser) parseBalanceOf(lines []string) (common.Address, string, int, error) {
+	if len(lines) != macroSet[MacroBalanceOf].ArgNumber {
+		return common.Address{}, "", 0, errInvalidMacroArgument
+	}
+	token, err := mp.lookupToken(lines[0])
+	if err != nil {
+		return common.Address{}, "", 0, err
+	}
+	addr, payload, err := mp.pack(token, "balanceOf", common.HexToAddress(lines[1]))
+	return addr, payload, token.Decimal, err
+}
+
+// parseApprove parses approve macro, the receiver is approved as the spender.
+// Approve macro syntax:
+// #APPROVE <Token symbol> (<Token number> Or MAX)
+// Return value:
+// contract address, invocation data and error
+func (mp *MacroParser) parseApprove(lines []string, receiver string) (common.Address, string, error) {
+	if len(lines) != macroSet[MacroApprove].ArgNumber {
+		return common.Address{}, "", errInvalidMacroArgument
+	}
+	token, err := mp.lookupToken(lines[0])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	var amount *big.Int
+	if strings.ToUpper(lines[1]) == "MAX" {
+		amount = maxAllowance
+	} else if strings.HasSuffix(lines[1], "%") {
+		return common.Address{}, "", errInvalidMacroArgument
+	} else if amount, err = mp.parseAmount(lines[1], token, "", ""); err != nil {
+		return common.Address{}, "", err
+	}
+	return mp.pack(token, "approve", common.HexToAddress(receiver), amount)
+}
+
+// parseAllowance parses allowance macro.
+// Allowance macro syntax:
+// #ALLOWANCE <Token symbol> <owner address> <spender address>
+// Return value:
+// contract address, invocation data, decimal and error
+func (mp *MacroParser) parseAllowance(lines []string) (common.Address, string, int, error) {
+	if len(lines) != macroSet[MacroAllowance].ArgNumber {
+		return common.Address{}, "", 0, errInvalidMacroArgument
+	}
+	if !common.IsHexAddress(lines[1]) || !common.IsHexAddress(lines[2]) {
+		return common.Address{}, "", 0, errInvalidMacroArgument
+	}
+	token, err := mp.lookupToken(lines[0])
+	if err != nil {
+		return common.Address{}, "", 0, err
+	}
+	addr, payload, err := mp.pack(token, "allowance", common.HexToAddress(lines[1]), common.HexToAddress(lines[2]))
+	return addr, payload, token.Decimal, err
+}
+
+// parseTransferFrom parses transferFrom macro, the tokens are transferred from
+// the given address to the receiver by the sender. The percentage is relative
+// to the balance of the given address.
+// TransferFrom macro syntax:
+// #TRANSFERFROM <Token symbol> <from address> (<Token number> Or <Token percentage>)
+// Return value:
+// contract address, invocation data and error
+func (mp *MacroParser) parseTransferFrom(lines []string, sender, receiver string) (common.Address, string, error) {
+	if len(lines) != macroSet[MacroTransferFrom].ArgNumber {
+		return common.Address{}, "", errInvalidMacroArgument
+	}
+	if !common.IsHexAddress(lines[1]) {
+		return common.Address{}, "", errInvalidMacroArgument
+	}
+	token, err := mp.lookupToken(lines[0])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	amount, err := mp.parseAmount(lines[2], token, lines[1], sender)
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	return mp.pack(token, "transferFrom", common.HexToAddress(lines[1]), common.HexToAddress(receiver), amount)
+}
+
+// parseCall parses the generic call macro. The function is given by signature,
+// e.g. "transfer(address,uint256)", or by method name if the contract abi is
+// loaded. The arguments are parsed per solidity type, see parseABIValue.
+// Call macro syntax:
+// #CALL <Contract address Or Token symbol> <Function signature Or Method name> <Arguments...>
+// Return value:
+// contract address, invocation data and error
+func (mp *MacroParser) parseCall(lines []string) (common.Address, string, error) {
+	if len(lines) < macroSet[MacroCall].ArgNumber {
+		return common.Address{}, "", errInvalidMacroArgument
+	}
+	var contract common.Address
+	if common.IsHexAddress(lines[0]) {
+		contract = common.HexToAddress(lines[0])
+	} else {
+		token, err := mp.lookupToken(lines[0])
 		if err != nil {
 			return common.Address{}, "", err
 		}
-		amount = big.NewInt(0)
-		if err := parsed.Unpack(&amount, "balanceOf", result); err != nil {
-			return common.Address{}, "", err
-		}
-		amount.Mul(amount, big.NewInt(int64(percantange))).Div(amount, big.NewInt(100))
-	} else {
-		v, err := strconv.ParseInt(lines[1], 10, 64)
+		contract = common.HexToAddress(token.Address)
+	}
+	signature := lines[1]
+	if strings.HasPrefix(signature, "\"") {
+		unquoted, err := strconv.Unquote(signature)
 		if err != nil {
-			return common.Address{}, "", err
+			return common.Address{}, "", errInvalidMacroArgument
 		}
-		amount = big.NewInt(v)
-		amount.Mul(amount, big.NewInt(int64(math.Pow10(decimal))))
+		signature = unquoted
 	}
-	input, err := parsed.Pack("transfer", common.HexToAddress(receiver), amount)
+	fn, err := mp.lookupFunction(signature, len(lines)-2)
 	if err != nil {
 		return common.Address{}, "", err
 	}
-	return common.HexToAddress(address), common.Bytes2Hex(input), err
+	input, err := fn.PackString(lines[2:])
+	if err != nil {
+		return common.Address{}, "", fmt.Errorf("%v %s: %v", errInvalidMacroArgument, fn.Sig(), err)
+	}
+	return contract, common.Bytes2Hex(input), nil
 }
 
-// parseTransfer parses balanceOf macro.
-// BalanceOf macro syntax:
-// #BALANCEOF <Token symbol> <token holder address>
+// lookupFunction returns the function with given signature, or the function
+// with given name and argument number in the loaded abi files.
+func (mp *MacroParser) lookupFunction(signature string, args int) (*abiFunction, error) {
+	return findABIFunction(mp.functions, signature, args)
+}
+
+// splitMacroFields splits the macro definition into the keyword and arguments,
+// see macro_lexer.go for the grammar.
+func splitMacroFields(input string) ([]string, error) {
+	syntax, err := parseMacro(input)
+	if err != nil {
+		return nil, err
+	}
+	fields := []string{syntax.keyword}
+	for _, arg := range syntax.args {
+		fields = append(fields, arg.text)
+	}
+	return fields, nil
+}
+
+// newEnv returns the expression evaluation environment of row.
+func (mp *MacroParser) newEnv(sender, receiver string, row int) *macroEnv {
+	return &macroEnv{
+		sender:   common.HexToAddress(sender),
+		receiver: common.HexToAddress(receiver),
+		row:      row,
+		tokenBalance: func(symbol string, holder common.Address) (*big.Rat, error) {
+			token, err := mp.lookupToken(symbol)
+			if err != nil {
+				return nil, err
+			}
+			balance, err := mp.balanceOf(token, holder.Hex(), sender)
+			if err != nil {
+				return nil, err
+			}
+			unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(token.Decimal)), nil)
+			return new(big.Rat).SetFrac(balance, unit), nil
+		},
+		ethBalance: func(holder common.Address) (*big.Int, error) {
+			if mp.client == nil {
+				return nil, errNoClient
+			}
+			return balanceAt(mp.client, mp.stateBlock(), holder)
+		},
+	}
+}
+
+// EvaluateValue evaluates the expression in value column of row. The result is
+// in wei and rounded down.
+func (mp *MacroParser) EvaluateValue(expr, sender, receiver string, row int) (*big.Int, error) {
+	value, err := evalNumber(expr, mp.newEnv(sender, receiver, row))
+	if err != nil {
+		return nil, err
+	}
+	if value.Sign() < 0 {
+		return nil, fmt.Errorf("%v %s: negative value %s", errInvalidExpression, expr, value.FloatString(0))
+	}
+	return new(big.Int).Quo(value.Num(), value.Denom()), nil
+}
+
+// lookupToken returns the token with given symbol or address. The unknown token
+// address is resolved on-chain and cached in the token registry.
+func (mp *MacroParser) lookupToken(ref string) (Token, error) {
+	token, err := mp.registry.Lookup(ref)
+	if err != errUnrecognizableTokenSymbol || !common.IsHexAddress(ref) || mp.client == nil {
+		return token, err
+	}
+	if token, err = fetchToken(mp.client, common.HexToAddress(ref)); err != nil {
+		return Token{}, fmt.Errorf("failed to resolve token %s: %v", ref, err)
+	}
+	for _, conflict := range mp.registry.Conflicts(token) {
+		logger.Warningf("Token %s resolved on-chain has the same symbol as %s(%s) in %s, make sure it's the right one",
+			token.Address, conflict.Symbol, common.HexToAddress(conflict.Address).Hex(), conflict.source)
+	}
+	if err := mp.registry.Cache(token); err != nil {
+		logger.Warningf("Failed to cache token %s: %v", token.Address, err)
+	}
+	logger.Noticef("Resolved token %s(%s) with %d decimals", token.Symbol, token.Address, token.Decimal)
+	return token, nil
+}
+
+// pack assembles the invocation data of ERC20 method.
 // Return value:
-// contract address, invocation data, decimal and error
-func (mp *MacroParser) parseBalanceOf(lines []string) (common.Address, string, int, error) {
-	var (
-		address string
-		decimal int
-		err     error
-	)
-	if len(lines) != macroSet[MacroBalanceOf].ArgNumber {
-		return common.Address{}, "", 0, errInvalidMacroArgument
+// contract address, invocation data and error
+func (mp *MacroParser) pack(token Token, method string, args ...interface{}) (common.Address, string, error) {
+	return packContract(resource.ERC20InterfaceABI, common.HexToAddress(token.Address), method, args...)
+}
+
+// parseAmount parses the token amount argument in token units, fractional
+// amounts are allowed within the precision of token. The percentage, which can
+// be fractional as well, is relative to the token balance of holder queried by
+// caller and the result is rounded down to the smallest token unit.
+func (mp *MacroParser) parseAmount(arg string, token Token, holder, caller string) (*big.Int, error) {
+	if !strings.HasSuffix(arg, "%") {
+		return parseTokenAmount(arg, token.Decimal)
 	}
-	symbol := lines[0]
-	if token, exist := mp.tokens[strings.ToLower(symbol)]; exist {
-		address = token.Address
-		decimal = token.Decimal
-	} else {
-		return common.Address{}, "", 0, errUnrecognizableTokenSymbol
+	percentage, ok := parseDecimal(arg[:len(arg)-1])
+	if !ok || percentage.Cmp(new(big.Rat).SetInt64(100)) > 0 {
+		return nil, errInvalidMacroArgument
+	}
+	// Fetch the balance
+	balance, err := mp.balanceOf(token, holder, caller)
+	if err != nil {
+		return nil, err
+	}
+	amount := new(big.Rat).Mul(new(big.Rat).SetInt(balance), percentage)
+	amount.Quo(amount, new(big.Rat).SetInt64(100))
+	return new(big.Int).Quo(amount.Num(), amount.Denom()), nil
+}
+
+// parseTokenAmount converts the decimal amount in token units to the amount in
+// the smallest token unit, e.g. 1.5 of token with 18 decimals is 1.5 * 10^18.
+// The amount with more fractional digits than token decimals is rejected rather
+// than rounded.
+func parseTokenAmount(arg string, decimals int) (*big.Int, error) {
+	amount, ok := parseDecimal(arg)
+	if !ok {
+		return nil, errInvalidMacroArgument
+	}
+	amount.Mul(amount, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	if !amount.IsInt() {
+		return nil, errTooPreciseAmount
+	}
+	return new(big.Int).Set(amount.Num()), nil
+}
+
+// parseDecimal parses the non-negative decimal number exactly, e.g. "12.5".
+// Exponents and fractions are not accepted.
+func parseDecimal(arg string) (*big.Rat, bool) {
+	if arg == "" || strings.Count(arg, ".") > 1 || strings.Trim(arg, "0123456789.") != "" || strings.Trim(arg, ".") == "" {
+		return nil, false
+	}
+	return new(big.Rat).SetString(arg)
+}
+
+// stateBlock returns the block which the balances used by macro are looked up
+// on. The specified block only applies to the read-only macros, the write macros
+// are always sized with the latest state since they're sent against it.
+func (mp *MacroParser) stateBlock() *BlockTag {
+	if !mp.reading {
+		return nil
+	}
+	return mp.block
+}
+
+// balanceOf queries the token balance of holder.
+func (mp *MacroParser) balanceOf(token Token, holder, caller string) (*big.Int, error) {
+	if mp.client == nil {
+		return nil, errNoClient
 	}
-	// Assemble the payload
 	parsed, err := abi.JSON(strings.NewReader(resource.ERC20InterfaceABI))
 	if err != nil {
-		return common.Address{}, "", 0, err
+		return nil, err
 	}
-	input, err := parsed.Pack("balanceOf", common.HexToAddress(lines[1]))
+	// Spawn a balance query
+	query, err := parsed.Pack("balanceOf", common.HexToAddress(holder))
 	if err != nil {
-		return common.Address{}, "", 0, err
+		return nil, err
+	}
+	to := common.HexToAddress(token.Address)
+	result, err := call(mp.client, mp.stateBlock(), &ethereum.CallMsg{
+		From: common.HexToAddress(caller),
+		To:   &to,
+		Data: query,
+	})
+	if err != nil {
+		return nil, err
+	}
+	amount := big.NewInt(0)
+	if err := parsed.Unpack(&amount, "balanceOf", result); err != nil {
+		return nil, err
 	}
-	return common.HexToAddress(address), common.Bytes2Hex(input), decimal, err
+	return amount, nil
 }
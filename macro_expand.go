@@ -0,0 +1,292 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rjl493456442/ethclient/client"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	expandFileFlag = cli.StringFlag{
+		Name:  "expandfile",
+		Usage: "csv file path for the expanded batch rows. If not specified, <batchfile>.expanded.csv is used",
+	}
+)
+
+var commandMacro = cli.Command{
+	Name:  "macro",
+	Usage: "Inspect the macro definitions",
+	Subcommands: []cli.Command{
+		{
+			Name:        "expand",
+			Usage:       "Expand the macro into the contract invocation without sending",
+			ArgsUsage:   "<macro>",
+			Description: "Print the contract address, method, decoded arguments and calldata of the macro. With --batchfile, every row of the batch file is expanded and written to a csv file for review",
+			Flags: []cli.Flag{
+				clientFlag,
+				senderFlag,
+				receiverFlag,
+				batchFileFlag,
+				formatFlag,
+				sheetFlag,
+				expandFileFlag,
+				tokenfileFlag,
+				tokenDirFlag,
+				chainIdFlag,
+				abiFlag,
+				macroFileFlag,
+				blockFlag,
+			},
+			Action: ExpandMacro,
+		},
+	},
+}
+
+// MacroExpansion is the contract invocation which the macro turns into.
+type MacroExpansion struct {
+	To       common.Address // Contract address, or the receiver of #SEND
+	Value    *big.Int       // Ether value in wei set by #SEND, nil if it's swept
+	Sweep    bool           // Whether the whole balance is swept by #SEND
+	Data     string         // Invocation data in hex
+	Function *abiFunction   // Function invoked, nil if it's unknown
+	Args     []string       // Decoded arguments of function
+	ReadOnly bool           // Whether the macro is executed as call
+}
+
+// Expand expands the macro in the context of given row. The arguments are
+// decoded with the function found by selector in the macro signature, the
+// loaded abi files, the user macros and the standard token interfaces.
+func (mp *MacroParser) Expand(input, sender, receiver string, row int) (*MacroExpansion, error) {
+	if mp.isValueMacro(input) {
+		value, sweep, err := mp.ParseValue(input, sender, receiver, row)
+		if err != nil {
+			return nil, err
+		}
+		return &MacroExpansion{To: common.HexToAddress(receiver), Value: value, Sweep: sweep}, nil
+	}
+	to, payload, _, err := mp.Parse(input, sender, receiver, row)
+	if err != nil {
+		return nil, err
+	}
+	expansion := &MacroExpansion{To: to, Data: payload, ReadOnly: mp.isReadMacro(input)}
+	expansion.decode(mp.candidates(input))
+	return expansion, nil
+}
+
+// decode decodes the invocation data with the first function whose selector
+// matches, the data is left undecoded if there is no such function.
+func (expansion *MacroExpansion) decode(candidates []*abiFunction) {
+	fn, values, err := decodeCalldata(candidates, common.FromHex(expansion.Data))
+	if err != nil {
+		return
+	}
+	expansion.Function, expansion.Args = fn, nil
+	for idx, value := range values {
+		expansion.Args = append(expansion.Args, formatABIValue(fn.Inputs[idx], value))
+	}
+}
+
+// candidates returns the functions which the macro may invoke.
+func (mp *MacroParser) candidates(input string) []*abiFunction {
+	var functions []*abiFunction
+	if fields, err := splitMacroFields(input); err == nil {
+		name := strings.ToLower(fields[0][1:])
+		if name == MacroCall && len(fields) > 2 {
+			signature := fields[2]
+			if unquoted, err := strconv.Unquote(signature); err == nil {
+				signature = unquoted
+			}
+			if fn, err := parseABISignature(signature); err == nil {
+				functions = append(functions, fn)
+			}
+		}
+		if def, exist := mp.macros[name]; exist {
+			functions = append(functions, def.fn)
+		}
+	}
+	functions = append(functions, mp.functions...)
+	return append(functions, builtinABI.Functions...)
+}
+
+// ExpandMacro expands the macro given in command line, or every row of batch
+// file if it's specified.
+func ExpandMacro(ctx *cli.Context) error {
+	var client *client.Client
+	if ctx.String(clientFlag.Name) != "" {
+		var err error
+		if client, err = getClient(ctx); err != nil {
+			return err
+		}
+	}
+	mp, err := getMacroParser(ctx, client)
+	if err != nil {
+		return err
+	}
+	if err := setupMacroParser(ctx, mp); err != nil {
+		return err
+	}
+	if ctx.IsSet(batchFileFlag.Name) {
+		return expandBatch(ctx, mp)
+	}
+	if ctx.NArg() != 1 {
+		return errInvalidArguments
+	}
+	expansion, err := mp.Expand(ctx.Args().First(), ctx.String(senderFlag.Name), ctx.String(receiverFlag.Name), 0)
+	if err != nil {
+		return err
+	}
+	return printExpansion(os.Stdout, expansion)
+}
+
+// printExpansion prints the expanded macro in human readable form.
+func printExpansion(out io.Writer, expansion *MacroExpansion) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	if expansion.Data == "" {
+		fmt.Fprintf(w, "Receiver:\t%s\n", expansion.To.Hex())
+		fmt.Fprintf(w, "Value:\t%s\n", expansion.value())
+		return w.Flush()
+	}
+	fmt.Fprintf(w, "Contract:\t%s\n", expansion.To.Hex())
+	if expansion.Function != nil {
+		fmt.Fprintf(w, "Method:\t%s\n", expansion.Function.Sig())
+		for idx, arg := range expansion.Args {
+			fmt.Fprintf(w, "Argument %d:\t%s\t%s\n", idx, expansion.Function.Inputs[idx], arg)
+		}
+	} else {
+		fmt.Fprintf(w, "Method:\tunknown\n")
+	}
+	if expansion.ReadOnly {
+		fmt.Fprintf(w, "Execution:\tcall\n")
+	} else {
+		fmt.Fprintf(w, "Execution:\ttransaction\n")
+	}
+	fmt.Fprintf(w, "Data:\t0x%s\n", expansion.Data)
+	return w.Flush()
+}
+
+// value returns the ether value of expansion in wei.
+func (expansion *MacroExpansion) value() string {
+	if expansion.Sweep {
+		return "ALL (balance minus the transaction fee)"
+	}
+	if expansion.Value == nil {
+		return "0"
+	}
+	return expansion.Value.String()
+}
+
+// expandColumns is the column order of expanded batch file, the original row
+// is followed by the expanded fields.
+var expandColumns = []string{"row", "from", "to", "value", "data", "expanded_to", "expanded_value", "expanded_data", "method", "arguments", "error"}
+
+// expandBatch expands every row of batch file and writes them to a csv file,
+// the failed rows are kept with the error.
+func expandBatch(ctx *cli.Context, mp *MacroParser) error {
+	batchfile := getBatchFile(ctx)
+	rw, err := OpenBatchFile(batchfile, ctx.String(formatFlag.Name), FormatOptions{Sheet: getSheetId(ctx)})
+	if err != nil {
+		return err
+	}
+	defer rw.Close()
+
+	output := ctx.String(expandFileFlag.Name)
+	if output == "" {
+		output = strings.TrimSuffix(batchfile, filepath.Ext(batchfile)) + ".expanded.csv"
+	}
+	fd, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	if _, err := fd.Write(encodeCSVLine(expandColumns)); err != nil {
+		return err
+	}
+	var rows, failed int
+	for {
+		entry, err := rw.Read()
+		if err == io.EOF {
+			break
+		}
+		var fields []string
+		if corrupted, ok := err.(*ErrCorrupted); ok {
+			fields = []string{strconv.Itoa(int(corrupted.Pos)), "", "", "", "", "", "", "", "", "", corrupted.Reason}
+		} else if err != nil {
+			return err
+		} else {
+			fields = expandRow(mp, entry)
+		}
+		if fields[len(fields)-1] != "" {
+			failed += 1
+		}
+		rows += 1
+		if _, err := fd.Write(encodeCSVLine(fields)); err != nil {
+			return err
+		}
+	}
+	logger.Noticef("Expanded %d rows with %d failures to %s", rows, failed, output)
+	return nil
+}
+
+// expandRow expands the row of batch file into the fields of expandColumns.
+func expandRow(mp *MacroParser, entry TransactionParams) []string {
+	value := strconv.FormatInt(entry.Value, 10)
+	if entry.ValueExpr != "" {
+		value = entry.ValueExpr
+	}
+	fields := []string{strconv.Itoa(entry.Row), entry.From.Hex(), entry.To.Hex(), value, entry.Data}
+
+	expansion := &MacroExpansion{To: entry.To, Value: big.NewInt(entry.Value), Data: strings.TrimPrefix(entry.Data, "0x")}
+	var err error
+	if entry.ValueExpr != "" {
+		expansion.Value, err = mp.EvaluateValue(entry.ValueExpr, entry.From.Hex(), entry.To.Hex(), entry.Row)
+	}
+	if err == nil && mp.isMacroDefinition(entry.Data) {
+		if mp.isValueMacro(entry.Data) && (entry.Value != 0 || entry.ValueExpr != "") {
+			err = errConflictValue
+		} else {
+			var expanded *MacroExpansion
+			if expanded, err = mp.Expand(entry.Data, entry.From.Hex(), entry.To.Hex(), entry.Row); err == nil {
+				if expanded.Data != "" {
+					expanded.Value = expansion.Value
+				}
+				expansion = expanded
+			}
+		}
+	} else if err == nil {
+		expansion.decode(mp.candidates(""))
+	}
+	if err != nil {
+		return append(fields, "", "", "", "", "", err.Error())
+	}
+	var method, data string
+	if expansion.Function != nil {
+		method = expansion.Function.Sig()
+	}
+	if expansion.Data != "" {
+		data = "0x" + expansion.Data
+	}
+	return append(fields, expansion.To.Hex(), expansion.value(), data, method, strings.Join(expansion.Args, " "), "")
+}
@@ -0,0 +1,137 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestExpandMacro(t *testing.T) {
+	var (
+		sender   = "0xadd0354d4f5c101685509001053730417321db49"
+		receiver = "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+		rdn      = common.HexToAddress("0x255aa6df07540cb5d3d297f0d0d4d84cb52bc8e6")
+	)
+	parser := NewMacroParser(nil, newTokenRegistry(0, []Token{
+		{Address: rdn.Hex(), Symbol: "RDN", Decimal: 18},
+	}))
+	var tests = []struct {
+		macro  string
+		to     common.Address
+		method string
+		args   []string
+		value  string
+		err    error
+	}{
+		{"#TRANSFER RDN 1.5", rdn, "transfer(address,uint256)", []string{common.HexToAddress(receiver).Hex(), "1500000000000000000"}, "", nil},
+		{"#APPROVE RDN 2", rdn, "approve(address,uint256)", []string{common.HexToAddress(receiver).Hex(), "2000000000000000000"}, "", nil},
+		{"#BALANCEOF RDN " + receiver, rdn, "balanceOf(address)", []string{common.HexToAddress(receiver).Hex()}, "", nil},
+		{`#CALL RDN "setName(string,uint8[])" "hi there" [1,2]`, rdn, "setName(string,uint8[])", []string{`"hi there"`, "[1,2]"}, "", nil},
+		{"#SEND ETH 1.5", common.HexToAddress(receiver), "", nil, "1500000000000000000", nil},
+		{"#SEND ETH ALL", common.HexToAddress(receiver), "", nil, "ALL (balance minus the transaction fee)", nil},
+		{"#SEND ETH 50%", common.Address{}, "", nil, "", errNoClient},
+		{"#TRANSFER RDN 10%", common.Address{}, "", nil, "", errNoClient},
+		{"#TRANSFER EOS 1", common.Address{}, "", nil, "", errUnrecognizableTokenSymbol},
+	}
+	for _, test := range tests {
+		expansion, err := parser.Expand(test.macro, sender, receiver, 1)
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.macro, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if expansion.To != test.to {
+			t.Errorf("%s: address mismatch, want %s, got %s", test.macro, test.to.Hex(), expansion.To.Hex())
+		}
+		if test.method == "" {
+			if expansion.Data != "" || expansion.value() != test.value {
+				t.Errorf("%s: value mismatch, want %s, got %s with data %s", test.macro, test.value, expansion.value(), expansion.Data)
+			}
+			continue
+		}
+		if expansion.Function == nil || expansion.Function.Sig() != test.method {
+			t.Errorf("%s: method mismatch, want %s, got %v", test.macro, test.method, expansion.Function)
+			continue
+		}
+		if strings.Join(expansion.Args, " ") != strings.Join(test.args, " ") {
+			t.Errorf("%s: arguments mismatch, want %q, got %q", test.macro, test.args, expansion.Args)
+		}
+	}
+}
+
+func TestExpandRow(t *testing.T) {
+	var (
+		sender   = common.HexToAddress("0xadd0354d4f5c101685509001053730417321db49")
+		receiver = common.HexToAddress("0x8f0909ccb296ebd319834edb0d5785794b781d7f")
+		rdn      = common.HexToAddress("0x255aa6df07540cb5d3d297f0d0d4d84cb52bc8e6")
+	)
+	parser := NewMacroParser(nil, newTokenRegistry(0, []Token{
+		{Address: rdn.Hex(), Symbol: "RDN", Decimal: 18},
+	}))
+	var tests = []struct {
+		entry  TransactionParams
+		expect []string // Expanded fields following the original ones
+	}{
+		{TransactionParams{From: sender, To: receiver, Data: "#TRANSFER RDN $ROW", Row: 2},
+			[]string{rdn.Hex(), "0", "0xa9059cbb" + abiTestWord(strings.ToLower(receiver.Hex()[2:])) + abiTestWord("1bc16d674ec80000"), "transfer(address,uint256)", receiver.Hex() + " 2000000000000000000", ""}},
+		{TransactionParams{From: sender, To: receiver, Data: "#SEND ETH 1", Row: 3},
+			[]string{receiver.Hex(), "1000000000000000000", "", "", "", ""}},
+		{TransactionParams{From: sender, To: receiver, ValueExpr: "$ROW * 10", Row: 4},
+			[]string{receiver.Hex(), "40", "", "", "", ""}},
+		{TransactionParams{From: sender, To: rdn, Data: "0x095ea7b3" + abiTestWord(strings.ToLower(receiver.Hex()[2:])) + abiTestWord("5"), Row: 5},
+			[]string{rdn.Hex(), "0", "0x095ea7b3" + abiTestWord(strings.ToLower(receiver.Hex()[2:])) + abiTestWord("5"), "approve(address,uint256)", receiver.Hex() + " 5", ""}},
+		{TransactionParams{From: sender, To: receiver, Value: 1, Data: "#SEND ETH 1", Row: 6},
+			[]string{"", "", "", "", "", errConflictValue.Error()}},
+		{TransactionParams{From: sender, To: receiver, Data: "#TRANSFER EOS 1", Row: 7},
+			[]string{"", "", "", "", "", errUnrecognizableTokenSymbol.Error()}},
+	}
+	for _, test := range tests {
+		fields := expandRow(parser, test.entry)
+		if len(fields) != len(expandColumns) {
+			t.Fatalf("row %d: field count mismatch, want %d, got %d", test.entry.Row, len(expandColumns), len(fields))
+		}
+		if got := strings.Join(fields[5:], "|"); !strings.HasPrefix(got, strings.Join(test.expect, "|")) {
+			t.Errorf("row %d: expanded fields mismatch, want %q, got %q", test.entry.Row, test.expect, fields[5:])
+		}
+	}
+}
+
+func TestPrintExpansion(t *testing.T) {
+	parser := NewMacroParser(nil, newTokenRegistry(0, []Token{
+		{Address: "0x255aa6df07540cb5d3d297f0d0d4d84cb52bc8e6", Symbol: "RDN", Decimal: 18},
+	}))
+	expansion, err := parser.Expand("#BALANCEOF RDN 0x8f0909ccb296ebd319834edb0d5785794b781d7f", "", "", 0)
+	if err != nil {
+		t.Fatalf("failed to expand macro: %v", err)
+	}
+	var out bytes.Buffer
+	if err := printExpansion(&out, expansion); err != nil {
+		t.Fatalf("failed to print expansion: %v", err)
+	}
+	expect := "Contract:    0x255Aa6DF07540Cb5d3d297f0D0D4D84cb52bc8e6\n" +
+		"Method:      balanceOf(address)\n" +
+		"Argument 0:  address  0x8f0909CCB296EBd319834EDB0d5785794B781d7f\n" +
+		"Execution:   call\n" +
+		"Data:        0x70a082310000000000000000000000008f0909ccb296ebd319834edb0d5785794b781d7f\n"
+	if out.String() != expect {
+		t.Errorf("output mismatch, want:\n%s\ngot:\n%s", expect, out.String())
+	}
+}
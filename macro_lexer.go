@@ -0,0 +1,319 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// The macro grammar:
+//
+//    macro     = keyword { argument } [ comment ]
+//    keyword   = "#" name
+//    argument  = string | operand { operator operand }
+//    operand   = run of adjacent tokens, the brackets and parentheses in it are
+//                balanced and may contain whitespace, e.g. $BALANCE(EOS, $SENDER)
+//    string    = double quoted string with Go escapes, e.g. "a \"quoted\" word"
+//    comment   = "//" to the end of line
+//
+// Arguments are separated by whitespace. The standalone operators join the
+// operands around them, so "$BALANCE(EOS) - 10" is one argument. The leading
+// "-" is ambiguous with negative number, it starts a new argument unless it's
+// followed by whitespace. The arguments are kept as they are in the input and
+// interpreted by the macro later.
+
+// macroTokenKind is the kind of macro token.
+type macroTokenKind int
+
+const (
+	macroEOF macroTokenKind = iota
+	macroKeyword
+	macroWord
+	macroString
+	macroOperator
+	macroOpen
+	macroClose
+	macroComma
+)
+
+// macroToken is the lexical token of macro.
+type macroToken struct {
+	kind  macroTokenKind
+	text  string // Raw text in the input, quotes are included for string
+	pos   int    // Byte offset in the input
+	space bool   // Whether the token is preceded by whitespace
+}
+
+// end returns the byte offset right after the token.
+func (tok macroToken) end() int {
+	return tok.pos + len(tok.text)
+}
+
+// macroArgument is the argument of macro with its position.
+type macroArgument struct {
+	text   string
+	column int
+	quoted bool
+}
+
+// macroSyntax is the parsed macro.
+type macroSyntax struct {
+	keyword string
+	args    []macroArgument
+	end     int // Column right after the last argument
+}
+
+// MacroSyntaxError is the macro error with the column where it occurs, the
+// column is counted in characters from 1.
+type MacroSyntaxError struct {
+	Kind   error // errInvalidMacroDefinition or errInvalidMacroArgument
+	Column int
+	Msg    string
+}
+
+func (err *MacroSyntaxError) Error() string {
+	return fmt.Sprintf("%v at column %d: %s", err.Kind, err.Column, err.Msg)
+}
+
+// column converts the byte offset to the character column.
+func column(input string, pos int) int {
+	if pos > len(input) {
+		pos = len(input)
+	}
+	return utf8.RuneCountInString(input[:pos]) + 1
+}
+
+// describeToken returns the token description used in error messages.
+func describeToken(tok macroToken) string {
+	if tok.kind == macroEOF {
+		return "end of macro"
+	}
+	return strconv.Quote(tok.text)
+}
+
+// isMacroSpace reports whether the character separates the tokens.
+func isMacroSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// isMacroWordChar reports whether the character belongs to a word, which is
+// anything except whitespace and punctuations of grammar.
+func isMacroWordChar(c byte) bool {
+	switch c {
+	case '"', '(', ')', '[', ']', ',', '+', '-', '*', '/', '#':
+		return false
+	}
+	return !isMacroSpace(c)
+}
+
+// lexMacro splits the input into tokens, the comment is dropped.
+func lexMacro(input string) ([]macroToken, error) {
+	var (
+		tokens []macroToken
+		pos    int
+	)
+	errorf := func(pos int, format string, args ...interface{}) error {
+		return &MacroSyntaxError{Kind: errInvalidMacroDefinition, Column: column(input, pos), Msg: fmt.Sprintf(format, args...)}
+	}
+	for {
+		space := false
+		for pos < len(input) && isMacroSpace(input[pos]) {
+			pos, space = pos+1, true
+		}
+		if pos >= len(input) || (input[pos] == '/' && pos+1 < len(input) && input[pos+1] == '/') {
+			return append(tokens, macroToken{kind: macroEOF, pos: len(input), space: space}), nil
+		}
+		start, c := pos, input[pos]
+		var kind macroTokenKind
+		switch {
+		case c == '#':
+			if len(tokens) != 0 {
+				return nil, errorf(pos, "unexpected \"#\", macro name is only allowed at the beginning")
+			}
+			pos += 1
+			for pos < len(input) && isMacroWordChar(input[pos]) {
+				pos += 1
+			}
+			if pos == start+1 {
+				return nil, errorf(pos, "expected macro name after \"#\"")
+			}
+			kind = macroKeyword
+		case c == '"':
+			pos += 1
+			for pos < len(input) && input[pos] != '"' {
+				if input[pos] == '\\' {
+					pos += 1
+				}
+				pos += 1
+			}
+			if pos >= len(input) {
+				return nil, errorf(start, "unterminated string, expected closing \"\\\"\"")
+			}
+			pos += 1
+			if _, err := strconv.Unquote(input[start:pos]); err != nil {
+				return nil, errorf(start, "invalid escape in string %s", input[start:pos])
+			}
+			kind = macroString
+		case c == '(' || c == '[':
+			pos, kind = pos+1, macroOpen
+		case c == ')' || c == ']':
+			pos, kind = pos+1, macroClose
+		case c == ',':
+			pos, kind = pos+1, macroComma
+		case c == '+' || c == '-' || c == '*' || c == '/':
+			pos, kind = pos+1, macroOperator
+		default:
+			for pos < len(input) && isMacroWordChar(input[pos]) {
+				pos += 1
+			}
+			kind = macroWord
+		}
+		tokens = append(tokens, macroToken{kind: kind, text: input[start:pos], pos: start, space: space})
+	}
+}
+
+// macroParser parses the tokens into macro arguments.
+type macroParser struct {
+	input  string
+	tokens []macroToken
+	pos    int
+}
+
+func (p *macroParser) peek() macroToken {
+	return p.tokens[p.pos]
+}
+
+func (p *macroParser) errorf(tok macroToken, format string, args ...interface{}) error {
+	return &MacroSyntaxError{Kind: errInvalidMacroDefinition, Column: column(p.input, tok.pos), Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseMacro parses the macro into the keyword and arguments.
+func parseMacro(input string) (*macroSyntax, error) {
+	tokens, err := lexMacro(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &macroParser{input: input, tokens: tokens}
+	if tok := p.peek(); tok.kind != macroKeyword {
+		return nil, p.errorf(tok, "expected macro name like #TRANSFER, got %s", describeToken(tok))
+	}
+	syntax := &macroSyntax{keyword: p.peek().text}
+	p.pos += 1
+	for p.peek().kind != macroEOF {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		syntax.args = append(syntax.args, arg)
+	}
+	syntax.end = column(input, p.tokens[p.pos-1].end())
+	return syntax, nil
+}
+
+// parseArgument parses a single argument.
+func (p *macroParser) parseArgument() (macroArgument, error) {
+	first := p.peek()
+	if first.kind == macroString {
+		p.pos += 1
+		if next := p.peek(); next.kind != macroEOF && !next.space {
+			return macroArgument{}, p.errorf(next, "expected whitespace after string, got %s", describeToken(next))
+		}
+		return macroArgument{text: first.text, column: column(p.input, first.pos), quoted: true}, nil
+	}
+	if err := p.parseOperand(); err != nil {
+		return macroArgument{}, err
+	}
+	// The standalone operator is left to the macro, e.g. "-" after string
+	if first.kind == macroOperator && p.tokens[p.pos-1] == first {
+		return p.argument(first), nil
+	}
+	for {
+		last, next := p.tokens[p.pos-1], p.peek()
+		switch {
+		case last.kind == macroOperator:
+			// The trailing operator is joined with the next operand, e.g. "a- b"
+		case next.kind == macroOperator && next.text == "-" && !p.tokens[p.pos+1].space && p.tokens[p.pos+1].kind != macroEOF:
+			// The leading "-" starts a new argument, e.g. "5 -1"
+			return p.argument(first), nil
+		case next.kind == macroOperator:
+			p.pos += 1
+		default:
+			return p.argument(first), nil
+		}
+		if tok := p.peek(); tok.kind == macroEOF || tok.kind == macroString {
+			return macroArgument{}, p.errorf(tok, "expected operand after %q, got %s", p.tokens[p.pos-1].text, describeToken(tok))
+		}
+		if err := p.parseOperand(); err != nil {
+			return macroArgument{}, err
+		}
+	}
+}
+
+// argument returns the argument which spans from the first token to the last
+// parsed one.
+func (p *macroParser) argument(first macroToken) macroArgument {
+	return macroArgument{text: p.input[first.pos:p.tokens[p.pos-1].end()], column: column(p.input, first.pos)}
+}
+
+// parseOperand parses the run of adjacent tokens, the whitespace is allowed in
+// brackets and parentheses.
+func (p *macroParser) parseOperand() error {
+	var stack []macroToken
+	for start := true; ; start = false {
+		tok := p.peek()
+		if tok.kind == macroEOF {
+			if len(stack) > 0 {
+				open := stack[len(stack)-1]
+				return p.errorf(tok, "expected %q to close %q at column %d", closer(open.text), open.text, column(p.input, open.pos))
+			}
+			return nil
+		}
+		if !start && tok.space && len(stack) == 0 {
+			return nil
+		}
+		switch tok.kind {
+		case macroOpen:
+			stack = append(stack, tok)
+		case macroClose:
+			if len(stack) == 0 {
+				return p.errorf(tok, "unexpected %q without opening bracket", tok.text)
+			}
+			open := stack[len(stack)-1]
+			if want := closer(open.text); tok.text != want {
+				return p.errorf(tok, "expected %q to close %q at column %d, got %q", want, open.text, column(p.input, open.pos), tok.text)
+			}
+			stack = stack[:len(stack)-1]
+		case macroComma:
+			if len(stack) == 0 {
+				return p.errorf(tok, "unexpected \",\" outside brackets")
+			}
+		case macroString:
+			if len(stack) == 0 {
+				return p.errorf(tok, "expected whitespace before string, got %s", describeToken(tok))
+			}
+		}
+		p.pos += 1
+	}
+}
+
+// closer returns the closing bracket of given opening one.
+func closer(open string) string {
+	if open == "(" {
+		return ")"
+	}
+	return "]"
+}
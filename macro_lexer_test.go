@@ -0,0 +1,134 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var macroSyntaxTests = []struct {
+	input  string
+	fields []string
+	column int    // Column of error, 0 means no error
+	msg    string // Expected part of error message
+}{
+	{"#TRANSFER EOS 20%", []string{"#TRANSFER", "EOS", "20%"}, 0, ""},
+	{"  #TRANSFER\tEOS   20%  ", []string{"#TRANSFER", "EOS", "20%"}, 0, ""},
+	{"#TRANSFER EOS 20% // airdrop to early users", []string{"#TRANSFER", "EOS", "20%"}, 0, ""},
+	{"#TRANSFER EOS $BALANCE(EOS) - 10", []string{"#TRANSFER", "EOS", "$BALANCE(EOS) - 10"}, 0, ""},
+	{"#TRANSFER EOS min($BALANCE(EOS, $SENDER), (1 + 2) * 3)/2", []string{"#TRANSFER", "EOS", "min($BALANCE(EOS, $SENDER), (1 + 2) * 3)/2"}, 0, ""},
+	{"#TRANSFER EOS 10- 5", []string{"#TRANSFER", "EOS", "10- 5"}, 0, ""},
+	{`#CALL RDN "f(int256,int256)" 5 -1`, []string{"#CALL", "RDN", `"f(int256,int256)"`, "5", "-1"}, 0, ""},
+	{`#CALL RDN setName "say \"hi\"\tthere"`, []string{"#CALL", "RDN", "setName", `"say \"hi\"\tthere"`}, 0, ""},
+	{`#CALL RDN setName "// not a comment"`, []string{"#CALL", "RDN", "setName", `"// not a comment"`}, 0, ""},
+	{`#CALL RDN f [1, 2, 3] (0x1234, "a b")`, []string{"#CALL", "RDN", "f", "[1, 2, 3]", `(0x1234, "a b")`}, 0, ""},
+	{"#BALANCEOF EOS", []string{"#BALANCEOF", "EOS"}, 0, ""},
+
+	{"TRANSFER EOS 1", nil, 1, `expected macro name like #TRANSFER, got "TRANSFER"`},
+	{"", nil, 1, "expected macro name like #TRANSFER, got end of macro"},
+	{"# EOS 1", nil, 2, `expected macro name after "#"`},
+	{"#TRANSFER EOS #1", nil, 15, `unexpected "#"`},
+	{`#CALL RDN setName "hello`, nil, 19, "unterminated string"},
+	{`#CALL RDN setName "bad \q"`, nil, 19, "invalid escape"},
+	{`#CALL RDN setName "a"b`, nil, 22, "expected whitespace after string"},
+	{"#TRANSFER EOS min(1, 2", nil, 23, `expected ")" to close "(" at column 18`},
+	{"#TRANSFER EOS min(1, 2]", nil, 23, `expected ")" to close "(" at column 18, got "]"`},
+	{"#TRANSFER EOS 1)", nil, 16, `unexpected ")"`},
+	{"#TRANSFER EOS 1, 2", nil, 16, `unexpected ","`},
+	{"#TRANSFER EOS 10 +", nil, 19, `expected operand after "+", got end of macro`},
+	{"#TRANSFER ÉOS 1)", nil, 16, `unexpected ")"`},
+}
+
+func TestParseMacroSyntax(t *testing.T) {
+	for _, test := range macroSyntaxTests {
+		fields, err := splitMacroFields(test.input)
+		if test.column == 0 {
+			if err != nil {
+				t.Errorf("%q: failed to parse: %v", test.input, err)
+			} else if strings.Join(fields, "|") != strings.Join(test.fields, "|") {
+				t.Errorf("%q: fields mismatch, want %q, got %q", test.input, test.fields, fields)
+			}
+			continue
+		}
+		syntaxErr, ok := err.(*MacroSyntaxError)
+		if !ok {
+			t.Errorf("%q: error mismatch, want syntax error, got %v", test.input, err)
+			continue
+		}
+		if syntaxErr.Column != test.column || !strings.Contains(syntaxErr.Msg, test.msg) {
+			t.Errorf("%q: error mismatch, want %q at column %d, got %v", test.input, test.msg, test.column, err)
+		}
+	}
+}
+
+func TestMacroArgumentErrors(t *testing.T) {
+	parser := NewMacroParser(nil, newTokenRegistry(0, nil))
+	var tests = []struct {
+		input string
+		err   string
+	}{
+		{"#TRANSFER EOS", "invalid macro argument at column 14: expected <token number>|<token percentage>, usage: #TRANSFER <token symbol> <token number>|<token percentage>"},
+		{"#ALLOWANCE", "invalid macro argument at column 11: expected <token symbol>"},
+		{"#BALANCEOF EOS 0x1234  extra", `invalid macro argument at column 24: unexpected argument "extra"`},
+	}
+	for _, test := range tests {
+		_, _, _, err := parser.Parse(test.input, "", "", 0)
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("%q: error mismatch, want %s, got %v", test.input, test.err, err)
+		}
+	}
+}
+
+// FuzzParseMacro checks the macro parser never panics, the error column is in
+// the input and the parsed arguments are stable when they're parsed again.
+func FuzzParseMacro(f *testing.F) {
+	for _, test := range macroSyntaxTests {
+		f.Add(test.input)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		if !utf8.ValidString(input) {
+			return
+		}
+		syntax, err := parseMacro(input)
+		if err != nil {
+			syntaxErr, ok := err.(*MacroSyntaxError)
+			if !ok {
+				t.Fatalf("%q: unexpected error type %T", input, err)
+			}
+			if syntaxErr.Column < 1 || syntaxErr.Column > utf8.RuneCountInString(input)+1 {
+				t.Fatalf("%q: error column %d out of range", input, syntaxErr.Column)
+			}
+			return
+		}
+		fields := []string{syntax.keyword}
+		for _, arg := range syntax.args {
+			if arg.text == "" {
+				t.Fatalf("%q: empty argument", input)
+			}
+			fields = append(fields, arg.text)
+		}
+		// The arguments are separated by whitespace, so joining them must
+		// result in the same ones.
+		again, err := splitMacroFields(strings.Join(fields, " "))
+		if err != nil {
+			t.Fatalf("%q: failed to parse the joined fields %q: %v", input, fields, err)
+		}
+		if strings.Join(again, "\x00") != strings.Join(fields, "\x00") {
+			t.Fatalf("%q: fields changed, want %q, got %q", input, fields, again)
+		}
+	})
+}
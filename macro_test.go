@@ -15,7 +15,9 @@
 package main
 
 import (
-	"os"
+	"encoding/json"
+	"fmt"
+	"math/big"
 	"path"
 	"strings"
 	"testing"
@@ -33,14 +35,10 @@ func TestReadTokenList(t *testing.T) {
 		t.Error("emty token list read from file")
 	}
 
-	tokens, err = ReadTokenList("")
-	if err != nil {
-		t.Error(err)
+	// The token list is never downloaded implicitly
+	if _, err = ReadTokenList(""); err == nil {
+		t.Error("expect error for missing token list")
 	}
-	if len(tokens) == 0 {
-		t.Error("emty token list fetch from github")
-	}
-	os.Remove(ethTokenFile)
 }
 
 func TestParse(t *testing.T) {
@@ -53,11 +51,12 @@ func TestParse(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	parser, err := NewMacroParser(cli, path.Join("test", "rinkebyEthToken.json"))
+	registry, err := OpenTokenRegistry("", 4, path.Join("test", "rinkebyEthToken.json"))
 	if err != nil {
 		t.Error(err)
 	}
-	addr, payload, decimal, err := parser.Parse(cmdBalanceOf, "", "")
+	parser := NewMacroParser(cli, registry)
+	addr, payload, decimal, err := parser.Parse(cmdBalanceOf, "", "", 0)
 	if err != nil {
 		t.Error(err)
 	}
@@ -66,7 +65,7 @@ func TestParse(t *testing.T) {
 		t.Error("invalid parse result")
 	}
 
-	addr, payload, decimal, err = parser.Parse(cmdTransfer, "0xadd0354d4f5c101685509001053730417321db49", "0x8f0909ccb296ebd319834edb0d5785794b781d7f")
+	addr, payload, decimal, err = parser.Parse(cmdTransfer, "0xadd0354d4f5c101685509001053730417321db49", "0x8f0909ccb296ebd319834edb0d5785794b781d7f", 0)
 	if err != nil {
 		t.Error(err)
 	}
@@ -76,7 +75,7 @@ func TestParse(t *testing.T) {
 		decimal, 0) {
 		t.Error("invalid parse result")
 	}
-	addr, payload, decimal, err = parser.Parse(cmdPercentageTransfer, "0xadd0354d4f5c101685509001053730417321db49", "0x8f0909ccb296ebd319834edb0d5785794b781d7f")
+	addr, payload, decimal, err = parser.Parse(cmdPercentageTransfer, "0xadd0354d4f5c101685509001053730417321db49", "0x8f0909ccb296ebd319834edb0d5785794b781d7f", 0)
 	if err != nil {
 		t.Error(err)
 	}
@@ -87,6 +86,226 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseERC20Macros(t *testing.T) {
+	var (
+		sender   = "0xadd0354d4f5c101685509001053730417321db49"
+		receiver = "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+		owner    = "0x7236bc5a9ff647d48b1eceaa07aa6438dcca615e"
+		contract = common.HexToAddress("0xe10f51424adbead82eb4b9ae72c29828dc24188f")
+		word     = func(hex string) string { return strings.Repeat("0", 64-len(hex)) + hex }
+	)
+	parser := NewMacroParser(nil, newTokenRegistry(0, []Token{
+		{Address: contract.Hex(), Symbol: "RDN", Decimal: 18},
+	}))
+	var tests = []struct {
+		macro   string
+		payload string
+		decimal int
+		err     error
+	}{
+		{"#APPROVE RDN MAX", "095ea7b3" + word(receiver[2:]) + strings.Repeat("f", 64), 0, nil},
+		{"#APPROVE rdn 100", "095ea7b3" + word(receiver[2:]) + word("56bc75e2d63100000"), 0, nil},
+		{"#ALLOWANCE RDN " + owner + " " + receiver, "dd62ed3e" + word(owner[2:]) + word(receiver[2:]), 18, nil},
+		{"#TRANSFERFROM RDN " + owner + " 100", "23b872dd" + word(owner[2:]) + word(receiver[2:]) + word("56bc75e2d63100000"), 0, nil},
+		{"#APPROVE RDN 50%", "", 0, errInvalidMacroArgument},
+		{"#ALLOWANCE RDN " + owner, "", 0, errInvalidMacroArgument},
+		{"#TRANSFERFROM RDN 0x1234 100", "", 0, errInvalidMacroArgument},
+		{"#APPROVE EOS MAX", "", 0, errUnrecognizableTokenSymbol},
+		{"#TRANSFER RDN 1.5", "a9059cbb" + word(receiver[2:]) + word("14d1120d7b160000"), 0, nil},
+		{"#APPROVE RDN 0.000000000000000001", "095ea7b3" + word(receiver[2:]) + word("1"), 0, nil},
+		{"#APPROVE RDN 0.0000000000000000001", "", 0, errTooPreciseAmount},
+		{"#TRANSFER RDN 1e3", "", 0, errInvalidMacroArgument},
+		{"#TRANSFER RDN -1", "", 0, errInvalidMacroArgument},
+		{"#TRANSFER RDN 100.5%", "", 0, errInvalidMacroArgument},
+	}
+	for _, test := range tests {
+		addr, payload, decimal, err := parser.Parse(test.macro, sender, receiver, 0)
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.macro, test.err, err)
+			continue
+		}
+		if err == nil && !checkEqual(addr, contract, payload, test.payload, decimal, test.decimal) {
+			t.Errorf("%s: invalid parse result, got %s %s %d", test.macro, addr.Hex(), payload, decimal)
+		}
+	}
+}
+
+func TestParseTokenAmount(t *testing.T) {
+	var tests = []struct {
+		amount   string
+		decimals int
+		expect   string
+		err      error
+	}{
+		{"123456789.123456789123456789", 18, "123456789123456789123456789", nil},
+		{"1.10", 1, "11", nil},
+		{".5", 2, "50", nil},
+		{"7.", 0, "7", nil},
+		{"1.01", 1, "", errTooPreciseAmount},
+		{"0.5", 0, "", errTooPreciseAmount},
+		{".", 18, "", errInvalidMacroArgument},
+		{"1/3", 18, "", errInvalidMacroArgument},
+		{"1.2.3", 18, "", errInvalidMacroArgument},
+	}
+	for _, test := range tests {
+		amount, err := parseTokenAmount(test.amount, test.decimals)
+		if err != test.err {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.amount, test.err, err)
+			continue
+		}
+		if err == nil && amount.String() != test.expect {
+			t.Errorf("%s: amount mismatch, want %s, got %s", test.amount, test.expect, amount)
+		}
+	}
+}
+
+func TestParseCallMacro(t *testing.T) {
+	var (
+		sender   = "0xadd0354d4f5c101685509001053730417321db49"
+		receiver = "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+		contract = common.HexToAddress("0xe10f51424adbead82eb4b9ae72c29828dc24188f")
+		word     = func(hex string) string { return strings.Repeat("0", 64-len(hex)) + hex }
+	)
+	transfer, _ := parseABISignature("transfer(address,uint256)")
+	setName, _ := parseABISignature("setName(string)")
+	parser := NewMacroParser(nil, newTokenRegistry(0, []Token{
+		{Address: contract.Hex(), Symbol: "RDN", Decimal: 18},
+	}))
+	parser.functions = []*abiFunction{transfer, setName}
+	var tests = []struct {
+		macro   string
+		payload string
+		err     error
+	}{
+		{`#CALL RDN "transfer(address,uint256)" ` + receiver + " 1ether", "a9059cbb" + word(receiver[2:]) + word("de0b6b3a7640000"), nil},
+		{`#CALL ` + contract.Hex() + ` "transfer(address to, uint256 value)" ` + receiver + " 1ether", "a9059cbb" + word(receiver[2:]) + word("de0b6b3a7640000"), nil},
+		{"#CALL RDN transfer " + receiver + " 0x10", "a9059cbb" + word(receiver[2:]) + word("10"), nil},
+		{`#CALL RDN setName "hello world"`, "c47f0027" + word("20") + word("b") + "68656c6c6f20776f726c64" + strings.Repeat("0", 42), nil},
+		{"#CALL RDN approve " + receiver + " 1", "", errUnknownABIMethod},
+		{`#CALL RDN "transfer(address,uint256)" 0x1234 1`, "", errInvalidMacroArgument},
+		{`#CALL RDN "transfer(address,uint256`, "", errInvalidMacroDefinition},
+		{`#CALL EOS "transfer(address,uint256)" ` + receiver + " 1", "", errUnrecognizableTokenSymbol},
+		{"#CALL RDN", "", errInvalidMacroArgument},
+		{`#CALL RDN "transfer(address,uint256)" $RECEIVER $ROW*2`, "a9059cbb" + word(receiver[2:]) + word("e"), nil},
+	}
+	for _, test := range tests {
+		addr, payload, decimal, err := parser.Parse(test.macro, sender, receiver, 7)
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.macro, test.err, err)
+			continue
+		}
+		if err == nil && !checkEqual(addr, contract, payload, test.payload, decimal, 0) {
+			t.Errorf("%s: invalid parse result, got %s %s %d", test.macro, addr.Hex(), payload, decimal)
+		}
+	}
+}
+
+func TestParseSendMacro(t *testing.T) {
+	var (
+		sender   = "0xadd0354d4f5c101685509001053730417321db49"
+		receiver = "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+	)
+	server, cli := newTestRPCServer(t, func(method string, params []json.RawMessage) (interface{}, error) {
+		if method != "eth_getBalance" {
+			return nil, fmt.Errorf("unsupported method %s", method)
+		}
+		return "0x3e8", nil // 1000 wei
+	})
+	defer server.Close()
+
+	parser := NewMacroParser(cli, newTokenRegistry(0, nil))
+	var tests = []struct {
+		macro string
+		value *big.Int
+		sweep bool
+		err   error
+	}{
+		{"#SEND ETH 1.5", big.NewInt(1500000000000000000), false, nil},
+		{"#send eth 0.000000000000000001", big.NewInt(1), false, nil},
+		{"#SEND ETH 50%", big.NewInt(500), false, nil},
+		{"#SEND ETH 33.33%", big.NewInt(333), false, nil},
+		{"#SEND ETH ALL", nil, true, nil},
+		{"#SEND ETH $ROW / 1000", big.NewInt(7000000000000000), false, nil},
+		{"#SEND ETH 0.0000000000000000001", nil, false, errTooPreciseAmount},
+		{"#SEND ETH 101%", nil, false, errInvalidMacroArgument},
+		{"#SEND RDN 1", nil, false, errInvalidMacroArgument},
+		{"#SEND ETH", nil, false, errInvalidMacroArgument},
+		{"#TRANSFER ETH 1", nil, false, errInvalidMacroDefinition},
+	}
+	for _, test := range tests {
+		if !parser.isValueMacro(test.macro) && test.err != errInvalidMacroDefinition {
+			t.Errorf("%s: not recognized as value macro", test.macro)
+		}
+		value, sweep, err := parser.ParseValue(test.macro, sender, receiver, 7)
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.macro, test.err, err)
+			continue
+		}
+		if err == nil && (sweep != test.sweep || (test.value == nil) != (value == nil) || (value != nil && value.Cmp(test.value) != 0)) {
+			t.Errorf("%s: invalid parse result, want %v %v, got %v %v", test.macro, test.value, test.sweep, value, sweep)
+		}
+	}
+	// The send macro can't be used as invocation data
+	if _, _, _, err := parser.Parse("#SEND ETH 1", sender, receiver, 7); err != errValueMacro {
+		t.Errorf("error mismatch, want %v, got %v", errValueMacro, err)
+	}
+}
+
+func TestQueryReadMacros(t *testing.T) {
+	var (
+		sender     = "0xadd0354d4f5c101685509001053730417321db49"
+		receiver   = "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+		token      = common.HexToAddress("0xe10f51424adbead82eb4b9ae72c29828dc24188f")
+		collection = common.HexToAddress("0x06012c8cf97bead5deae237070f9587f8e7a266d")
+		word       = func(hex string) string { return strings.Repeat("0", 64-len(hex)) + hex }
+	)
+	server, cli := newTestRPCServer(t, testCallHandler(map[common.Address]map[string]string{
+		token: {
+			"70a08231" + word(receiver[2:]):                    word("1158e460913d00000"), // 20 * 10^18
+			"dd62ed3e" + word(sender[2:]) + word(receiver[2:]): word("6f05b59d3b20000"),   // 0.5 * 10^18
+		},
+		collection: {
+			"6352211e" + word("1"):                      word(receiver[2:]),
+			"00fdd58e" + word(receiver[2:]) + word("7"): word("3"),
+		},
+	}))
+	defer server.Close()
+
+	parser := NewMacroParser(cli, newTokenRegistry(0, []Token{
+		{Address: token.Hex(), Symbol: "RDN", Decimal: 18},
+		{Address: collection.Hex(), Symbol: "CK", Decimal: 0},
+	}))
+	var tests = []struct {
+		macro  string
+		result string
+		err    bool
+	}{
+		{"#BALANCEOF RDN $RECEIVER", "20", false},
+		{"#ALLOWANCE RDN " + sender + " " + receiver, "0.5", false},
+		{"#NFTOWNER CK 1", common.HexToAddress(receiver).Hex(), false},
+		{"#1155BALANCE CK 7 " + receiver, "3", false},
+		{"#BALANCEOF RDN " + sender, "", true},
+	}
+	for _, test := range tests {
+		if !parser.isReadMacro(test.macro) {
+			t.Errorf("%s: not recognized as read-only macro", test.macro)
+		}
+		result, err := parser.Query(test.macro, sender, receiver, 1)
+		if (err != nil) != test.err {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.macro, test.err, err)
+			continue
+		}
+		if result != test.result {
+			t.Errorf("%s: result mismatch, want %s, got %s", test.macro, test.result, result)
+		}
+	}
+	for _, macro := range []string{"#TRANSFER RDN 1", "#NFTTRANSFER CK 1", "#SEND ETH 1", "0x1234"} {
+		if parser.isReadMacro(macro) {
+			t.Errorf("%s: recognized as read-only macro", macro)
+		}
+	}
+}
+
 func checkEqual(addr, addrExpect common.Address, payload, payloadExpect string, decimal, decimalExpect int) bool {
 	if strings.ToLower(addr.Hex()) != strings.ToLower(addrExpect.Hex()) {
 		return false
@@ -0,0 +1,206 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// User macros are declared in a json file, which is an array of definitions:
+//
+//    [
+//      {
+//        "name": "stake",
+//        "description": "stake RDN tokens",
+//        "args": ["amount"],
+//        "contract": "RDN",
+//        "signature": "stake(address,uint256)",
+//        "inputs": ["$SENDER", "{amount} ether"]
+//      }
+//    ]
+//
+// The contract is an address or a token symbol, and can be an argument
+// placeholder as well. The inputs are the argument templates of function, the
+// placeholders {arg} are replaced by the macro arguments. Then the integer
+// inputs are evaluated as expressions and the other inputs are evaluated if
+// they contain variables, see expr.go.
+
+var (
+	errInvalidMacroFile = errors.New("invalid macro file")
+
+	macroNameRegexp        = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	macroPlaceholderRegexp = regexp.MustCompile(`\{([^{}]*)\}`)
+)
+
+// MacroDefinition is the user macro definition in macro file.
+type MacroDefinition struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Args        []string `json:"args"`
+	Contract    string   `json:"contract"`
+	Signature   string   `json:"signature"`
+	Inputs      []string `json:"inputs"`
+
+	fn *abiFunction
+}
+
+// loadMacroFile loads and validates the user macro definitions. The errors
+// point to the definition by its position and name in file.
+func loadMacroFile(path string, registry *TokenRegistry) ([]*MacroDefinition, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var definitions []*MacroDefinition
+	if err := json.Unmarshal(content, &definitions); err != nil {
+		var offset int64 = -1
+		switch e := err.(type) {
+		case *json.SyntaxError:
+			offset = e.Offset
+		case *json.UnmarshalTypeError:
+			offset = e.Offset
+		}
+		if offset >= 0 {
+			return nil, fmt.Errorf("%s:%d: %v, %v", path, bytes.Count(content[:offset], []byte("\n"))+1, errInvalidMacroFile, err)
+		}
+		return nil, fmt.Errorf("%s: %v, %v", path, errInvalidMacroFile, err)
+	}
+	names := make(map[string]bool)
+	for idx, def := range definitions {
+		if err := def.validate(registry); err != nil {
+			return nil, fmt.Errorf("%s: %v, macro %q (definition %d): %v", path, errInvalidMacroFile, def.Name, idx+1, err)
+		}
+		name := strings.ToLower(def.Name)
+		if _, builtin := macroSet[name]; builtin {
+			return nil, fmt.Errorf("%s: %v, macro %q (definition %d): conflicts with built-in macro", path, errInvalidMacroFile, def.Name, idx+1)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("%s: %v, macro %q (definition %d): duplicated definition", path, errInvalidMacroFile, def.Name, idx+1)
+		}
+		names[name] = true
+	}
+	return definitions, nil
+}
+
+// validate checks the definition and parses the function signature.
+func (def *MacroDefinition) validate(registry *TokenRegistry) error {
+	if !macroNameRegexp.MatchString(def.Name) {
+		return fmt.Errorf("invalid name %q", def.Name)
+	}
+	args := make(map[string]bool)
+	for _, arg := range def.Args {
+		if !macroNameRegexp.MatchString(arg) {
+			return fmt.Errorf("invalid argument name %q", arg)
+		}
+		if args[arg] {
+			return fmt.Errorf("duplicated argument %q", arg)
+		}
+		args[arg] = true
+	}
+	// checkPlaceholders ensures all placeholders refer to the declared arguments
+	checkPlaceholders := func(field, template string) error {
+		for _, match := range macroPlaceholderRegexp.FindAllStringSubmatch(template, -1) {
+			if !args[match[1]] {
+				return fmt.Errorf("%s %q refers to undeclared argument {%s}", field, template, match[1])
+			}
+		}
+		return nil
+	}
+	switch {
+	case def.Contract == "":
+		return errors.New("missing contract")
+	case macroPlaceholderRegexp.MatchString(def.Contract):
+		if err := checkPlaceholders("contract", def.Contract); err != nil {
+			return err
+		}
+	case !common.IsHexAddress(def.Contract):
+		if _, err := registry.Lookup(def.Contract); err != nil {
+			return fmt.Errorf("contract %q is neither an address nor a known token symbol: %v", def.Contract, err)
+		}
+	}
+	fn, err := parseABISignature(def.Signature)
+	if err != nil {
+		return err
+	}
+	if len(def.Inputs) != len(fn.Inputs) {
+		return fmt.Errorf("%d inputs are given while signature %s requires %d", len(def.Inputs), fn.Sig(), len(fn.Inputs))
+	}
+	for idx, input := range def.Inputs {
+		if err := checkPlaceholders(fmt.Sprintf("input %d", idx+1), input); err != nil {
+			return err
+		}
+	}
+	def.fn = fn
+	return nil
+}
+
+// expand fills the templates with given arguments, returns the contract and the
+// function inputs.
+func (def *MacroDefinition) expand(args []string) (string, []string) {
+	values := make(map[string]string)
+	for idx, name := range def.Args {
+		values[name] = args[idx]
+	}
+	fill := func(template string) string {
+		return macroPlaceholderRegexp.ReplaceAllStringFunc(template, func(match string) string {
+			return values[match[1:len(match)-1]]
+		})
+	}
+	inputs := make([]string, len(def.Inputs))
+	for idx, input := range def.Inputs {
+		inputs[idx] = fill(input)
+	}
+	return fill(def.Contract), inputs
+}
+
+// parseUserMacro parses the user macro with given arguments.
+// Return value:
+// contract address, invocation data and error
+func (mp *MacroParser) parseUserMacro(def *MacroDefinition, lines []string, env *macroEnv) (common.Address, string, error) {
+	if len(lines) != len(def.Args) {
+		return common.Address{}, "", fmt.Errorf("%v, #%s requires %d arguments", errInvalidMacroArgument, strings.ToUpper(def.Name), len(def.Args))
+	}
+	contract, inputs := def.expand(lines)
+	for idx, input := range inputs {
+		typ := def.fn.Inputs[idx]
+		numeric := typ.kind == abiUint || typ.kind == abiInt
+		if strings.HasPrefix(input, "\"") || (!numeric && !isMacroExpression(input)) {
+			continue
+		}
+		value, err := evalExpression(input, env)
+		if err != nil {
+			return common.Address{}, "", err
+		}
+		if numeric && (value.num == nil || !value.num.IsInt()) {
+			return common.Address{}, "", fmt.Errorf("%v, input %d of #%s is not an integer: %s", errInvalidMacroArgument, idx+1, strings.ToUpper(def.Name), input)
+		}
+		if numeric {
+			inputs[idx] = new(big.Int).Set(value.num.Num()).String()
+		} else {
+			inputs[idx] = formatExprValue(value)
+		}
+	}
+	signature := def.fn.Sig()
+	return mp.parseCall(append([]string{contract, signature}, inputs...))
+}
@@ -0,0 +1,214 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestUserMacros(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-macro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		sender   = "0xadd0354d4f5c101685509001053730417321db49"
+		receiver = "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+		contract = common.HexToAddress("0xe10f51424adbead82eb4b9ae72c29828dc24188f")
+		pool     = "0x7236bc5a9ff647d48b1eceaa07aa6438dcca615e"
+		word     = func(hex string) string { return strings.Repeat("0", 64-len(hex)) + hex }
+	)
+	macroFile := path.Join(dir, "macros.json")
+	ioutil.WriteFile(macroFile, []byte(`[
+		{
+			"name": "stake",
+			"description": "stake RDN tokens for the receiver",
+			"args": ["amount"],
+			"contract": "RDN",
+			"signature": "stake(address,uint256)",
+			"inputs": ["$RECEIVER", "{amount} ether"]
+		},
+		{
+			"name": "vote",
+			"args": ["pool", "proposal", "support"],
+			"contract": "{pool}",
+			"signature": "vote(uint256,bool)",
+			"inputs": ["{proposal}", "{support}"]
+		}
+	]`), 0644)
+
+	parser := NewMacroParser(nil, newTokenRegistry(0, []Token{
+		{Address: contract.Hex(), Symbol: "RDN", Decimal: 18},
+	}))
+	if err := parser.LoadMacros(macroFile); err != nil {
+		t.Fatalf("failed to load macros: %v", err)
+	}
+	if err := parser.LoadMacros(macroFile); err == nil {
+		t.Fatalf("expect error for redefined macros")
+	}
+	stake, _ := parseABISignature("stake(address,uint256)")
+	vote, _ := parseABISignature("vote(uint256,bool)")
+	var tests = []struct {
+		macro    string
+		contract common.Address
+		payload  string
+		err      bool
+	}{
+		{"#STAKE 1.5", contract, common.Bytes2Hex(stake.Selector()) + word(receiver[2:]) + word("14d1120d7b160000"), false},
+		{"#stake $ROW", contract, common.Bytes2Hex(stake.Selector()) + word(receiver[2:]) + word("3782dace9d900000"), false},
+		{"#VOTE " + pool + " 12 true", common.HexToAddress(pool), common.Bytes2Hex(vote.Selector()) + word("c") + word("1"), false},
+		{"#STAKE 0.0000000000000000001", contract, "", true},
+		{"#STAKE", contract, "", true},
+		{"#VOTE " + pool + " 12", contract, "", true},
+	}
+	for _, test := range tests {
+		addr, payload, _, err := parser.Parse(test.macro, sender, receiver, 4)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expect error", test.macro)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.macro, err)
+			continue
+		}
+		if addr != test.contract || payload != test.payload {
+			t.Errorf("%s: invalid parse result, got %s %s", test.macro, addr.Hex(), payload)
+		}
+	}
+}
+
+func TestReadOnlyUserMacros(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-macro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		sender   = "0xadd0354d4f5c101685509001053730417321db49"
+		receiver = "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+		contract = common.HexToAddress("0xe10f51424adbead82eb4b9ae72c29828dc24188f")
+		word     = func(hex string) string { return strings.Repeat("0", 64-len(hex)) + hex }
+	)
+	macroFile := path.Join(dir, "macros.json")
+	ioutil.WriteFile(macroFile, []byte(`[
+		{
+			"name": "staked",
+			"args": ["holder"],
+			"contract": "RDN",
+			"signature": "function stakedOf(address holder) view returns (uint256 amount, bool locked)",
+			"inputs": ["{holder}"]
+		},
+		{
+			"name": "rate",
+			"contract": "RDN",
+			"signature": "rate() pure",
+			"inputs": []
+		},
+		{
+			"name": "stake",
+			"args": ["amount"],
+			"contract": "RDN",
+			"signature": "stake(uint256)",
+			"inputs": ["{amount} ether"]
+		}
+	]`), 0644)
+
+	staked, _ := parseABISignature("stakedOf(address)")
+	rate, _ := parseABISignature("rate()")
+	server, cli := newTestRPCServer(t, testCallHandler(map[common.Address]map[string]string{
+		contract: {
+			common.Bytes2Hex(staked.Selector()) + word(receiver[2:]): word("5") + word("1"),
+			common.Bytes2Hex(rate.Selector()):                        word("2a"),
+		},
+	}))
+	defer server.Close()
+
+	parser := NewMacroParser(cli, newTokenRegistry(0, []Token{
+		{Address: contract.Hex(), Symbol: "RDN", Decimal: 18},
+	}))
+	if err := parser.LoadMacros(macroFile); err != nil {
+		t.Fatalf("failed to load macros: %v", err)
+	}
+	var tests = []struct {
+		macro    string
+		readOnly bool
+		result   string
+	}{
+		{"#STAKED $RECEIVER // comment", true, "5 true"},
+		{"#rate", true, "0x" + word("2a")},
+		{"#STAKE 1", false, ""},
+		{"#UNKNOWN 1", false, ""},
+	}
+	for _, test := range tests {
+		if parser.isReadMacro(test.macro) != test.readOnly {
+			t.Errorf("%s: read-only mismatch, want %v", test.macro, test.readOnly)
+			continue
+		}
+		if !test.readOnly {
+			continue
+		}
+		result, err := parser.Query(test.macro, sender, receiver, 1)
+		if err != nil {
+			t.Errorf("%s: failed to query: %v", test.macro, err)
+			continue
+		}
+		if result != test.result {
+			t.Errorf("%s: result mismatch, want %s, got %s", test.macro, test.result, result)
+		}
+	}
+}
+
+func TestInvalidMacroFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-macro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	registry := newTokenRegistry(0, []Token{{Address: "0xe10f51424adbead82eb4b9ae72c29828dc24188f", Symbol: "RDN"}})
+	var tests = []struct {
+		content string
+		expect  string
+	}{
+		{"[\n{\"name\": \"stake\",\n\"args\": 1}]", "macros.json:3:"},
+		{`[{"name": "stake", "contract": "RDN", "signature": "stake(uint256)", "inputs": ["1"]}, {"name": "claim", "contract": "EOS", "signature": "claim()"}]`, `macro "claim" (definition 2): contract "EOS"`},
+		{`[{"name": "claim", "contract": "RDN", "signature": "claim(uint257)"}]`, `macro "claim" (definition 1): invalid abi type`},
+		{`[{"name": "stake", "contract": "RDN", "signature": "stake(uint256)", "inputs": []}]`, "0 inputs are given while signature stake(uint256) requires 1"},
+		{`[{"name": "stake", "args": ["amount"], "contract": "RDN", "signature": "stake(uint256)", "inputs": ["{amout}"]}]`, "undeclared argument {amout}"},
+		{`[{"name": "stake", "args": ["a", "a"], "contract": "RDN", "signature": "stake()"}]`, `duplicated argument "a"`},
+		{`[{"name": "Transfer", "contract": "RDN", "signature": "transfer()"}]`, "conflicts with built-in macro"},
+		{`[{"name": "claim", "contract": "RDN", "signature": "claim()"}, {"name": "CLAIM", "contract": "RDN", "signature": "claim()"}]`, `macro "CLAIM" (definition 2): duplicated definition`},
+		{`[{"name": "bad name", "contract": "RDN", "signature": "claim()"}]`, `invalid name "bad name"`},
+	}
+	for _, test := range tests {
+		macroFile := path.Join(dir, "macros.json")
+		ioutil.WriteFile(macroFile, []byte(test.content), 0644)
+		_, err := loadMacroFile(macroFile, registry)
+		if err == nil || !strings.Contains(err.Error(), test.expect) {
+			t.Errorf("error mismatch, want %q, got %v", test.expect, err)
+		}
+	}
+}
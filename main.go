@@ -35,6 +35,12 @@ func init() {
 		commandSend,
 		commandSendBatch,
 		commandCall,
+		commandEncode,
+		commandDecode,
+		commandSigDB,
+		commandExport,
+		commandTokens,
+		commandMacro,
 	}
 }
 
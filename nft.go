@@ -0,0 +1,165 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rjl493456442/ethclient/resource"
+)
+
+// Non-fungible token macros operate on ERC721 and ERC1155 collections. The
+// collection is given by contract address or by the alias in token registry,
+// which is the symbol of collection entry, e.g.
+//
+//    #NFTTRANSFER  <collection> <token id>
+//    #NFTOWNER     <collection> <token id>
+//    #1155TRANSFER <collection> <token id> <amount>
+//    #1155BALANCE  <collection> <token id> <holder address>
+//
+// The token ids and amounts are integers, hex is accepted as well.
+
+// lookupCollection returns the contract address of collection. Unlike tokens,
+// the unknown address is used as it is since collections have no decimals.
+func (mp *MacroParser) lookupCollection(ref string) (common.Address, error) {
+	if common.IsHexAddress(ref) {
+		return common.HexToAddress(ref), nil
+	}
+	token, err := mp.registry.Lookup(ref)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.HexToAddress(token.Address), nil
+}
+
+// parseTokenId parses the token id or amount, which must fit in uint256.
+func parseTokenId(arg string) (*big.Int, error) {
+	id, err := parseABIInteger(arg)
+	if err != nil {
+		return nil, fmt.Errorf("%v, %v", errInvalidMacroArgument, err)
+	}
+	if id.Sign() < 0 || id.Cmp(maxAllowance) > 0 {
+		return nil, fmt.Errorf("%v, %s is out of uint256 range", errInvalidMacroArgument, arg)
+	}
+	return id, nil
+}
+
+// parseNFTTransfer parses ERC721 transfer macro, the token is transferred from
+// sender to receiver by safeTransferFrom.
+// NFT transfer macro syntax:
+// #NFTTRANSFER <Collection> <Token id>
+// Return value:
+// contract address, invocation data and error
+func (mp *MacroParser) parseNFTTransfer(lines []string, sender, receiver string) (common.Address, string, error) {
+	if len(lines) != macroSet[MacroNFTTransfer].ArgNumber {
+		return common.Address{}, "", errInvalidMacroArgument
+	}
+	contract, err := mp.lookupCollection(lines[0])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	id, err := parseTokenId(lines[1])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	return packContract(resource.ERC721InterfaceABI, contract, "safeTransferFrom", common.HexToAddress(sender), common.HexToAddress(receiver), id)
+}
+
+// parseNFTOwner parses ERC721 owner query macro.
+// NFT owner macro syntax:
+// #NFTOWNER <Collection> <Token id>
+// Return value:
+// contract address, invocation data and error
+func (mp *MacroParser) parseNFTOwner(lines []string) (common.Address, string, error) {
+	if len(lines) != macroSet[MacroNFTOwner].ArgNumber {
+		return common.Address{}, "", errInvalidMacroArgument
+	}
+	contract, err := mp.lookupCollection(lines[0])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	id, err := parseTokenId(lines[1])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	return packContract(resource.ERC721InterfaceABI, contract, "ownerOf", id)
+}
+
+// parse1155Transfer parses ERC1155 transfer macro, the amount of token is
+// transferred from sender to receiver by safeTransferFrom with empty data.
+// ERC1155 transfer macro syntax:
+// #1155TRANSFER <Collection> <Token id> <Amount>
+// Return value:
+// contract address, invocation data and error
+func (mp *MacroParser) parse1155Transfer(lines []string, sender, receiver string) (common.Address, string, error) {
+	if len(lines) != macroSet[Macro1155Transfer].ArgNumber {
+		return common.Address{}, "", errInvalidMacroArgument
+	}
+	contract, err := mp.lookupCollection(lines[0])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	id, err := parseTokenId(lines[1])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	amount, err := parseTokenId(lines[2])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	return packContract(resource.ERC1155InterfaceABI, contract, "safeTransferFrom", common.HexToAddress(sender), common.HexToAddress(receiver), id, amount, []byte{})
+}
+
+// parse1155Balance parses ERC1155 balance query macro.
+// ERC1155 balance macro syntax:
+// #1155BALANCE <Collection> <Token id> <holder address>
+// Return value:
+// contract address, invocation data and error
+func (mp *MacroParser) parse1155Balance(lines []string) (common.Address, string, error) {
+	if len(lines) != macroSet[Macro1155Balance].ArgNumber {
+		return common.Address{}, "", errInvalidMacroArgument
+	}
+	if !common.IsHexAddress(lines[2]) {
+		return common.Address{}, "", errInvalidMacroArgument
+	}
+	contract, err := mp.lookupCollection(lines[0])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	id, err := parseTokenId(lines[1])
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	return packContract(resource.ERC1155InterfaceABI, contract, "balanceOf", common.HexToAddress(lines[2]), id)
+}
+
+// packContract assembles the invocation data of method in given contract abi.
+// Return value:
+// contract address, invocation data and error
+func packContract(definition string, contract common.Address, method string, args ...interface{}) (common.Address, string, error) {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	input, err := parsed.Pack(method, args...)
+	if err != nil {
+		return common.Address{}, "", err
+	}
+	return contract, common.Bytes2Hex(input), nil
+}
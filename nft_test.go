@@ -0,0 +1,61 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseNFTMacros(t *testing.T) {
+	var (
+		sender     = "0xadd0354d4f5c101685509001053730417321db49"
+		receiver   = "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+		collection = common.HexToAddress("0x06012c8cf97bead5deae237070f9587f8e7a266d")
+		word       = func(hex string) string { return strings.Repeat("0", 64-len(hex)) + hex }
+	)
+	parser := NewMacroParser(nil, newTokenRegistry(0, []Token{
+		{Address: collection.Hex(), Symbol: "CK", Decimal: 0},
+	}))
+	var tests = []struct {
+		macro   string
+		payload string
+		err     error
+	}{
+		{"#NFTTRANSFER CK 1024", "42842e0e" + word(sender[2:]) + word(receiver[2:]) + word("400"), nil},
+		{"#nfttransfer " + collection.Hex() + " 0x400", "42842e0e" + word(sender[2:]) + word(receiver[2:]) + word("400"), nil},
+		{"#NFTOWNER CK 1", "6352211e" + word("1"), nil},
+		{"#1155TRANSFER CK 7 $ROW", "f242432a" + word(sender[2:]) + word(receiver[2:]) + word("7") + word("3") + word("a0") + word("0"), nil},
+		{"#1155BALANCE CK 7 " + receiver, "00fdd58e" + word(receiver[2:]) + word("7"), nil},
+		{"#NFTTRANSFER CK", "", errInvalidMacroArgument},
+		{"#NFTTRANSFER CK 1.5", "", errInvalidMacroArgument},
+		{"#NFTOWNER CK -1", "", errInvalidMacroArgument},
+		{"#1155TRANSFER CK 7 0x" + strings.Repeat("f", 65), "", errInvalidMacroArgument},
+		{"#1155BALANCE CK 7 0x1234", "", errInvalidMacroArgument},
+		{"#NFTOWNER PUNK 1", "", errUnrecognizableTokenSymbol},
+	}
+	for _, test := range tests {
+		addr, payload, decimal, err := parser.Parse(test.macro, sender, receiver, 3)
+		if err != test.err && (err == nil || test.err == nil || !strings.HasPrefix(err.Error(), test.err.Error())) {
+			t.Errorf("%s: error mismatch, want %v, got %v", test.macro, test.err, err)
+			continue
+		}
+		if err == nil && !checkEqual(addr, collection, payload, test.payload, decimal, 0) {
+			t.Errorf("%s: invalid parse result, got %s %s %d", test.macro, addr.Hex(), payload, decimal)
+		}
+	}
+}
@@ -0,0 +1,722 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// odsContent is the name of the package entry which contains all sheets.
+	odsContent = "content.xml"
+
+	// odsMimetype is the media type of OpenDocument spreadsheet.
+	odsMimetype = "application/vnd.oasis.opendocument.spreadsheet"
+)
+
+// odsSheetReader streams the rows of a table in OpenDocument spreadsheet
+// without loading the whole document into memory.
+//
+// Note, consecutive identical rows and cells are compressed by the repeated
+// attributes in OpenDocument, they are expanded by the reader.
+type odsSheetReader struct {
+	zr      *zip.ReadCloser
+	content io.ReadCloser
+	decoder *xml.Decoder
+	depth   int // nested table depth, 1 is the selected table
+	number  int // number of the last visited row
+
+	pending []string // the repeated row not returned yet
+	repeat  int      // remaining repeat times of the pending row
+}
+
+func openODSSheet(filename string, sheet string) (*odsSheetReader, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	var file *zip.File
+	for _, f := range zr.File {
+		if f.Name == odsContent {
+			file = f
+		}
+	}
+	if file == nil {
+		zr.Close()
+		return nil, errInvalidContent
+	}
+	content, err := file.Open()
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	reader := &odsSheetReader{
+		zr:      zr,
+		content: content,
+		decoder: xml.NewDecoder(content),
+	}
+	// Move to the beginning of the selected table
+	for reader.depth == 0 {
+		token, err := reader.decoder.Token()
+		if err != nil {
+			reader.close()
+			if err == io.EOF {
+				return nil, fmt.Errorf("sheet %s not found", sheet)
+			}
+			return nil, err
+		}
+		if t, ok := token.(xml.StartElement); ok && t.Name.Local == "table" && odsAttr(t, "name") == sheet {
+			reader.depth = 1
+		}
+	}
+	return reader, nil
+}
+
+// odsAttr returns the value of attribute with given local name.
+func odsAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// odsRepeat returns the value of repeated attribute, 1 if not specified.
+func odsRepeat(element xml.StartElement, name string) int {
+	n, err := strconv.Atoi(odsAttr(element, name))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+// next returns the next non-empty row in the table with its row number(1 based).
+func (reader *odsSheetReader) next() (int, []string, error) {
+	if reader.repeat > 0 {
+		reader.repeat -= 1
+		reader.number += 1
+		return reader.number, append([]string{}, reader.pending...), nil
+	}
+	var (
+		row        []string
+		col        int
+		repeat     int // repeat times of current row
+		cell       xml.StartElement
+		cellRepeat int
+		text       []byte
+		paragraphs int
+		inText     int
+		inRow      bool
+		inCell     bool
+		annotation int
+	)
+	for {
+		token, err := reader.decoder.Token()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "table" {
+				reader.depth += 1
+			}
+			if reader.depth > 1 {
+				continue
+			}
+			switch t.Name.Local {
+			case "table-row":
+				inRow, row, col, repeat = true, nil, 0, odsRepeat(t, "number-rows-repeated")
+			case "table-cell", "covered-table-cell":
+				if inRow {
+					inCell, cell, text, paragraphs, inText = true, t, text[:0], 0, 0
+					cellRepeat = odsRepeat(t, "number-columns-repeated")
+				}
+			case "annotation":
+				annotation += 1
+			case "p", "h":
+				if inCell && annotation == 0 {
+					if paragraphs > 0 {
+						text = append(text, '\n')
+					}
+					paragraphs, inText = paragraphs+1, inText+1
+				}
+			case "s":
+				if inCell && annotation == 0 {
+					text = append(text, strings.Repeat(" ", odsRepeat(t, "c"))...)
+				}
+			case "tab":
+				if inCell && annotation == 0 {
+					text = append(text, '\t')
+				}
+			case "line-break":
+				if inCell && annotation == 0 {
+					text = append(text, '\n')
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "table" {
+				if reader.depth -= 1; reader.depth == 0 {
+					return 0, nil, io.EOF
+				}
+				continue
+			}
+			if reader.depth > 1 {
+				continue
+			}
+			switch t.Name.Local {
+			case "table-row":
+				if !inRow {
+					continue
+				}
+				inRow = false
+				if len(row) == 0 {
+					reader.number += repeat
+					continue
+				}
+				reader.number += 1
+				if repeat > 1 {
+					reader.pending, reader.repeat = row, repeat-1
+				}
+				return reader.number, append([]string{}, row...), nil
+			case "table-cell", "covered-table-cell":
+				if !inCell {
+					continue
+				}
+				inCell = false
+				if value := odsCellValue(cell, string(text)); value != "" {
+					for len(row) < col+cellRepeat {
+						row = append(row, "")
+					}
+					for i := col; i < col+cellRepeat; i++ {
+						row[i] = value
+					}
+				}
+				col += cellRepeat
+			case "annotation":
+				annotation -= 1
+			case "p", "h":
+				if inCell && annotation == 0 {
+					inText -= 1
+				}
+			}
+		case xml.CharData:
+			if inCell && inText > 0 && annotation == 0 && reader.depth == 1 {
+				text = append(text, t...)
+			}
+		}
+	}
+}
+
+// odsCellValue resolves the value of cell. The raw value is preferred for the
+// typed cells, so that the numbers are not affected by the display format.
+func odsCellValue(cell xml.StartElement, text string) string {
+	switch odsAttr(cell, "value-type") {
+	case "float", "percentage", "currency":
+		return odsAttr(cell, "value")
+	case "date":
+		return odsAttr(cell, "date-value")
+	case "time":
+		return odsAttr(cell, "time-value")
+	case "boolean":
+		return odsAttr(cell, "boolean-value")
+	}
+	return text
+}
+
+func (reader *odsSheetReader) close() error {
+	reader.content.Close()
+	return reader.zr.Close()
+}
+
+// rewriteODS sets the cells of given column in the selected table to the
+// target values, which are keyed by the row number(1 based). The updated rows
+// are deleted from the targets.
+//
+// Only the modified rows are re-encoded, the rest of document is kept as it is.
+// Note, the content of document is loaded into memory.
+func rewriteODS(filename string, sheet string, col int, targets map[int]string) error {
+	return updateODS(filename, func(content []byte) ([]byte, error) {
+		return setODSCells(content, sheet, col, targets)
+	})
+}
+
+// appendODS appends the rows to the end of selected table, the header row is
+// added if the table is empty. The document and the table are created if they
+// don't exist.
+func appendODS(filename string, sheet string, header []string, rows [][]string) error {
+	update := func(content []byte) ([]byte, error) {
+		return appendODSRows(content, sheet, header, rows)
+	}
+	if _, err := os.Stat(filename); err != nil {
+		return createODS(filename, sheet, update)
+	}
+	return updateODS(filename, update)
+}
+
+// updateODS replaces the content document of the package with the updated one.
+func updateODS(filename string, update func(content []byte) ([]byte, error)) error {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	var content []byte
+	for _, file := range zr.File {
+		if file.Name != odsContent {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		content, err = ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if content == nil {
+		return errInvalidContent
+	}
+	if content, err = update(content); err != nil {
+		return err
+	}
+	return rewriteFile(filename, func(w *bufio.Writer) error {
+		zw := zip.NewWriter(w)
+		for _, file := range zr.File {
+			// The entries are kept in the original order, since the mimetype
+			// entry must be the first one.
+			if file.Name != odsContent {
+				if err := zw.Copy(file); err != nil {
+					return err
+				}
+				continue
+			}
+			fw, err := zw.CreateHeader(&zip.FileHeader{
+				Name:     file.Name,
+				Method:   zip.Deflate,
+				Modified: file.Modified,
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := fw.Write(content); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	})
+}
+
+// createODS creates the minimal document with a single empty table, the content
+// document is updated before writing.
+func createODS(filename string, sheet string, update func(content []byte) ([]byte, error)) error {
+	var name bytes.Buffer
+	xml.EscapeText(&name, []byte(sheet))
+	content, err := update([]byte(xml.Header + `<office:document-content` +
+		` xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"` +
+		` xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"` +
+		` xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" office:version="1.2">` +
+		`<office:body><office:spreadsheet><table:table table:name="` + name.String() + `"></table:table>` +
+		`</office:spreadsheet></office:body></office:document-content>`))
+	if err != nil {
+		return err
+	}
+	manifest := xml.Header + `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">` +
+		`<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="` + odsMimetype + `"/>` +
+		`<manifest:file-entry manifest:full-path="` + odsContent + `" manifest:media-type="text/xml"/>` +
+		`</manifest:manifest>`
+
+	return rewriteFile(filename, func(w *bufio.Writer) error {
+		zw := zip.NewWriter(w)
+		// The mimetype entry must be the first one and uncompressed.
+		for _, entry := range []struct {
+			name    string
+			method  uint16
+			content []byte
+		}{
+			{"mimetype", zip.Store, []byte(odsMimetype)},
+			{"META-INF/manifest.xml", zip.Deflate, []byte(manifest)},
+			{odsContent, zip.Deflate, content},
+		} {
+			fw, err := zw.CreateHeader(&zip.FileHeader{Name: entry.name, Method: entry.method})
+			if err != nil {
+				return err
+			}
+			if _, err := fw.Write(entry.content); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	})
+}
+
+// appendODSRows inserts the rows right after the last non-empty row of selected
+// table, so that the trailing empty rows(e.g. the formatted but unused rows) are
+// kept behind. The table is added to the end of spreadsheet if not found.
+func appendODSRows(content []byte, sheet string, header []string, rows [][]string) ([]byte, error) {
+	var (
+		decoder  = xml.NewDecoder(bytes.NewReader(content))
+		depth    int
+		found    bool
+		prefix         = "table:"
+		body     int64 = -1 // offset right after the start tag of table
+		firstRow int64 = -1 // offset of the first row in table
+		end      int64 = -1 // offset of the end tag of table or spreadsheet
+	)
+	for end < 0 {
+		start := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errInvalidContent
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "table" && depth > 0:
+				depth += 1
+			case t.Name.Local == "table" && odsAttr(t, "name") == sheet:
+				depth, found = 1, true
+				body, prefix = decoder.InputOffset(), odsPrefix(content[start:decoder.InputOffset()])
+			case t.Name.Local == "table-row" && depth == 1 && firstRow < 0:
+				firstRow = start
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Local == "table" && depth > 0:
+				if depth -= 1; depth == 0 {
+					end = start
+				}
+			case t.Name.Local == "spreadsheet" && !found:
+				end = start
+			}
+		}
+	}
+	var out bytes.Buffer
+	if !found {
+		var name bytes.Buffer
+		xml.EscapeText(&name, []byte(sheet))
+		out.Write(content[:end])
+		out.WriteString("<" + prefix + "table " + prefix + "name=\"" + name.String() + "\">")
+		out.Write(encodeODSRows(prefix, append([][]string{header}, rows...)))
+		out.WriteString("</" + prefix + "table>")
+		out.Write(content[end:])
+		return out.Bytes(), nil
+	}
+	// Find the end of the last non-empty row, the selected table is already
+	// entered by the reader.
+	reader := &odsSheetReader{decoder: xml.NewDecoder(bytes.NewReader(content[body:end])), depth: 1}
+	last := int64(-1)
+	for {
+		if _, _, err := reader.next(); err != nil {
+			break
+		}
+		last = body + reader.decoder.InputOffset()
+	}
+	pos := last
+	if pos < 0 {
+		// The table is empty, the header is added before the rows
+		rows = append([][]string{header}, rows...)
+		if pos = end; firstRow >= 0 {
+			pos = firstRow
+		}
+	}
+	out.Write(content[:pos])
+	out.Write(encodeODSRows(prefix, rows))
+	out.Write(content[pos:])
+	return out.Bytes(), nil
+}
+
+// encodeODSRows encodes the rows as the table-row elements, the non-empty
+// values are set as string cells.
+func encodeODSRows(prefix string, rows [][]string) []byte {
+	var out bytes.Buffer
+	for _, row := range rows {
+		out.WriteString("<" + prefix + "table-row>")
+		for _, value := range row {
+			if value == "" {
+				out.WriteString("<" + prefix + "table-cell/>")
+				continue
+			}
+			out.WriteString(odsStringCell(prefix, value))
+		}
+		out.WriteString("</" + prefix + "table-row>")
+	}
+	return out.Bytes()
+}
+
+// odsStringCell returns the cell element with the string value.
+func odsStringCell(prefix string, value string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(value))
+	return "<" + prefix + "table-cell office:value-type=\"string\"><text:p>" + escaped.String() + "</text:p></" + prefix + "table-cell>"
+}
+
+// setODSCells sets the cells of given column in the content document.
+func setODSCells(content []byte, sheet string, col int, targets map[int]string) ([]byte, error) {
+	if len(targets) == 0 {
+		return content, nil
+	}
+	var (
+		out      bytes.Buffer
+		decoder  = xml.NewDecoder(bytes.NewReader(content))
+		copied   int64
+		depth    int
+		found    bool
+		number   int
+		inRow    bool
+		rowStart int64
+		repeat   int
+	)
+	for len(targets) > 0 {
+		start := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "table" && depth > 0:
+				depth += 1
+			case t.Name.Local == "table" && odsAttr(t, "name") == sheet:
+				depth, found = 1, true
+			case t.Name.Local == "table-row" && depth == 1 && !inRow:
+				inRow, rowStart, repeat = true, start, odsRepeat(t, "number-rows-repeated")
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Local == "table" && depth > 0:
+				depth -= 1
+			case t.Name.Local == "table-row" && depth == 1 && inRow:
+				inRow = false
+				first := number + 1
+				if number += repeat; !odsHasTarget(targets, first, number) {
+					continue
+				}
+				end := decoder.InputOffset()
+				row, err := setODSRowCells(content[rowStart:end], first, repeat, col, targets)
+				if err != nil {
+					return nil, err
+				}
+				out.Write(content[copied:rowStart])
+				out.Write(row)
+				copied = end
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("sheet %s not found", sheet)
+	}
+	out.Write(content[copied:])
+	return out.Bytes(), nil
+}
+
+// odsHasTarget reports whether any row in [first, last] is the target.
+func odsHasTarget(targets map[int]string, first, last int) bool {
+	if last-first < len(targets) {
+		for n := first; n <= last; n++ {
+			if _, exist := targets[n]; exist {
+				return true
+			}
+		}
+		return false
+	}
+	for n := range targets {
+		if n >= first && n <= last {
+			return true
+		}
+	}
+	return false
+}
+
+// setODSRowCells re-encodes the (repeated) row element. The target rows are
+// split out from the repeated ones and the cell of given column is set.
+func setODSRowCells(raw []byte, first int, repeat int, col int, targets map[int]string) ([]byte, error) {
+	tag, body, closing, err := splitODSElement(raw)
+	if err != nil {
+		return nil, err
+	}
+	prefix := odsPrefix(tag)
+	var (
+		out     bytes.Buffer
+		pending int
+	)
+	flush := func() {
+		if pending > 0 {
+			out.Write(setODSRepeat(tag, prefix, "number-rows-repeated", pending))
+			out.Write(body)
+			out.Write(closing)
+			pending = 0
+		}
+	}
+	for n := first; n < first+repeat; n++ {
+		value, exist := targets[n]
+		if !exist {
+			pending += 1
+			continue
+		}
+		flush()
+		cells, err := setODSCell(body, prefix, col, value)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(bytes.TrimSuffix(setODSRepeat(tag, prefix, "number-rows-repeated", 1), []byte("/>")))
+		if bytes.HasSuffix(tag, []byte("/>")) {
+			out.WriteString(">")
+		}
+		out.Write(cells)
+		out.WriteString("</" + prefix + "table-row>")
+		delete(targets, n)
+	}
+	flush()
+	return out.Bytes(), nil
+}
+
+// setODSCell sets the cell of given column in the cells of row.
+func setODSCell(body []byte, prefix string, col int, value string) ([]byte, error) {
+	var (
+		out     bytes.Buffer
+		decoder = xml.NewDecoder(bytes.NewReader(body))
+		depth   int
+		start   int64
+		cell    xml.StartElement
+		idx     int
+		done    bool
+	)
+	target := odsStringCell(prefix, value)
+
+	copied := int64(0)
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				start, cell = offset, t
+			}
+			depth += 1
+		case xml.EndElement:
+			if depth -= 1; depth != 0 || done {
+				continue
+			}
+			repeat := odsRepeat(cell, "number-columns-repeated")
+			if idx+repeat <= col {
+				idx += repeat
+				continue
+			}
+			// Split the repeated cell which covers the target column
+			end := decoder.InputOffset()
+			tag, content, closing, err := splitODSElement(body[start:end])
+			if err != nil {
+				return nil, err
+			}
+			cellPrefix := odsPrefix(tag)
+			out.Write(body[copied:start])
+			if before := col - idx; before > 0 {
+				out.Write(setODSRepeat(tag, cellPrefix, "number-columns-repeated", before))
+				out.Write(content)
+				out.Write(closing)
+			}
+			out.WriteString(target)
+			if after := idx + repeat - col - 1; after > 0 {
+				out.Write(setODSRepeat(tag, cellPrefix, "number-columns-repeated", after))
+				out.Write(content)
+				out.Write(closing)
+			}
+			copied, done = end, true
+		}
+	}
+	out.Write(body[copied:])
+	if !done {
+		if idx < col {
+			fmt.Fprintf(&out, "<%stable-cell %snumber-columns-repeated=\"%d\"/>", prefix, prefix, col-idx)
+		}
+		out.WriteString(target)
+	}
+	return out.Bytes(), nil
+}
+
+// splitODSElement splits the raw element into the start tag, the content and
+// the end tag. The content and the end tag are empty for self-closing element.
+func splitODSElement(raw []byte) ([]byte, []byte, []byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.RawToken(); err != nil {
+		return nil, nil, nil, err
+	}
+	tag := raw[:decoder.InputOffset()]
+	if bytes.HasSuffix(tag, []byte("/>")) {
+		return tag, nil, nil, nil
+	}
+	idx := bytes.LastIndex(raw, []byte("</"))
+	if idx < len(tag) {
+		return nil, nil, nil, errInvalidContent
+	}
+	return tag, raw[len(tag):idx], raw[idx:], nil
+}
+
+// odsPrefix returns the namespace prefix(with colon) of the start tag.
+func odsPrefix(tag []byte) string {
+	name := strings.TrimPrefix(strings.FieldsFunc(string(tag), func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\r' || r == '\n' || r == '>' || r == '/'
+	})[0], "<")
+	if idx := strings.Index(name, ":"); idx >= 0 {
+		return name[:idx+1]
+	}
+	return ""
+}
+
+var odsRepeatPatterns = map[string]*regexp.Regexp{
+	"number-rows-repeated":    regexp.MustCompile(`\s+([\w.-]+:)?number-rows-repeated\s*=\s*("[^"]*"|'[^']*')`),
+	"number-columns-repeated": regexp.MustCompile(`\s+([\w.-]+:)?number-columns-repeated\s*=\s*("[^"]*"|'[^']*')`),
+}
+
+// setODSRepeat sets the repeated attribute of start tag, the attribute is
+// removed if the repeat times is 1.
+func setODSRepeat(tag []byte, prefix string, attr string, n int) []byte {
+	tag = odsRepeatPatterns[attr].ReplaceAll(tag, nil)
+	if n <= 1 {
+		return tag
+	}
+	end := len(tag) - 1
+	if bytes.HasSuffix(tag, []byte("/>")) {
+		end = len(tag) - 2
+	}
+	return []byte(fmt.Sprintf("%s %s%s=\"%d\"%s", tag[:end], prefix, attr, n, tag[end:]))
+}
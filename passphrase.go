@@ -0,0 +1,241 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	errUnknownPassphraseLabel = errors.New("unknown passphrase label")
+	errInvalidPassphraseMap   = errors.New("invalid passphrase map file")
+)
+
+// Prefixes of passphrase references in batch file.
+const (
+	passphraseEnvPrefix   = "env:"
+	passphraseFilePrefix  = "file:"
+	passphraseLabelPrefix = "@"
+
+	// passphraseLiteralPrefix escapes the literal passphrase which starts with
+	// the reference prefixes, e.g. "literal:@home".
+	passphraseLiteralPrefix = "literal:"
+)
+
+// maxLiteralRows is the maximum number of rows listed in the literal passphrase warning.
+const maxLiteralRows = 20
+
+// isPassphraseReference reports whether the passphrase field of batch file is a
+// reference instead of the literal passphrase.
+func isPassphraseReference(field string) bool {
+	return strings.HasPrefix(field, passphraseEnvPrefix) ||
+		strings.HasPrefix(field, passphraseFilePrefix) ||
+		strings.HasPrefix(field, passphraseLabelPrefix)
+}
+
+// literalPassphrase returns the literal passphrase of the passphrase field, the
+// escape prefix is removed. False is returned if the field is empty or a reference.
+func literalPassphrase(field string) (string, bool) {
+	if strings.HasPrefix(field, passphraseLiteralPrefix) {
+		return strings.TrimPrefix(field, passphraseLiteralPrefix), true
+	}
+	if field == "" || isPassphraseReference(field) {
+		return "", false
+	}
+	return field, true
+}
+
+// loadPassphraseMap loads the passphrase map file. Each line of the file is a
+// label or an account address followed by whitespace and the passphrase, e.g.
+//
+//    0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e helloworld
+//    treasury   s3cret passphrase
+//
+// Blank lines and lines starting with '#' are ignored. The passphrase is the
+// rest of line without the line terminator, so it can contain spaces.
+func loadPassphraseMap(path string) (map[string]string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var (
+		labels  = make(map[string]string)
+		scanner = bufio.NewScanner(fd)
+		line    int
+	)
+	for scanner.Scan() {
+		line += 1
+		text := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		text = strings.TrimLeft(text, " \t")
+		idx := strings.IndexAny(text, " \t")
+		if idx < 0 {
+			return nil, fmt.Errorf("%s:%d: %v, missing passphrase", path, line, errInvalidPassphraseMap)
+		}
+		key, passphrase := passphraseMapKey(text[:idx]), strings.TrimLeft(text[idx:], " \t")
+		if _, exist := labels[key]; exist {
+			return nil, fmt.Errorf("%s:%d: %v, duplicated entry %s", path, line, errInvalidPassphraseMap, text[:idx])
+		}
+		labels[key] = passphrase
+	}
+	return labels, scanner.Err()
+}
+
+// passphraseMapKey normalizes the key of passphrase map, addresses are case
+// insensitive while labels are not.
+func passphraseMapKey(key string) string {
+	if common.IsHexAddress(key) {
+		return strings.ToLower(common.HexToAddress(key).Hex())
+	}
+	return key
+}
+
+// passphraseKey is the cache key of resolved passphrases.
+type passphraseKey struct {
+	sender common.Address
+	field  string
+}
+
+// passphraseResolver resolves the passphrase fields of batch file. A field can be:
+//
+//    env:VAR        read from the environment variable VAR
+//    file:/path     read from the file, the trailing line terminator is removed
+//    @label         looked up from the passphrase map file
+//    <empty>        looked up from the passphrase map file by sender address,
+//                   or fallback to the command line options and the prompt
+//    literal:text   the literal passphrase text, which may start with the prefixes above
+//    <others>       the literal passphrase, which is not recommended
+//
+// Every reference is resolved only once per sender.
+type passphraseResolver struct {
+	labels   map[string]string
+	fallback func(sender common.Address) string
+	cache    map[passphraseKey]string
+	literal  int // number of rows with literal passphrase
+}
+
+func newPassphraseResolver(labels map[string]string, fallback func(sender common.Address) string) *passphraseResolver {
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	return &passphraseResolver{
+		labels:   labels,
+		fallback: fallback,
+		cache:    make(map[passphraseKey]string),
+	}
+}
+
+// resolve returns the passphrase of sender with given passphrase field of row.
+func (resolver *passphraseResolver) resolve(sender common.Address, field string) (string, error) {
+	if passphrase, literal := literalPassphrase(field); literal {
+		return passphrase, nil
+	}
+	key := passphraseKey{sender: sender, field: field}
+	if passphrase, exist := resolver.cache[key]; exist {
+		return passphrase, nil
+	}
+	var (
+		passphrase string
+		err        error
+	)
+	switch {
+	case strings.HasPrefix(field, passphraseEnvPrefix):
+		name := strings.TrimPrefix(field, passphraseEnvPrefix)
+		value, exist := os.LookupEnv(name)
+		if !exist {
+			return "", fmt.Errorf("passphrase environment variable %s is not set", name)
+		}
+		passphrase = value
+	case strings.HasPrefix(field, passphraseFilePrefix):
+		var content []byte
+		if content, err = ioutil.ReadFile(strings.TrimPrefix(field, passphraseFilePrefix)); err != nil {
+			return "", err
+		}
+		passphrase = strings.TrimRight(string(content), "\r\n")
+	case strings.HasPrefix(field, passphraseLabelPrefix):
+		label := strings.TrimPrefix(field, passphraseLabelPrefix)
+		value, exist := resolver.labels[label]
+		if !exist {
+			return "", fmt.Errorf("%v %s", errUnknownPassphraseLabel, label)
+		}
+		passphrase = value
+	default:
+		value, exist := resolver.labels[strings.ToLower(sender.Hex())]
+		if !exist {
+			value = resolver.fallback(sender)
+		}
+		passphrase = value
+	}
+	resolver.cache[key] = passphrase
+	return passphrase, nil
+}
+
+// scan reads all rows of batch file and warns about the literal passphrases up
+// front, so that the rows never signed(e.g. skipped by --begin, read-only macros
+// or failed rows) are reported as well. Corrupted rows are ignored.
+func (resolver *passphraseResolver) scan(reader Reader) error {
+	var rows []string
+	resolver.literal = 0
+	for {
+		param, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*ErrCorrupted); ok {
+				continue
+			}
+			return err
+		}
+		if _, literal := literalPassphrase(param.Passphrase); !literal {
+			continue
+		}
+		if resolver.literal += 1; len(rows) < maxLiteralRows {
+			rows = append(rows, fmt.Sprintf("%d", param.Row))
+		}
+	}
+	if resolver.literal == 0 {
+		return nil
+	}
+	if resolver.literal > len(rows) {
+		rows = append(rows, "...")
+	}
+	logger.Warning("!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!")
+	logger.Warningf("Batch file contains literal passphrases at %d rows, which is insecure.", resolver.literal)
+	logger.Warningf("Rows: %s", strings.Join(rows, ", "))
+	logger.Warning("Use env:VAR, file:/path or @label reference instead.")
+	logger.Warning("!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!!")
+	return nil
+}
+
+// report warns about the literal passphrases again after the batch is finished.
+func (resolver *passphraseResolver) report() {
+	if resolver.literal > 0 {
+		logger.Warningf("%d rows in batch file contain literal passphrases, please replace them with references", resolver.literal)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPassphraseResolver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		alice = common.HexToAddress("0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e")
+		bob   = common.HexToAddress("0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf")
+		carol = common.HexToAddress("0x7Cd6342b4b02A90bcf60F1f843d1002897e38b1f")
+	)
+	mapfile := path.Join(dir, "passwords")
+	content := "# passphrase map\r\n0x7236bc5a9ff647d48b1eceaa07aa6438dcca615e alice passphrase\r\n\r\ntreasury\t s3cret \r\n"
+	if err := ioutil.WriteFile(mapfile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keyfile := path.Join(dir, "bob")
+	if err := ioutil.WriteFile(keyfile, []byte("bob passphrase\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("ETHCLIENT_TEST_PASSPHRASE", "env passphrase")
+	defer os.Unsetenv("ETHCLIENT_TEST_PASSPHRASE")
+
+	labels, err := loadPassphraseMap(mapfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompted := make(map[common.Address]int)
+	resolver := newPassphraseResolver(labels, func(sender common.Address) string {
+		prompted[sender] += 1
+		return "prompted"
+	})
+
+	var tests = []struct {
+		sender common.Address
+		field  string
+		want   string
+		fail   bool
+	}{
+		{alice, "", "alice passphrase", false},
+		{bob, "@treasury", "s3cret ", false},
+		{bob, "file:" + keyfile, "bob passphrase", false},
+		{carol, "env:ETHCLIENT_TEST_PASSPHRASE", "env passphrase", false},
+		{carol, "env:ETHCLIENT_TEST_UNSET", "", true},
+		{carol, "@unknown", "", true},
+		{carol, "", "prompted", false},
+		{carol, "", "prompted", false},
+		{bob, "", "prompted", false},
+		{carol, "helloworld", "helloworld", false},
+		{carol, "literal:@treasury", "@treasury", false},
+		{carol, "literal:env:ETHCLIENT_TEST_PASSPHRASE", "env:ETHCLIENT_TEST_PASSPHRASE", false},
+	}
+	for i, test := range tests {
+		got, err := resolver.resolve(test.sender, test.field)
+		if test.fail {
+			if err == nil {
+				t.Errorf("test %d: expect error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if got != test.want {
+			t.Errorf("test %d: passphrase mismatch, want %q, got %q", i, test.want, got)
+		}
+	}
+	// Prompt only once per sender
+	if prompted[carol] != 1 || prompted[bob] != 1 || prompted[alice] != 0 {
+		t.Errorf("prompt times mismatch, got %v", prompted)
+	}
+}
+
+func TestScanLiteralPassphrases(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	batchfile := path.Join(dir, "batch.txt")
+	content := "0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e, 0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf, 1, , helloworld\n" +
+		"0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e, 0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf, 1, , @treasury\n" +
+		"0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e, 0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf, 1, , \n" +
+		"0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e, 0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf, x, , corrupted\n" +
+		"0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e, 0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf, 1, #BALANCEOF EOS, literal:@home\n"
+	if err := ioutil.WriteFile(batchfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := NewRawTextReader(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	resolver := newPassphraseResolver(nil, nil)
+	if err := resolver.scan(reader); err != nil {
+		t.Fatal(err)
+	}
+	// The read-only macro row is counted as well, while the corrupted one is skipped
+	if resolver.literal != 2 {
+		t.Errorf("literal passphrase number mismatch, want 2, got %d", resolver.literal)
+	}
+}
+
+func TestLoadInvalidPassphraseMap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, content := range []string{"treasury\n", "treasury a\ntreasury b\n"} {
+		mapfile := path.Join(dir, "passwords")
+		if err := ioutil.WriteFile(mapfile, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadPassphraseMap(mapfile); err == nil {
+			t.Errorf("expect error for %q", content)
+		}
+	}
+}
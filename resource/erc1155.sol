@@ -0,0 +1,17 @@
+// ----------------------------------------------------------------------------
+// ERC Multi Token Standard #1155 Interface
+// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-1155.md
+// ----------------------------------------------------------------------------
+contract ERC1155Interface {
+    function balanceOf(address owner, uint id) public constant returns (uint balance);
+    function balanceOfBatch(address[] owners, uint[] ids) public constant returns (uint[] balances);
+    function isApprovedForAll(address owner, address operator) public constant returns (bool approved);
+    function safeTransferFrom(address from, address to, uint id, uint value, bytes data) public;
+    function safeBatchTransferFrom(address from, address to, uint[] ids, uint[] values, bytes data) public;
+    function setApprovalForAll(address operator, bool approved) public;
+
+    event TransferSingle(address indexed operator, address indexed from, address indexed to, uint id, uint value);
+    event TransferBatch(address indexed operator, address indexed from, address indexed to, uint[] ids, uint[] values);
+    event ApprovalForAll(address indexed owner, address indexed operator, bool approved);
+    event URI(string value, uint indexed id);
+}
@@ -0,0 +1,18 @@
+// ----------------------------------------------------------------------------
+// ERC Non-Fungible Token Standard #721 Interface
+// https://github.com/ethereum/EIPs/blob/master/EIPS/eip-721.md
+// ----------------------------------------------------------------------------
+contract ERC721Interface {
+    function balanceOf(address owner) public constant returns (uint balance);
+    function ownerOf(uint tokenId) public constant returns (address owner);
+    function getApproved(uint tokenId) public constant returns (address operator);
+    function isApprovedForAll(address owner, address operator) public constant returns (bool approved);
+    function safeTransferFrom(address from, address to, uint tokenId) public;
+    function transferFrom(address from, address to, uint tokenId) public;
+    function approve(address approved, uint tokenId) public;
+    function setApprovalForAll(address operator, bool approved) public;
+
+    event Transfer(address indexed from, address indexed to, uint indexed tokenId);
+    event Approval(address indexed owner, address indexed approved, uint indexed tokenId);
+    event ApprovalForAll(address indexed owner, address indexed operator, bool approved);
+}
@@ -0,0 +1,83 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+// CommonSignatures are the human-readable signatures of widely used contracts,
+// which are bundled in the signature database along with the token interfaces.
+const CommonSignatures = `
+// ERC20 metadata and extensions
+function name() view returns (string)
+function symbol() view returns (string)
+function decimals() view returns (uint8)
+function increaseAllowance(address spender, uint256 addedValue) returns (bool)
+function decreaseAllowance(address spender, uint256 subtractedValue) returns (bool)
+function permit(address owner, address spender, uint256 value, uint256 deadline, uint8 v, bytes32 r, bytes32 s)
+function nonces(address owner) view returns (uint256)
+function DOMAIN_SEPARATOR() view returns (bytes32)
+
+// ERC721 metadata and enumerable
+function tokenURI(uint256 tokenId) view returns (string)
+function tokenOfOwnerByIndex(address owner, uint256 index) view returns (uint256)
+function tokenByIndex(uint256 index) view returns (uint256)
+
+// ERC1155 metadata
+function uri(uint256 id) view returns (string)
+
+// ERC165
+function supportsInterface(bytes4 interfaceId) view returns (bool)
+
+// WETH
+function deposit() payable
+function withdraw(uint256 wad)
+event Deposit(address indexed dst, uint256 wad)
+event Withdrawal(address indexed src, uint256 wad)
+
+// Ownable
+function owner() view returns (address)
+function transferOwnership(address newOwner)
+function renounceOwnership()
+event OwnershipTransferred(address indexed previousOwner, address indexed newOwner)
+
+// Pausable
+function paused() view returns (bool)
+function pause()
+function unpause()
+event Paused(address account)
+event Unpaused(address account)
+
+// AccessControl
+function hasRole(bytes32 role, address account) view returns (bool)
+function getRoleAdmin(bytes32 role) view returns (bytes32)
+function grantRole(bytes32 role, address account)
+function revokeRole(bytes32 role, address account)
+function renounceRole(bytes32 role, address account)
+event RoleGranted(bytes32 indexed role, address indexed account, address indexed sender)
+event RoleRevoked(bytes32 indexed role, address indexed account, address indexed sender)
+
+// Proxies, EIP-1967 and transparent proxy
+function implementation() view returns (address)
+function admin() view returns (address)
+function changeAdmin(address newAdmin)
+function upgradeTo(address newImplementation)
+function upgradeToAndCall(address newImplementation, bytes data) payable
+function proxiableUUID() view returns (bytes32)
+event Upgraded(address indexed implementation)
+event AdminChanged(address previousAdmin, address newAdmin)
+event BeaconUpgraded(address indexed beacon)
+
+// Multicall
+function multicall(bytes[] data) returns (bytes[] results)
+function aggregate((address target, bytes callData)[] calls) returns (uint256 blockNumber, bytes[] returnData)
+`
@@ -0,0 +1,494 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+)
+
+var (
+	errResultsUnsupported = errors.New("batch format doesn't support result file")
+	errInvalidResultFile  = errors.New("invalid result file")
+)
+
+// Result status of batch rows.
+const (
+	ResultSent   = "sent"
+	ResultFailed = "failed"
+	ResultCalled = "called" // The row is a read-only macro executed as call
+)
+
+// resultColumns is the column order of result file in tabular formats.
+var resultColumns = []string{"row", "hash", "status", "error", "nonce", "gas", "result"}
+
+// BatchResult is the sending result of a single batch file row.
+type BatchResult struct {
+	Row    int    `json:"row"` // Row id in the batch file, same as the one reported in error messages
+	Hash   string `json:"hash,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Nonce  uint64 `json:"nonce"`
+	Gas    uint64 `json:"gas"`
+	Result string `json:"result,omitempty"` // Decoded result of read-only macro
+}
+
+// fields returns the result fields in the order of resultColumns.
+func (result *BatchResult) fields() []string {
+	return []string{
+		strconv.Itoa(result.Row),
+		result.Hash,
+		result.Status,
+		result.Error,
+		strconv.FormatUint(result.Nonce, 10),
+		strconv.FormatUint(result.Gas, 10),
+		result.Result,
+	}
+}
+
+// ResultWriter records the results of batch sending.
+type ResultWriter interface {
+	WriteResult(result BatchResult) error
+	Flush() error
+	Close() error
+}
+
+// OpenResultFile opens the result file with specified format. If the format is
+// not specified, it's decided by the file extension. Results are appended if
+// the result file already exists, so the interrupted batch can be resumed with
+// the same result file.
+func OpenResultFile(filename string, name string, opts FormatOptions) (ResultWriter, error) {
+	var (
+		format *BatchFormat
+		err    error
+	)
+	if name != "" {
+		format, err = lookupBatchFormat(name)
+	} else {
+		format, err = detectResultFormat(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if format.OpenResults == nil {
+		return nil, errResultsUnsupported
+	}
+	return format.OpenResults(filename, opts)
+}
+
+// detectResultFormat detects the result file format by the file extension.
+// The csv format is used if the extension is unknown.
+func detectResultFormat(filename string) (*BatchFormat, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, format := range batchFormats {
+		for _, e := range format.Extensions {
+			if e == ext {
+				return format, nil
+			}
+		}
+	}
+	return lookupBatchFormat("csv")
+}
+
+// defaultResultFile returns the default result file path and format of given
+// batch file, e.g. "batch.results.csv" for "batch.csv". The csv format is used
+// if the batch file format doesn't support result file.
+func defaultResultFile(batchfile string, name string) (string, string, error) {
+	var (
+		format *BatchFormat
+		err    error
+	)
+	if name != "" {
+		format, err = lookupBatchFormat(name)
+	} else {
+		format, err = detectBatchFormat(batchfile)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	ext := filepath.Ext(batchfile)
+	if format.OpenResults == nil {
+		return strings.TrimSuffix(batchfile, ext) + ".results.csv", "csv", nil
+	}
+	return strings.TrimSuffix(batchfile, ext) + ".results" + ext, format.Name, nil
+}
+
+/*
+	Line based result writer
+*/
+
+// lineResultWriter appends every result as a line to the end of result file
+// and syncs it to disk, so that all written results survive the interruption.
+type lineResultWriter struct {
+	fd     *os.File
+	encode func(result BatchResult) ([]byte, error)
+}
+
+func openLineResultWriter(filename string, header []byte, encode func(result BatchResult) ([]byte, error)) (*lineResultWriter, error) {
+	fd, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fd.Stat()
+	if err != nil {
+		fd.Close()
+		return nil, err
+	}
+	var prefix []byte
+	if info.Size() == 0 {
+		prefix = header
+	} else {
+		// Terminate the last line first if the final newline is missing.
+		last := make([]byte, 1)
+		if _, err := fd.ReadAt(last, info.Size()-1); err != nil {
+			fd.Close()
+			return nil, err
+		}
+		if last[0] != '\n' {
+			prefix = []byte("\n")
+		}
+	}
+	if len(prefix) > 0 {
+		if _, err := fd.Write(prefix); err != nil {
+			fd.Close()
+			return nil, err
+		}
+	}
+	return &lineResultWriter{fd: fd, encode: encode}, nil
+}
+
+func (writer *lineResultWriter) WriteResult(result BatchResult) error {
+	line, err := writer.encode(result)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.fd.Write(line); err != nil {
+		return err
+	}
+	return writer.fd.Sync()
+}
+
+// Flush is a no-op since every result is synced as soon as it's written.
+func (writer *lineResultWriter) Flush() error {
+	return nil
+}
+
+func (writer *lineResultWriter) Close() error {
+	return writer.fd.Close()
+}
+
+// NewCSVResultWriter opens a csv result file with header row.
+func NewCSVResultWriter(filename string) (ResultWriter, error) {
+	return openLineResultWriter(filename, encodeCSVLine(resultColumns), func(result BatchResult) ([]byte, error) {
+		return encodeCSVLine(result.fields()), nil
+	})
+}
+
+func encodeCSVLine(fields []string) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(fields)
+	w.Flush()
+	return buf.Bytes()
+}
+
+// NewRawTextResultWriter opens a raw text result file. Raw text has no quoting
+// rule, so the commas and line breaks in error message are replaced.
+//
+// Note, raw text result line format:
+// <row>, <hash>, <status>, <error>, <nonce>, <gas>, <result>
+func NewRawTextResultWriter(filename string) (ResultWriter, error) {
+	replacer := strings.NewReplacer(",", ";", "\r", " ", "\n", " ")
+	return openLineResultWriter(filename, nil, func(result BatchResult) ([]byte, error) {
+		fields := result.fields()
+		for i := range fields {
+			fields[i] = replacer.Replace(fields[i])
+		}
+		return []byte(strings.Join(fields, ", ") + "\n"), nil
+	})
+}
+
+// NewJSONLResultWriter opens a json lines result file.
+func NewJSONLResultWriter(filename string) (ResultWriter, error) {
+	return openLineResultWriter(filename, nil, func(result BatchResult) ([]byte, error) {
+		blob, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		return append(blob, '\n'), nil
+	})
+}
+
+/*
+	JSON result writer
+*/
+
+// JSONResultWriter writes the results as a json array. The closing bracket is
+// rewritten after every result, so the file is always a valid json document.
+type JSONResultWriter struct {
+	fd    *os.File
+	end   int64 // offset right after the last array element
+	empty bool
+}
+
+func NewJSONResultWriter(filename string) (ResultWriter, error) {
+	fd, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	writer := &JSONResultWriter{fd: fd, empty: true}
+	if err := writer.locate(); err != nil {
+		fd.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+// locate finds the end of the existent array, or initializes an empty one.
+func (writer *JSONResultWriter) locate() error {
+	info, err := writer.fd.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		writer.end = 1
+		_, err := writer.fd.WriteAt([]byte("[\n]\n"), 0)
+		return err
+	}
+	content, err := readTail(writer.fd, info.Size())
+	if err != nil {
+		return err
+	}
+	trimmed := bytes.TrimRight(content, " \t\r\n")
+	if !bytes.HasSuffix(trimmed, []byte("]")) {
+		return errInvalidResultFile
+	}
+	trimmed = bytes.TrimRight(trimmed[:len(trimmed)-1], " \t\r\n")
+	if len(trimmed) == 0 {
+		return errInvalidResultFile
+	}
+	writer.end = info.Size() - int64(len(content)-len(trimmed))
+	writer.empty = trimmed[len(trimmed)-1] == '['
+	return nil
+}
+
+// readTail reads at most the last 4KB of the file.
+func readTail(fd *os.File, size int64) ([]byte, error) {
+	offset := size - 4096
+	if offset < 0 {
+		offset = 0
+	}
+	content := make([]byte, size-offset)
+	if _, err := fd.ReadAt(content, offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return content, nil
+}
+
+func (writer *JSONResultWriter) WriteResult(result BatchResult) error {
+	blob, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if !writer.empty {
+		buf.WriteString(",")
+	}
+	buf.WriteString("\n  ")
+	buf.Write(blob)
+	element := buf.Len()
+	buf.WriteString("\n]\n")
+
+	if _, err := writer.fd.WriteAt(buf.Bytes(), writer.end); err != nil {
+		return err
+	}
+	writer.end += int64(element)
+	writer.empty = false
+	return writer.fd.Sync()
+}
+
+// Flush is a no-op since every result is synced as soon as it's written.
+func (writer *JSONResultWriter) Flush() error {
+	return nil
+}
+
+func (writer *JSONResultWriter) Close() error {
+	return writer.fd.Close()
+}
+
+/*
+	Excel result writer
+*/
+
+// ExcelResultWriter appends the results to a sheet of the workbook. Results
+// are recorded in the journal first and saved to the workbook when flushed.
+type ExcelResultWriter struct {
+	*journaledWriter
+	sheet string
+	rows  int
+}
+
+func NewExcelResultWriter(filename string, sheet string) (ResultWriter, error) {
+	if sheet == "" {
+		sheet = DefaultSheet
+	}
+	writer := &ExcelResultWriter{sheet: sheet}
+	journaled, err := newJournaledWriter(filename, false, writer.apply)
+	if err != nil {
+		return nil, err
+	}
+	writer.journaledWriter = journaled
+	if err := writer.Recover(); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (writer *ExcelResultWriter) WriteResult(result BatchResult) error {
+	blob, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return writer.journaledWriter.WriteString(strconv.Itoa(result.Row), string(blob))
+}
+
+// apply appends the recorded results to the end of sheet, the header row is
+// added if the sheet is empty.
+func (writer *ExcelResultWriter) apply(filename string, cursor *journalCursor) error {
+	var fd *excelize.File
+	if _, err := os.Stat(filename); err == nil {
+		if fd, err = excelize.OpenFile(filename); err != nil {
+			return err
+		}
+	} else {
+		fd = excelize.NewFile()
+		fd.SetSheetName(DefaultSheet, writer.sheet)
+	}
+	if fd.GetSheetIndex(writer.sheet) == 0 {
+		fd.NewSheet(writer.sheet)
+	}
+	rows := len(fd.GetRows(writer.sheet))
+	if rows == 0 {
+		writer.setRow(fd, 1, resultColumns)
+		rows = 1
+	}
+	for ; cursor.entry != nil; cursor.next() {
+		var result BatchResult
+		if err := json.Unmarshal([]byte(cursor.entry.value), &result); err != nil {
+			return errCorruptedJournal
+		}
+		rows += 1
+		writer.setRow(fd, rows, result.fields())
+	}
+	return fd.SaveAs(filename)
+}
+
+func (writer *ExcelResultWriter) setRow(fd *excelize.File, row int, fields []string) {
+	for idx, field := range fields {
+		fd.SetCellValue(writer.sheet, excelize.ToAlphaString(idx)+strconv.Itoa(row), field)
+	}
+}
+
+/*
+	ODS result writer
+*/
+
+// ODSResultWriter appends the results to a sheet of the OpenDocument spreadsheet,
+// the semantics are same with ExcelResultWriter.
+type ODSResultWriter struct {
+	*journaledWriter
+	sheet string
+}
+
+func NewODSResultWriter(filename string, sheet string) (ResultWriter, error) {
+	if sheet == "" {
+		sheet = DefaultSheet
+	}
+	writer := &ODSResultWriter{sheet: sheet}
+	journaled, err := newJournaledWriter(filename, false, writer.apply)
+	if err != nil {
+		return nil, err
+	}
+	writer.journaledWriter = journaled
+	if err := writer.Recover(); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (writer *ODSResultWriter) WriteResult(result BatchResult) error {
+	blob, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return writer.journaledWriter.WriteString(strconv.Itoa(result.Row), string(blob))
+}
+
+// apply appends the recorded results to the end of sheet, the header row is
+// added if the sheet is empty.
+func (writer *ODSResultWriter) apply(filename string, cursor *journalCursor) error {
+	var rows [][]string
+	for ; cursor.entry != nil; cursor.next() {
+		var result BatchResult
+		if err := json.Unmarshal([]byte(cursor.entry.value), &result); err != nil {
+			return errCorruptedJournal
+		}
+		rows = append(rows, result.fields())
+	}
+	return appendODS(filename, writer.sheet, resultColumns, rows)
+}
+
+/*
+	In-place result writer
+*/
+
+// inplaceResultWriter writes the hashes of sent transactions and the results of
+// read-only macros back to the result column of batch file itself. The failed
+// rows are left untouched.
+type inplaceResultWriter struct {
+	rw RWriter
+}
+
+func newInplaceResultWriter(rw RWriter) ResultWriter {
+	return &inplaceResultWriter{rw: rw}
+}
+
+func (writer *inplaceResultWriter) WriteResult(result BatchResult) error {
+	switch result.Status {
+	case ResultSent:
+		return writer.rw.WriteString(writer.rw.Axis(result.Row), result.Hash)
+	case ResultCalled:
+		return writer.rw.WriteCalled(writer.rw.Axis(result.Row), result.Result)
+	}
+	return nil
+}
+
+func (writer *inplaceResultWriter) Flush() error {
+	return writer.rw.Flush()
+}
+
+// Close is a no-op, the batch file is closed by its owner.
+func (writer *inplaceResultWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,492 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var testResults = []BatchResult{
+	{Row: 1, Hash: "0x8f3c3f8b1c9e2f3b0c0c6a4ff39b8d6c1f2d1c07d2a4a5e0de0b83ef8b5b7a21", Status: ResultSent, Nonce: 7, Gas: 21000},
+	{Row: 2, Status: ResultFailed, Error: "insufficient funds, for gas * price + value", Nonce: 8, Gas: 52000},
+	{Row: 4, Hash: "0x1a1e1d6dc2ea5fa3bbac2d4f4bf3d7f2c37bd3fba7f1c7e3f2c5a6e7d5d6c7b8", Status: ResultSent, Nonce: 9, Gas: 21000},
+	{Row: 5, Status: ResultCalled, Result: "12.5"},
+}
+
+func TestResultWriters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var tests = []struct {
+		filename string
+		parse    func(t *testing.T, filename string) []BatchResult
+	}{
+		{"results.csv", parseCSVResults},
+		{"results.txt", parseRawTextResults},
+		{"results.json", parseJSONResults},
+		{"results.jsonl", parseJSONLResults},
+		{"results.xlsx", parseExcelResults},
+		{"results.ods", parseODSResults},
+	}
+	for _, test := range tests {
+		filename := path.Join(dir, test.filename)
+		// The results are appended if the result file is reopened.
+		for _, results := range [][]BatchResult{testResults[:2], testResults[2:]} {
+			writer, err := OpenResultFile(filename, "", FormatOptions{})
+			if err != nil {
+				t.Fatalf("%s: %v", test.filename, err)
+			}
+			for _, result := range results {
+				if err := writer.WriteResult(result); err != nil {
+					t.Fatalf("%s: %v", test.filename, err)
+				}
+			}
+			if err := writer.Flush(); err != nil {
+				t.Fatalf("%s: %v", test.filename, err)
+			}
+			writer.Close()
+		}
+		got := test.parse(t, filename)
+		want := testResults
+		if strings.HasSuffix(test.filename, ".txt") {
+			// Commas are replaced in raw text result file
+			want = append([]BatchResult{}, testResults...)
+			want[1].Error = strings.Replace(want[1].Error, ",", ";", -1)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: results mismatch, want %v, got %v", test.filename, want, got)
+		}
+	}
+}
+
+func TestODSResultWriterAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content, err := ioutil.ReadFile(path.Join("test", "batch.ods"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := path.Join(dir, "batch.ods")
+	if err := ioutil.WriteFile(filename, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The results are appended to the existent sheet with trailing empty
+	// rows and the new sheet.
+	origin := odsRowNumbers(t, filename, DefaultSheet)
+	for _, sheet := range []string{DefaultSheet, "Results"} {
+		writer, err := NewODSResultWriter(filename, sheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, result := range testResults {
+			if err := writer.WriteResult(result); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		writer.Close()
+	}
+	want := origin
+	for i := range testResults {
+		want = append(want, origin[len(origin)-1]+i+1)
+	}
+	if got := odsRowNumbers(t, filename, DefaultSheet); !reflect.DeepEqual(got, want) {
+		t.Errorf("row numbers mismatch, want %v, got %v", want, got)
+	}
+	if got := parseODSSheetResults(t, filename, "Results"); !reflect.DeepEqual(got, testResults) {
+		t.Errorf("results mismatch, want %v, got %v", testResults, got)
+	}
+	// The other sheet is left untouched
+	reader, err := NewODSReader(filename, "Transfers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if params, err := reader.ReadAll(); err != nil || len(params) != 1 || params[0].Data != "#TRANSFER EOS 1" {
+		t.Errorf("other sheet mismatch, got %v, %v", params, err)
+	}
+}
+
+// odsRowNumbers returns the numbers of all non-empty rows in the sheet.
+func odsRowNumbers(t *testing.T, filename string, sheet string) []int {
+	stream, err := openODSSheet(filename, sheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.close()
+
+	var numbers []int
+	for {
+		number, _, err := stream.next()
+		if err == io.EOF {
+			return numbers
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		numbers = append(numbers, number)
+	}
+}
+
+func TestJSONResultWriterValid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The result file is always a valid json document even if the writer is
+	// not closed properly.
+	filename := path.Join(dir, "results.json")
+	writer, err := NewJSONResultWriter(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	for i, result := range testResults {
+		if err := writer.WriteResult(result); err != nil {
+			t.Fatal(err)
+		}
+		if got := parseJSONResults(t, filename); len(got) != i+1 {
+			t.Fatalf("result number mismatch, want %d, got %d", i+1, len(got))
+		}
+	}
+}
+
+func TestRawTextInplaceRerun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := "0x0000000000000000000000000000000000000001, 0x0000000000000000000000000000000000000002, 1, , 123\r\n"
+	batchfile := path.Join(dir, "batch.txt")
+	if err := ioutil.WriteFile(batchfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Rerun the batch twice, the hash of last run should replace the old one.
+	for _, hash := range []string{"0x01", "0x02"} {
+		rw, err := NewRawTextRWriter(batchfile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer := newInplaceResultWriter(rw)
+		writer.WriteResult(BatchResult{Row: 0, Status: ResultFailed})
+		writer.WriteResult(BatchResult{Row: 0, Hash: hash, Status: ResultSent})
+		if err := writer.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		rw.Close()
+	}
+	got, err := ioutil.ReadFile(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.TrimSuffix(content, "\r\n") + ", 0x02\r\n"
+	if string(got) != want {
+		t.Errorf("content mismatch, want %q, got %q", want, got)
+	}
+}
+
+func TestInplaceCalledRows(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := `{"from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","value":0,"data":"#BALANCEOF RDN 0x0000000000000000000000000000000000000001","passphrase":"","hash":"0x0000000000000000000000000000000000000000000000000000000000000000","status":false}
+{"from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","value":1,"data":"","passphrase":"","hash":"0x0000000000000000000000000000000000000000000000000000000000000000","status":false}
+`
+	batchfile := path.Join(dir, "batch.jsonl")
+	if err := ioutil.WriteFile(batchfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rw, err := NewJSONLRWriter(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := newInplaceResultWriter(rw)
+	if err := writer.WriteResult(BatchResult{Row: 0, Status: ResultCalled, Result: "1.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteResult(BatchResult{Row: 1, Hash: "0x02", Status: ResultSent}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rw.Close()
+
+	reader, err := NewJSONLReader(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	params, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 2 {
+		t.Fatalf("row number mismatch, want 2, got %d", len(params))
+	}
+	if params[0].Result != "1.5" || params[0].Status || params[0].Hash != (common.Hash{}) {
+		t.Errorf("called row mismatch, result %q hash %s status %v", params[0].Result, params[0].Hash.Hex(), params[0].Status)
+	}
+	if !params[1].Status || params[1].Hash != common.HexToHash("0x02") {
+		t.Errorf("sent row mismatch, hash %s status %v", params[1].Hash.Hex(), params[1].Status)
+	}
+}
+
+func TestReplayJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := "0x0000000000000000000000000000000000000001, 0x0000000000000000000000000000000000000002, 1, , 123\n" +
+		"0x0000000000000000000000000000000000000001, 0x0000000000000000000000000000000000000002, 2, , 123\n"
+	batchfile := path.Join(dir, "batch.txt")
+	if err := ioutil.WriteFile(batchfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Interrupt the inplace run without flushing
+	rw, err := NewRawTextRWriter(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := newInplaceResultWriter(rw)
+	if err := writer.WriteResult(BatchResult{Row: 0, Status: ResultCalled, Result: "20.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteResult(BatchResult{Row: 1, Hash: "0x01", Status: ResultSent}); err != nil {
+		t.Fatal(err)
+	}
+	rw.Close()
+
+	// Rerun without inplace, the results go to the result file
+	output := path.Join(dir, "batch.results.csv")
+	results, err := NewCSVResultWriter(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replayJournal(batchfile, results); err != nil {
+		t.Fatal(err)
+	}
+	results.Close()
+
+	if got, _ := ioutil.ReadFile(batchfile); string(got) != content {
+		t.Errorf("batch file is modified, got %q", got)
+	}
+	if _, err := os.Stat(batchfile + journalSuffix); !os.IsNotExist(err) {
+		t.Error("journal is not removed after replay")
+	}
+	got := parseCSVResults(t, output)
+	if len(got) != 2 || got[0].Row != 0 || got[0].Result != "20.5" || got[0].Status != ResultCalled ||
+		got[1].Row != 1 || got[1].Hash != "0x01" || got[1].Status != ResultSent {
+		t.Errorf("replayed results mismatch, got %v", got)
+	}
+}
+
+func TestRawTextInplaceCalledRows(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := "0x0000000000000000000000000000000000000001, 0x0000000000000000000000000000000000000002, 0, #BALANCEOF RDN 0x0000000000000000000000000000000000000001, \n" +
+		"0x0000000000000000000000000000000000000001, 0x0000000000000000000000000000000000000002, 1, , \n"
+	batchfile := path.Join(dir, "batch.txt")
+	if err := ioutil.WriteFile(batchfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rw, err := NewRawTextRWriter(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := newInplaceResultWriter(rw)
+	if err := writer.WriteResult(BatchResult{Row: 0, Status: ResultCalled, Result: "20.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteResult(BatchResult{Row: 1, Hash: "0x02", Status: ResultSent}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rw.Close()
+
+	want := "0x0000000000000000000000000000000000000001, 0x0000000000000000000000000000000000000002, 0, #BALANCEOF RDN 0x0000000000000000000000000000000000000001, , 20.5\n" +
+		"0x0000000000000000000000000000000000000001, 0x0000000000000000000000000000000000000002, 1, , , 0x02\n"
+	got, err := ioutil.ReadFile(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("file content mismatch\nwant:\n%q\ngot:\n%q", want, got)
+	}
+}
+
+func parseResultFields(t *testing.T, fields []string) BatchResult {
+	if len(fields) != len(resultColumns) {
+		t.Fatalf("invalid result fields %v", fields)
+	}
+	var result BatchResult
+	blob := `{"row":` + fields[0] + `,"hash":"` + fields[1] + `","status":"` + fields[2] + `","error":"` + fields[3] + `","nonce":` + fields[4] + `,"gas":` + fields[5] + `,"result":"` + fields[6] + `"}`
+	if err := json.Unmarshal([]byte(blob), &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func parseCSVResults(t *testing.T, filename string) []BatchResult {
+	fd, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	records, err := csv.NewReader(fd).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(records[0], resultColumns) {
+		t.Fatalf("header mismatch, got %v", records[0])
+	}
+	var results []BatchResult
+	for _, record := range records[1:] {
+		results = append(results, parseResultFields(t, record))
+	}
+	return results
+}
+
+func parseRawTextResults(t *testing.T, filename string) []BatchResult {
+	fd, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	var results []BatchResult
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ", ")
+		results = append(results, parseResultFields(t, fields))
+	}
+	return results
+}
+
+func parseJSONResults(t *testing.T, filename string) []BatchResult {
+	blob, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []BatchResult
+	if err := json.Unmarshal(blob, &results); err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+func parseJSONLResults(t *testing.T, filename string) []BatchResult {
+	fd, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	var results []BatchResult
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		var result BatchResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func parseExcelResults(t *testing.T, filename string) []BatchResult {
+	fd, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := fd.GetRows(DefaultSheet)
+	if len(rows) == 0 || !reflect.DeepEqual(rows[0], resultColumns) {
+		t.Fatalf("header mismatch, got %v", rows)
+	}
+	var results []BatchResult
+	for _, row := range rows[1:] {
+		results = append(results, parseResultFields(t, row))
+	}
+	return results
+}
+
+func parseODSResults(t *testing.T, filename string) []BatchResult {
+	return parseODSSheetResults(t, filename, DefaultSheet)
+}
+
+func parseODSSheetResults(t *testing.T, filename string, sheet string) []BatchResult {
+	stream, err := openODSSheet(filename, sheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.close()
+
+	var results []BatchResult
+	for {
+		number, row, err := stream.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The trailing empty cells are omitted by the reader
+		for len(row) < len(resultColumns) {
+			row = append(row, "")
+		}
+		if number == 1 {
+			if !reflect.DeepEqual(row, resultColumns) {
+				t.Fatalf("header mismatch, got %v", row)
+			}
+			continue
+		}
+		results = append(results, parseResultFields(t, row))
+	}
+	return results
+}
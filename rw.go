@@ -16,16 +16,19 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/360EntSecGroup-Skylar/excelize"
 	"github.com/ethereum/go-ethereum/common"
-	"io/ioutil"
 )
 
 var (
@@ -39,8 +42,8 @@ const (
 	fieldNumber = 5 // total field number of transaction in batch file
 )
 
-// ErrCorrupted describes error due to corruption. This error will be wrapped
-// with errors.ErrCorrupted.
+// ErrCorrupted describes error due to corruption. Readers return it for the
+// rows with invalid content, which can be skipped safely.
 type ErrCorrupted struct {
 	Pos    int64
 	Size   int64
@@ -57,20 +60,77 @@ type TransactionParams struct {
 	From       common.Address `json:"from"`
 	To         common.Address `json:"to"`
 	Value      int64          `json:"value"`
+	ValueExpr  string         `json:"-"` // value expression evaluated at send time, see expr.go. It's the string value in json
 	Data       string         `json:"data"`
 	Passphrase string         `json:"passphrase"`
 	Hash       common.Hash    `json:"hash"`
 	Status     bool           `json:"status"`
+	Result     string         `json:"result,omitempty"` // result of read-only macro, only available in json
+	Row        int            `json:"-"`                // original row number in batch file
 }
 
+// UnmarshalJSON decodes the transaction object, the value can be an integer in
+// wei or a string of integer or value expression like the other formats.
+func (param *TransactionParams) UnmarshalJSON(input []byte) error {
+	type params TransactionParams
+	var dec struct {
+		*params
+		Value json.RawMessage `json:"value"`
+	}
+	dec.params = (*params)(param)
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	param.Value, param.ValueExpr = 0, ""
+	if len(dec.Value) == 0 || string(dec.Value) == "null" {
+		return nil
+	}
+	var field string
+	if err := json.Unmarshal(dec.Value, &field); err != nil {
+		return json.Unmarshal(dec.Value, &param.Value)
+	}
+	value, valueExpr, err := parseValueField(strings.TrimSpace(field))
+	if err != nil {
+		return fmt.Errorf("invalid transfer value %s", field)
+	}
+	param.Value, param.ValueExpr = value, valueExpr
+	return nil
+}
+
+// MarshalJSON encodes the transaction object, the value expression is kept as
+// the string value.
+func (param TransactionParams) MarshalJSON() ([]byte, error) {
+	type params TransactionParams
+	if param.ValueExpr == "" {
+		return json.Marshal(params(param))
+	}
+	return json.Marshal(struct {
+		params
+		Value string `json:"value"`
+	}{params(param), param.ValueExpr})
+}
+
+// Reader reads transactions from batch file row by row. All implementations
+// stream the rows, so that the memory usage is bounded regardless of file size.
+// Rows with invalid content are reported as *ErrCorrupted, which can be skipped.
 type Reader interface {
 	Read() (TransactionParams, error)
 	ReadAll() ([]TransactionParams, error)
+	Close() error
 }
 
+// Writer records the results into batch file. Results are appended to a journal
+// once written, and merged into the batch file when flushing.
 type Writer interface {
+	// Axis returns the position in batch file to record the result of given row.
+	Axis(row int) string
 	WriteString(axis string, value string) error
+	// WriteCalled records the result of read-only macro at the given position.
+	WriteCalled(axis string, value string) error
+	// Recover merges the results left in the journal by the interrupted run.
+	Recover() error
 	Flush() error
+	Close() error
 }
 
 type RWriter interface {
@@ -78,80 +138,474 @@ type RWriter interface {
 	Writer
 }
 
+// readAll reads all rows with the given reader, corrupted rows are skipped.
+func readAll(reader Reader) ([]TransactionParams, error) {
+	params := []TransactionParams{}
+	for {
+		param, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*ErrCorrupted); ok {
+				logger.Error(err)
+				continue
+			}
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
 /*
 	Json Reader
 */
 
+// JSONReader a reader to read json batch file.
+// Note, json batch file is an array of transaction objects:
+// [{"from": <sender>, "to": <receiver>, "value": <value>, "data": <payload>, "passphrase": <passphrase>}, ...]
+type JSONReader struct {
+	fd      *os.File
+	decoder *json.Decoder
+	started bool
+	idx     int
+}
+
+func NewJSONReader(filename string) (Reader, error) {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONReader{
+		fd:      fd,
+		decoder: json.NewDecoder(bufio.NewReader(fd)),
+	}, nil
+}
+
+// openJSONArray consumes the opening bracket of the json array.
+func openJSONArray(decoder *json.Decoder) error {
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return errEmptyFileContent
+	}
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return errInvalidContent
+	}
+	return nil
+}
+
+// Read decodes the next transaction object in the json array.
+func (reader *JSONReader) Read() (TransactionParams, error) {
+	if !reader.started {
+		if err := openJSONArray(reader.decoder); err != nil {
+			return TransactionParams{}, err
+		}
+		reader.started = true
+	}
+	if !reader.decoder.More() {
+		return TransactionParams{}, io.EOF
+	}
+	var param TransactionParams
+	if err := reader.decoder.Decode(&param); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok || err == io.ErrUnexpectedEOF {
+			return TransactionParams{}, err
+		}
+		// The invalid object is already consumed by the decoder, skip it
+		reader.idx += 1
+		return TransactionParams{}, &ErrCorrupted{Pos: int64(reader.idx - 1), Kind: "json object", Reason: err.Error()}
+	}
+	param.Row = reader.idx
+	reader.idx += 1
+	return param, nil
+}
+
+// ReadAll decodes all transaction objects in the json array.
+func (reader *JSONReader) ReadAll() ([]TransactionParams, error) {
+	return readAll(reader)
+}
+
+func (reader *JSONReader) Close() error {
+	return reader.fd.Close()
+}
+
+// JSONLReader a reader to read json lines batch file.
+// Note, each non-empty line of the file is a single transaction object.
+type JSONLReader struct {
+	fd      *os.File
+	scanner *bufio.Scanner
+	idx     int // line index
+	row     int // object index
+}
+
+func NewJSONLReader(filename string) (Reader, error) {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLReader{
+		fd:      fd,
+		scanner: bufio.NewScanner(fd),
+	}, nil
+}
+
+// Read decodes the next non-empty line in the json lines file.
+func (reader *JSONLReader) Read() (TransactionParams, error) {
+	for reader.scanner.Scan() {
+		line := strings.TrimSpace(reader.scanner.Text())
+		reader.idx += 1
+		if line == "" {
+			continue
+		}
+		reader.row += 1
+		var param TransactionParams
+		if err := json.Unmarshal([]byte(line), &param); err != nil {
+			return TransactionParams{}, &ErrCorrupted{Pos: int64(reader.idx - 1), Kind: "json line", Reason: err.Error()}
+		}
+		param.Row = reader.row - 1
+		return param, nil
+	}
+	if err := reader.scanner.Err(); err != nil {
+		return TransactionParams{}, err
+	}
+	return TransactionParams{}, io.EOF
+}
+
+// ReadAll decodes all lines in the json lines file.
+// Blank lines and corrupted lines are skipped.
+func (reader *JSONLReader) ReadAll() ([]TransactionParams, error) {
+	return readAll(reader)
+}
+
+func (reader *JSONLReader) Close() error {
+	return reader.fd.Close()
+}
+
+// JSONWriter records the transaction results into a json or json lines batch file.
+// Only the objects with results are re-encoded when flushing, others are kept untouched.
+type JSONWriter struct {
+	*journaledWriter
+	lines bool
+	size  int // total number of transaction objects
+}
+
+func newJSONWriter(filename string, lines bool) (*JSONWriter, error) {
+	writer := &JSONWriter{lines: lines}
+	if err := writer.count(filename); err != nil {
+		return nil, err
+	}
+	journaled, err := newJournaledWriter(filename, true, writer.apply)
+	if err != nil {
+		return nil, err
+	}
+	writer.journaledWriter = journaled
+	return writer, nil
+}
+
+func NewJSONWriter(filename string) (Writer, error) {
+	return newJSONWriter(filename, false)
+}
+
+func NewJSONLWriter(filename string) (Writer, error) {
+	return newJSONWriter(filename, true)
+}
+
+// count counts the transaction objects in the batch file.
+func (writer *JSONWriter) count(filename string) error {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	if writer.lines {
+		scanner := bufio.NewScanner(fd)
+		for scanner.Scan() {
+			if len(bytes.TrimSpace(scanner.Bytes())) != 0 {
+				writer.size += 1
+			}
+		}
+		return scanner.Err()
+	}
+	decoder := json.NewDecoder(bufio.NewReader(fd))
+	if err := openJSONArray(decoder); err != nil {
+		return err
+	}
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return err
+		}
+		writer.size += 1
+	}
+	return nil
+}
+
+// Axis returns the object index of given row.
+func (writer *JSONWriter) Axis(row int) string {
+	return strconv.Itoa(row)
+}
+
+// WriteString records the transaction hash of the specific object.
+// Using string as the index is due to interface uniform.
+func (writer *JSONWriter) WriteString(s string, value string) error {
+	if err := writer.check(s); err != nil {
+		return err
+	}
+	return writer.journaledWriter.WriteString(s, value)
+}
+
+// WriteCalled records the result of read-only macro of the specific object.
+func (writer *JSONWriter) WriteCalled(s string, value string) error {
+	if err := writer.check(s); err != nil {
+		return err
+	}
+	return writer.journaledWriter.WriteCalled(s, value)
+}
+
+// check checks whether the object index is in the range.
+func (writer *JSONWriter) check(s string) error {
+	idx, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	if idx < 0 || idx >= writer.size {
+		return errRowIndexExceed
+	}
+	return nil
+}
+
+// apply merges the recorded results into the batch file.
+func (writer *JSONWriter) apply(filename string, cursor *journalCursor) error {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	setResult := func(raw []byte, entry *journalEntry) ([]byte, error) {
+		var param TransactionParams
+		if err := json.Unmarshal(raw, &param); err != nil {
+			return nil, err
+		}
+		if entry.called {
+			param.Result = entry.value
+		} else {
+			param.Hash = common.HexToHash(entry.value)
+			param.Status = true
+		}
+		if writer.lines {
+			return json.Marshal(param)
+		}
+		return json.MarshalIndent(param, "  ", "  ")
+	}
+	return rewriteFile(filename, func(w *bufio.Writer) error {
+		if writer.lines {
+			var (
+				reader = bufio.NewReader(fd)
+				row    = 0
+			)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil && err != io.EOF {
+					return err
+				}
+				if content := strings.TrimRight(line, "\r\n"); strings.TrimSpace(content) != "" {
+					if entry := cursor.match(row); entry != nil {
+						result, err := setResult([]byte(content), entry)
+						if err != nil {
+							return err
+						}
+						line = string(result) + line[len(content):]
+					}
+					row += 1
+				}
+				w.WriteString(line)
+				if err == io.EOF {
+					return nil
+				}
+			}
+		}
+		decoder := json.NewDecoder(bufio.NewReader(fd))
+		if err := openJSONArray(decoder); err != nil {
+			return err
+		}
+		w.WriteString("[")
+		for row := 0; decoder.More(); row++ {
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return err
+			}
+			if entry := cursor.match(row); entry != nil {
+				result, err := setResult(raw, entry)
+				if err != nil {
+					return err
+				}
+				raw = result
+			}
+			if row > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n  ")
+			w.Write(raw)
+		}
+		w.WriteString("\n]\n")
+		return nil
+	})
+}
+
+type JSONRWriter struct {
+	reader Reader
+	writer Writer
+}
+
+func NewJSONRWriter(filename string) (RWriter, error) {
+	writer, err := NewJSONWriter(filename)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := NewJSONReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONRWriter{
+		reader: reader,
+		writer: writer,
+	}, nil
+}
+
+func NewJSONLRWriter(filename string) (RWriter, error) {
+	writer, err := NewJSONLWriter(filename)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := NewJSONLReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONRWriter{
+		reader: reader,
+		writer: writer,
+	}, nil
+}
+
+func (rw *JSONRWriter) Read() (TransactionParams, error) {
+	return rw.reader.Read()
+}
+
+func (rw *JSONRWriter) ReadAll() ([]TransactionParams, error) {
+	return rw.reader.ReadAll()
+}
+
+func (rw *JSONRWriter) Axis(row int) string {
+	return rw.writer.Axis(row)
+}
+
+func (rw *JSONRWriter) WriteString(axis string, value string) error {
+	return rw.writer.WriteString(axis, value)
+}
+
+func (rw *JSONRWriter) WriteCalled(axis string, value string) error {
+	return rw.writer.WriteCalled(axis, value)
+}
+
+func (rw *JSONRWriter) Recover() error {
+	return rw.writer.Recover()
+}
+
+func (rw *JSONRWriter) Flush() error {
+	return rw.writer.Flush()
+}
+
+func (rw *JSONRWriter) Close() error {
+	rw.reader.Close()
+	return rw.writer.Close()
+}
+
 /*
 	Excel Reader
 */
 
 const DefaultSheet = "Sheet1"
 
+// ExcelReader a reader to read excel batch file. The first row of sheet is
+// treated as header and skipped.
 type ExcelReader struct {
-	fd    *excelize.File
-	sheet string
-	idx   int
+	stream  *xlsxSheetReader
+	visited bool
 }
 
 func NewExcelReader(filename string, sheet string) (Reader, error) {
-	fd, err := excelize.OpenFile(filename)
+	stream, err := openXLSXSheet(filename, sheet)
 	if err != nil {
 		return nil, err
 	}
 	return &ExcelReader{
-		fd:    fd,
-		sheet: sheet,
-		idx:   0,
+		stream: stream,
 	}, nil
 }
 
+// Read reads the next non-empty row in the sheet.
 func (reader *ExcelReader) Read() (TransactionParams, error) {
-	rows := reader.fd.GetRows(reader.sheet)
-	if len(rows) < 1 {
-		return TransactionParams{}, errEmptyFileContent
-	}
-	reader.idx += 1
-	if len(rows) >= reader.idx {
-		return TransactionParams{}, io.EOF
+	for {
+		idx, row, err := reader.stream.next()
+		if err == io.EOF && !reader.visited {
+			return TransactionParams{}, errEmptyFileContent
+		}
+		if err != nil {
+			return TransactionParams{}, err
+		}
+		reader.visited = true
+		if idx > 1 {
+			return reader.parseRow(row, idx)
+		}
 	}
-	row := rows[reader.idx]
-	return reader.parseRow(row, reader.idx)
 }
 
 func (reader *ExcelReader) ReadAll() ([]TransactionParams, error) {
-	rows := reader.fd.GetRows(reader.sheet)
-	if len(rows) < 1 {
-		return nil, errEmptyFileContent
-	}
-	var params []TransactionParams
-	for idx, row := range rows[1:] {
-		if param, err := reader.parseRow(row, idx); err == nil {
-			params = append(params, param)
-		}
-	}
-	return params, nil
+	return readAll(reader)
 }
 
+// parseRow parses the excel row into transaction params, idx is the row number in sheet.
 func (reader *ExcelReader) parseRow(row []string, idx int) (TransactionParams, error) {
+	return parseSheetRow(row, idx, "excel row")
+}
+
+// parseValueField parses the value field of batch file, which is either an
+// integer in wei or an expression evaluated at send time.
+func parseValueField(field string) (int64, string, error) {
+	if isMacroExpression(field) {
+		return 0, field, nil
+	}
+	value, err := strconv.ParseInt(field, 10, 64)
+	return value, "", err
+}
+
+// parseSheetRow parses the spreadsheet row into transaction params, idx is the
+// row number in sheet.
+func parseSheetRow(row []string, idx int, kind string) (TransactionParams, error) {
 	if len(row) < fieldNumber {
-		return TransactionParams{}, errInvalidContent
+		return TransactionParams{}, &ErrCorrupted{Pos: int64(idx), Kind: kind, Reason: errInvalidContent.Error()}
 	}
 	for i := 0; i < fieldNumber; i++ {
 		// Remove all leading and trailing blank char
 		row[i] = strings.Trim(row[i], " ")
 	}
-	value, err := strconv.ParseInt(row[2], 10, 64)
+	value, valueExpr, err := parseValueField(row[2])
 	if err != nil {
-		logger.Errorf("Corrupted raw text line at %d, invalid transfer value %s", idx, value)
-		return TransactionParams{}, err
+		return TransactionParams{}, &ErrCorrupted{Pos: int64(idx), Kind: kind, Reason: fmt.Sprintf("invalid transfer value %s", row[2])}
 	}
 	param := TransactionParams{
 		From:       common.HexToAddress(row[0]),
 		To:         common.HexToAddress(row[1]),
 		Value:      value,
+		ValueExpr:  valueExpr,
 		Data:       row[3],
 		Passphrase: row[4],
+		Row:        idx,
 	}
 	// Parse extra fields
 	if len(row) >= fieldNumber+1 {
@@ -160,31 +614,46 @@ func (reader *ExcelReader) parseRow(row []string, idx int) (TransactionParams, e
 	return param, nil
 }
 
+func (reader *ExcelReader) Close() error {
+	return reader.stream.close()
+}
+
+// ExcelWriter records the results into the sixth column of excel batch file.
+// Note, the workbook is loaded into memory when flushing, the journal is the
+// durable record before that.
 type ExcelWriter struct {
-	fd    *excelize.File
+	*journaledWriter
 	sheet string
-	idx   int
 }
 
 func NewExcelWriter(filename string, sheet string) (Writer, error) {
-	fd, err := excelize.OpenFile(filename)
+	if _, err := os.Stat(filename); err != nil {
+		return nil, err
+	}
+	writer := &ExcelWriter{sheet: sheet}
+	journaled, err := newJournaledWriter(filename, false, writer.apply)
 	if err != nil {
 		return nil, err
 	}
-	return &ExcelWriter{
-		fd:    fd,
-		sheet: sheet,
-		idx:   0,
-	}, nil
+	writer.journaledWriter = journaled
+	return writer, nil
 }
 
-func (writer *ExcelWriter) WriteString(axis string, value string) error {
-	writer.fd.SetCellValue(writer.sheet, axis, value)
-	return nil
+// Axis returns the result cell of given row, which is the sixth column.
+func (writer *ExcelWriter) Axis(row int) string {
+	return "F" + strconv.Itoa(row)
 }
 
-func (writer *ExcelWriter) Flush() error {
-	return writer.fd.Save()
+// apply sets the recorded results to the cells of workbook.
+func (writer *ExcelWriter) apply(filename string, cursor *journalCursor) error {
+	fd, err := excelize.OpenFile(filename)
+	if err != nil {
+		return err
+	}
+	for ; cursor.entry != nil; cursor.next() {
+		fd.SetCellValue(writer.sheet, cursor.entry.axis, cursor.entry.value)
+	}
+	return fd.Save()
 }
 
 type ExcelRWriter struct {
@@ -216,14 +685,565 @@ func (rw *ExcelRWriter) ReadAll() ([]TransactionParams, error) {
 	return rw.reader.ReadAll()
 }
 
+func (rw *ExcelRWriter) Axis(row int) string {
+	return rw.writer.Axis(row)
+}
+
 func (rw *ExcelRWriter) WriteString(axis string, value string) error {
 	return rw.writer.WriteString(axis, value)
 }
 
+func (rw *ExcelRWriter) WriteCalled(axis string, value string) error {
+	return rw.writer.WriteCalled(axis, value)
+}
+
+func (rw *ExcelRWriter) Recover() error {
+	return rw.writer.Recover()
+}
+
 func (rw *ExcelRWriter) Flush() error {
 	return rw.writer.Flush()
 }
 
+func (rw *ExcelRWriter) Close() error {
+	rw.reader.Close()
+	return rw.writer.Close()
+}
+
+/*
+	ODS Reader
+*/
+
+// ODSReader a reader to read OpenDocument spreadsheet batch file. The row
+// semantics are same with ExcelReader, the first row of sheet is treated as
+// header and skipped.
+type ODSReader struct {
+	stream  *odsSheetReader
+	visited bool
+}
+
+func NewODSReader(filename string, sheet string) (Reader, error) {
+	stream, err := openODSSheet(filename, sheet)
+	if err != nil {
+		return nil, err
+	}
+	return &ODSReader{
+		stream: stream,
+	}, nil
+}
+
+// Read reads the next non-empty row in the sheet.
+func (reader *ODSReader) Read() (TransactionParams, error) {
+	for {
+		idx, row, err := reader.stream.next()
+		if err == io.EOF && !reader.visited {
+			return TransactionParams{}, errEmptyFileContent
+		}
+		if err != nil {
+			return TransactionParams{}, err
+		}
+		reader.visited = true
+		if idx > 1 {
+			return parseSheetRow(row, idx, "ods row")
+		}
+	}
+}
+
+func (reader *ODSReader) ReadAll() ([]TransactionParams, error) {
+	return readAll(reader)
+}
+
+func (reader *ODSReader) Close() error {
+	return reader.stream.close()
+}
+
+// ODSWriter records the results into the sixth column of OpenDocument
+// spreadsheet batch file. Only the rows with results are re-encoded.
+// Note, the content of document is loaded into memory when flushing, the
+// journal is the durable record before that.
+type ODSWriter struct {
+	*journaledWriter
+	sheet string
+}
+
+func NewODSWriter(filename string, sheet string) (Writer, error) {
+	if _, err := os.Stat(filename); err != nil {
+		return nil, err
+	}
+	writer := &ODSWriter{sheet: sheet}
+	journaled, err := newJournaledWriter(filename, false, writer.apply)
+	if err != nil {
+		return nil, err
+	}
+	writer.journaledWriter = journaled
+	return writer, nil
+}
+
+// Axis returns the result cell of given row, which is the sixth column.
+func (writer *ODSWriter) Axis(row int) string {
+	return "F" + strconv.Itoa(row)
+}
+
+// apply sets the recorded results to the cells of document.
+func (writer *ODSWriter) apply(filename string, cursor *journalCursor) error {
+	targets := make(map[int]string)
+	for ; cursor.entry != nil; cursor.next() {
+		row, err := strconv.Atoi(strings.TrimLeft(cursor.entry.axis, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+		if err != nil || xlsxColumnIndex(cursor.entry.axis) != fieldNumber {
+			return errCorruptedJournal
+		}
+		targets[row] = cursor.entry.value
+	}
+	if err := rewriteODS(filename, writer.sheet, fieldNumber, targets); err != nil {
+		return err
+	}
+	if len(targets) > 0 {
+		return errRowIndexExceed
+	}
+	return nil
+}
+
+type ODSRWriter struct {
+	writer Writer
+	reader Reader
+}
+
+func NewODSRWriter(filename string, sheet string) (RWriter, error) {
+	writer, err := NewODSWriter(filename, sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := NewODSReader(filename, sheet)
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+	return &ODSRWriter{
+		writer: writer,
+		reader: reader,
+	}, nil
+}
+
+func (rw *ODSRWriter) Read() (TransactionParams, error) {
+	return rw.reader.Read()
+}
+
+func (rw *ODSRWriter) ReadAll() ([]TransactionParams, error) {
+	return rw.reader.ReadAll()
+}
+
+func (rw *ODSRWriter) Axis(row int) string {
+	return rw.writer.Axis(row)
+}
+
+func (rw *ODSRWriter) WriteString(axis string, value string) error {
+	return rw.writer.WriteString(axis, value)
+}
+
+func (rw *ODSRWriter) WriteCalled(axis string, value string) error {
+	return rw.writer.WriteCalled(axis, value)
+}
+
+func (rw *ODSRWriter) Recover() error {
+	return rw.writer.Recover()
+}
+
+func (rw *ODSRWriter) Flush() error {
+	return rw.writer.Flush()
+}
+
+func (rw *ODSRWriter) Close() error {
+	rw.reader.Close()
+	return rw.writer.Close()
+}
+
+/*
+	CSV Reader
+*/
+
+// csvColumns is the default column layout of csv batch file without header.
+var csvColumns = []string{"from", "to", "value", "data", "passphrase", "hash"}
+
+// csvRecord is a single record in csv batch file with its position.
+type csvRecord struct {
+	fields []string
+	line   int   // line number where the record starts
+	end    int64 // byte offset right after the record
+}
+
+// newCSVParser returns a csv reader follows RFC 4180 quoting rules, with
+// extra support for `#` comment lines and fields with variable number.
+func newCSVParser(r io.Reader) *csv.Reader {
+	parser := csv.NewReader(r)
+	parser.Comment = '#'
+	parser.FieldsPerRecord = -1
+	parser.TrimLeadingSpace = true
+	return parser
+}
+
+// nextCSVRecord reads the next csv record and its position.
+func nextCSVRecord(parser *csv.Reader) (*csvRecord, error) {
+	fields, err := parser.Read()
+	if err != nil {
+		return nil, err
+	}
+	line, _ := parser.FieldPos(0)
+	return &csvRecord{
+		fields: fields,
+		line:   line,
+		end:    parser.InputOffset(),
+	}, nil
+}
+
+// isCSVHeader reports whether the given record is a header row. A header only
+// contains the known column names, so a data row with malformed content is still
+// treated as a record and reported as corrupted.
+func isCSVHeader(fields []string) bool {
+	named := false
+	for _, field := range fields {
+		name := strings.ToLower(strings.TrimSpace(field))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, column := range csvColumns {
+			if name == column {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return false
+		}
+		named = true
+	}
+	return named
+}
+
+// CSVReader a reader to read csv batch file.
+// Note, csv batch file has an optional header row. If the header is specified,
+// columns are matched by name(from, to, value, data, passphrase, hash), otherwise
+// the column order is same with raw text file.
+// Lines start with `#` are treated as comments and blank lines are ignored.
+type CSVReader struct {
+	fd      *os.File
+	parser  *csv.Reader
+	columns map[string]int
+	started bool
+}
+
+func NewCSVReader(filename string) (Reader, error) {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &CSVReader{
+		fd:     fd,
+		parser: newCSVParser(bufio.NewReader(fd)),
+	}, nil
+}
+
+// Read reads the next record in csv file and parse it into transaction params.
+func (reader *CSVReader) Read() (TransactionParams, error) {
+	record, err := nextCSVRecord(reader.parser)
+	if err != nil {
+		return TransactionParams{}, err
+	}
+	if !reader.started {
+		reader.started = true
+		reader.columns = make(map[string]int)
+		if isCSVHeader(record.fields) {
+			for idx, name := range record.fields {
+				reader.columns[strings.ToLower(strings.TrimSpace(name))] = idx
+			}
+			if record, err = nextCSVRecord(reader.parser); err != nil {
+				return TransactionParams{}, err
+			}
+		} else {
+			for idx, name := range csvColumns {
+				reader.columns[name] = idx
+			}
+		}
+	}
+	return reader.parseRecord(record)
+}
+
+// ReadAll reads all records in csv file. Records with invalid content are skipped,
+// while malformed csv file is treated as error.
+func (reader *CSVReader) ReadAll() ([]TransactionParams, error) {
+	return readAll(reader)
+}
+
+func (reader *CSVReader) parseRecord(record *csvRecord) (TransactionParams, error) {
+	field := func(name string) string {
+		idx, exist := reader.columns[name]
+		if !exist || idx >= len(record.fields) {
+			return ""
+		}
+		return strings.TrimSpace(record.fields[idx])
+	}
+	if !common.IsHexAddress(field("from")) {
+		return TransactionParams{}, &ErrCorrupted{Pos: int64(record.line), Kind: "csv row", Reason: fmt.Sprintf("invalid sender %s", field("from"))}
+	}
+	value, valueExpr, err := parseValueField(field("value"))
+	if err != nil {
+		return TransactionParams{}, &ErrCorrupted{Pos: int64(record.line), Kind: "csv row", Reason: fmt.Sprintf("invalid transfer value %s", field("value"))}
+	}
+	param := TransactionParams{
+		From:       common.HexToAddress(field("from")),
+		To:         common.HexToAddress(field("to")),
+		Value:      value,
+		ValueExpr:  valueExpr,
+		Data:       field("data"),
+		Passphrase: field("passphrase"),
+		Row:        record.line,
+	}
+	if hash := field("hash"); hash != "" {
+		param.Hash = common.HexToHash(hash)
+	}
+	return param, nil
+}
+
+func (reader *CSVReader) Close() error {
+	return reader.fd.Close()
+}
+
+// CSVWriter records transaction results into the hash column of csv batch file.
+// Records are addressed by the line number where they start, all other content
+// like comments are kept untouched.
+type CSVWriter struct {
+	*journaledWriter
+	header  *csvRecord
+	hashCol int
+	lines   []int // sorted line numbers of all records
+}
+
+func NewCSVWriter(filename string) (Writer, error) {
+	writer := &CSVWriter{hashCol: fieldNumber}
+	if err := writer.index(filename); err != nil {
+		return nil, err
+	}
+	journaled, err := newJournaledWriter(filename, true, writer.apply)
+	if err != nil {
+		return nil, err
+	}
+	writer.journaledWriter = journaled
+	return writer, nil
+}
+
+// index resolves the header and the line numbers of all records in csv file.
+func (writer *CSVWriter) index(filename string) error {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	parser := newCSVParser(bufio.NewReader(fd))
+	for first := true; ; first = false {
+		record, err := nextCSVRecord(parser)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if first && isCSVHeader(record.fields) {
+			writer.header = record
+			writer.hashCol = len(record.fields)
+			for idx, name := range record.fields {
+				if strings.ToLower(strings.TrimSpace(name)) == "hash" {
+					writer.hashCol = idx
+				}
+			}
+			continue
+		}
+		writer.lines = append(writer.lines, record.line)
+	}
+}
+
+// Axis returns the line number of given row, csv rows are already addressed by line.
+func (writer *CSVWriter) Axis(row int) string {
+	return strconv.Itoa(row)
+}
+
+// WriteString writes the value to the hash column of the record starts at specific line.
+// Using string as the index is due to interface uniform.
+func (writer *CSVWriter) WriteString(s string, value string) error {
+	if err := writer.check(s); err != nil {
+		return err
+	}
+	return writer.journaledWriter.WriteString(s, value)
+}
+
+// WriteCalled writes the result of read-only macro to the hash column of the
+// record starts at specific line.
+func (writer *CSVWriter) WriteCalled(s string, value string) error {
+	if err := writer.check(s); err != nil {
+		return err
+	}
+	return writer.journaledWriter.WriteCalled(s, value)
+}
+
+// check checks whether a record starts at the given line.
+func (writer *CSVWriter) check(s string) error {
+	line, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	if idx := sort.SearchInts(writer.lines, line); idx == len(writer.lines) || writer.lines[idx] != line {
+		return errRowIndexExceed
+	}
+	return nil
+}
+
+// apply merges the recorded results into the csv file. Only the records with
+// results are re-encoded, other content is copied as it is.
+func (writer *CSVWriter) apply(filename string, cursor *journalCursor) error {
+	src, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	var (
+		parser = newCSVParser(bufio.NewReader(src))
+		raw    = bufio.NewReader(fd)
+		line   = 1 // line number of the raw reader
+		offset int64
+	)
+	return rewriteFile(filename, func(w *bufio.Writer) error {
+		for {
+			record, err := nextCSVRecord(parser)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			var fields []string
+			if record.line == writer.headerLine() {
+				if writer.hashCol == len(record.fields) && cursor.entry != nil {
+					fields = append(append(fields, record.fields...), "hash")
+				}
+			} else if entry := cursor.match(record.line); entry != nil {
+				fields = append(fields, record.fields...)
+				for len(fields) <= writer.hashCol {
+					fields = append(fields, "")
+				}
+				fields[writer.hashCol] = entry.value
+			}
+			if fields == nil {
+				continue
+			}
+			// Keep the content before the record, then replace the record with
+			// the modified one.
+			for line < record.line {
+				s, err := raw.ReadString('\n')
+				if err != nil {
+					return err
+				}
+				w.WriteString(s)
+				offset += int64(len(s))
+				line += 1
+			}
+			original := make([]byte, record.end-offset)
+			if _, err := io.ReadFull(raw, original); err != nil {
+				return err
+			}
+			offset = record.end
+			line += bytes.Count(original, []byte("\n"))
+
+			encoder := csv.NewWriter(w)
+			encoder.UseCRLF = bytes.HasSuffix(original, []byte("\r\n"))
+			if bytes.HasSuffix(original, []byte("\n")) {
+				encoder.Write(fields)
+				encoder.Flush()
+			} else {
+				// The last record has no line terminator
+				var buf bytes.Buffer
+				encoder = csv.NewWriter(&buf)
+				encoder.Write(fields)
+				encoder.Flush()
+				w.Write(bytes.TrimRight(buf.Bytes(), "\r\n"))
+			}
+			if err := encoder.Error(); err != nil {
+				return err
+			}
+		}
+		_, err := io.Copy(w, raw)
+		return err
+	})
+}
+
+// headerLine returns the line number of header, 0 means no header.
+func (writer *CSVWriter) headerLine() int {
+	if writer.header == nil {
+		return 0
+	}
+	return writer.header.line
+}
+
+type CSVRWriter struct {
+	reader Reader
+	writer Writer
+}
+
+func NewCSVRWriter(filename string) (RWriter, error) {
+	writer, err := NewCSVWriter(filename)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := NewCSVReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &CSVRWriter{
+		reader: reader,
+		writer: writer,
+	}, nil
+}
+
+func (rw *CSVRWriter) Read() (TransactionParams, error) {
+	return rw.reader.Read()
+}
+
+func (rw *CSVRWriter) ReadAll() ([]TransactionParams, error) {
+	return rw.reader.ReadAll()
+}
+
+func (rw *CSVRWriter) Axis(row int) string {
+	return rw.writer.Axis(row)
+}
+
+func (rw *CSVRWriter) WriteString(axis string, value string) error {
+	return rw.writer.WriteString(axis, value)
+}
+
+func (rw *CSVRWriter) WriteCalled(axis string, value string) error {
+	return rw.writer.WriteCalled(axis, value)
+}
+
+func (rw *CSVRWriter) Recover() error {
+	return rw.writer.Recover()
+}
+
+func (rw *CSVRWriter) Flush() error {
+	return rw.writer.Flush()
+}
+
+func (rw *CSVRWriter) Close() error {
+	rw.reader.Close()
+	return rw.writer.Close()
+}
+
 /*
 	Raw Text Reader
 */
@@ -234,6 +1254,7 @@ func (rw *ExcelRWriter) Flush() error {
 type RawTextReader struct {
 	fd      *os.File
 	scanner *bufio.Scanner
+	idx     int
 }
 
 func NewRawTextReader(filename string) (Reader, error) {
@@ -254,7 +1275,8 @@ func (reader *RawTextReader) Read() (TransactionParams, error) {
 		return TransactionParams{}, errEmptycanner
 	}
 	if reader.scanner.Scan() {
-		return reader.parseLine(reader.scanner.Text(), 0)
+		reader.idx += 1
+		return reader.parseLine(reader.scanner.Text(), reader.idx-1)
 	} else {
 		if err := reader.scanner.Err(); err != nil {
 			return TransactionParams{}, err
@@ -269,44 +1291,30 @@ func (reader *RawTextReader) ReadAll() ([]TransactionParams, error) {
 	if reader.scanner == nil {
 		return nil, errEmptycanner
 	}
-	var (
-		idx    = 0
-		params = []TransactionParams{}
-	)
-	for reader.scanner.Scan() {
-		p, err := reader.parseLine(reader.scanner.Text(), idx)
-		if err == nil {
-			params = append(params, p)
-		}
-		idx += 1
-	}
-	if err := reader.scanner.Err(); err != nil {
-		return nil, err
-	}
-	return params, nil
+	return readAll(reader)
 }
 
 func (reader *RawTextReader) parseLine(line string, idx int) (TransactionParams, error) {
 	substr := strings.Split(line, ",")
 	if len(substr) < fieldNumber {
-		logger.Errorf("Corrupted raw text line at %d", idx)
-		return TransactionParams{}, errInvalidContent
+		return TransactionParams{}, &ErrCorrupted{Pos: int64(idx), Kind: "raw text line", Reason: errInvalidContent.Error()}
 	}
 	for i := 0; i < fieldNumber; i++ {
 		// Remove all leading and trailing blank char
 		substr[i] = strings.Trim(substr[i], " ")
 	}
-	value, err := strconv.ParseInt(substr[2], 10, 64)
+	value, valueExpr, err := parseValueField(substr[2])
 	if err != nil {
-		logger.Errorf("Corrupted raw text line at %d, invalid transfer value %s", idx, value)
-		return TransactionParams{}, err
+		return TransactionParams{}, &ErrCorrupted{Pos: int64(idx), Kind: "raw text line", Reason: fmt.Sprintf("invalid transfer value %s", substr[2])}
 	}
 	param := TransactionParams{
 		From:       common.HexToAddress(substr[0]),
 		To:         common.HexToAddress(substr[1]),
 		Value:      value,
+		ValueExpr:  valueExpr,
 		Data:       substr[3],
 		Passphrase: substr[4],
+		Row:        idx,
 	}
 	// Parse extra fields
 	if len(substr) >= fieldNumber+1 {
@@ -315,46 +1323,121 @@ func (reader *RawTextReader) parseLine(line string, idx int) (TransactionParams,
 	return param, nil
 }
 
+func (reader *RawTextReader) Close() error {
+	return reader.fd.Close()
+}
+
+// RawTextWriter writes the results to the sixth field of lines in raw text file.
 type RawTextWriter struct {
-	fd    *os.File
-	lines []string
+	*journaledWriter
+	lines int // total line number
 }
 
 func NewRawTextWriter(filename string) (Writer, error) {
-	fd, err := os.Open(filename)
-	if err != nil {
+	writer := &RawTextWriter{}
+	if err := writer.count(filename); err != nil {
 		return nil, err
 	}
-	content, err := ioutil.ReadFile(filename)
+	journaled, err := newJournaledWriter(filename, true, writer.apply)
 	if err != nil {
 		return nil, err
 	}
-	lines := strings.Split(string(content), "\n")
-	return &RawTextWriter{
-		fd:    fd,
-		lines: lines,
-	}, nil
+	writer.journaledWriter = journaled
+	return writer, nil
+}
+
+// count counts the lines in raw text file.
+func (writer *RawTextWriter) count(filename string) error {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	writer.lines = 1
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := fd.Read(buf)
+		writer.lines += bytes.Count(buf[:n], []byte("\n"))
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Axis returns the line index of given row.
+func (writer *RawTextWriter) Axis(row int) string {
+	return strconv.Itoa(row)
 }
 
 // WriteString writes the value to specific line.
 // Using string as the index is due to interface uniform.
 func (writer *RawTextWriter) WriteString(s string, value string) error {
+	if err := writer.check(s); err != nil {
+		return err
+	}
+	return writer.journaledWriter.WriteString(s, value)
+}
+
+// WriteCalled writes the result of read-only macro to specific line.
+func (writer *RawTextWriter) WriteCalled(s string, value string) error {
+	if err := writer.check(s); err != nil {
+		return err
+	}
+	return writer.journaledWriter.WriteCalled(s, value)
+}
+
+// check checks whether the line index is in the range.
+func (writer *RawTextWriter) check(s string) error {
 	idx, err := strconv.Atoi(s)
 	if err != nil {
 		return err
 	}
-	if idx < 0 || idx >= len(writer.lines) {
+	if idx < 0 || idx >= writer.lines {
 		return errRowIndexExceed
 	}
-	line := writer.lines[idx]
-	line += fmt.Sprintf(", %s", value)
-	writer.lines[idx] = line
 	return nil
 }
 
-func (writer *RawTextWriter) Flush() error {
-	out := strings.Join(writer.lines, "\n")
-	return ioutil.WriteFile(writer.fd.Name(), []byte(out), 0644)
+// apply merges the recorded results into the raw text file line by line.
+func (writer *RawTextWriter) apply(filename string, cursor *journalCursor) error {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	reader := bufio.NewReader(fd)
+	return rewriteFile(filename, func(w *bufio.Writer) error {
+		for idx := 0; ; idx++ {
+			line, err := reader.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if entry := cursor.match(idx); entry != nil {
+				content := strings.TrimRight(line, "\r\n")
+				line = setRawTextResult(content, entry.value) + line[len(content):]
+			}
+			w.WriteString(line)
+			if err == io.EOF {
+				return nil
+			}
+		}
+	})
+}
+
+// setRawTextResult sets the result field of the line. The existent result
+// is replaced instead of stacking another one, so the batch can be rerun.
+func setRawTextResult(line string, value string) string {
+	fields := strings.Split(line, ",")
+	if len(fields) <= fieldNumber {
+		return line + fmt.Sprintf(", %s", value)
+	}
+	fields[fieldNumber] = " " + value
+	return strings.Join(fields, ",")
 }
 
 type RawTextRWriter struct {
@@ -385,10 +1468,27 @@ func (rw *RawTextRWriter) ReadAll() ([]TransactionParams, error) {
 	return rw.reader.ReadAll()
 }
 
+func (rw *RawTextRWriter) Axis(row int) string {
+	return rw.writer.Axis(row)
+}
+
 func (rw *RawTextRWriter) WriteString(axis string, value string) error {
 	return rw.writer.WriteString(axis, value)
 }
 
+func (rw *RawTextRWriter) WriteCalled(axis string, value string) error {
+	return rw.writer.WriteCalled(axis, value)
+}
+
+func (rw *RawTextRWriter) Recover() error {
+	return rw.writer.Recover()
+}
+
 func (rw *RawTextRWriter) Flush() error {
 	return rw.writer.Flush()
 }
+
+func (rw *RawTextRWriter) Close() error {
+	rw.reader.Close()
+	return rw.writer.Close()
+}
@@ -15,8 +15,19 @@
 package main
 
 import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
 	"path"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func ExampleRTReaderRead() {
@@ -90,3 +101,705 @@ func ExampleExcelReaderReadAll() {
 	// 0x7Cd6342b4b02A90bcf60F1f843d1002897e38b1f
 	// 0xfFc1736f670f305A3d752280d07F6895379cbD70
 }
+
+func ExampleODSReader_ReadAll() {
+	reader, err := NewODSReader(path.Join("test", "batch.ods"), DefaultSheet)
+	if err != nil {
+		return
+	}
+	params, err := reader.ReadAll()
+	if err != nil {
+		return
+	}
+	for _, param := range params {
+		fmt.Println(param.Row, param.From.Hex(), param.Value, param.Passphrase)
+	}
+	// Output:
+	// 2 0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e 10000000000000 @treasury
+	// 3 0x7Cd6342b4b02A90bcf60F1f843d1002897e38b1f 10000000000 hello  world
+	// 4 0xfFc1736f670f305A3d752280d07F6895379cbD70 1000000000000 env:PASSPHRASE
+	// 5 0xfFc1736f670f305A3d752280d07F6895379cbD70 1000000000000 env:PASSPHRASE
+	// 7 0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e 1000 @treasury
+}
+
+func ExampleJSONReader_ReadAll() {
+	reader, err := NewJSONReader(path.Join("test", "batch.json"))
+	if err != nil {
+		return
+	}
+	params, err := reader.ReadAll()
+	if err != nil {
+		return
+	}
+	for _, param := range params {
+		fmt.Println(param.From.Hex(), param.Data)
+	}
+	// Output:
+	// 0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e 0x123456
+	// 0x7Cd6342b4b02A90bcf60F1f843d1002897e38b1f #TRANSFER EOS 200
+	// 0xfFc1736f670f305A3d752280d07F6895379cbD70 0x123456
+}
+
+func ExampleJSONLReader_ReadAll() {
+	reader, err := NewJSONLReader(path.Join("test", "batch.jsonl"))
+	if err != nil {
+		return
+	}
+	params, err := reader.ReadAll()
+	if err != nil {
+		return
+	}
+	for _, param := range params {
+		fmt.Println(param.From.Hex(), param.Data)
+	}
+	// Output:
+	// 0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e 0x123456
+	// 0x7Cd6342b4b02A90bcf60F1f843d1002897e38b1f #TRANSFER EOS 200
+	// 0xfFc1736f670f305A3d752280d07F6895379cbD70 0x123456
+}
+
+func TestJSONRWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	hash := common.HexToHash("0x0a972ae96dc50ef4701e21bc6fe25389801286a96decee9a1ffb49a78b640954")
+	for _, fname := range []string{"batch.json", "batch.jsonl"} {
+		content, err := ioutil.ReadFile(path.Join("test", fname))
+		if err != nil {
+			t.Fatal(err)
+		}
+		batchfile := path.Join(dir, fname)
+		if err := ioutil.WriteFile(batchfile, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		newRW := NewJSONRWriter
+		if fname == "batch.jsonl" {
+			newRW = NewJSONLRWriter
+		}
+		rw, err := newRW(batchfile)
+		if err != nil {
+			t.Fatalf("%s: %v", fname, err)
+		}
+		if err := rw.WriteString("1", hash.Hex()); err != nil {
+			t.Fatalf("%s: %v", fname, err)
+		}
+		if err := rw.WriteString("3", hash.Hex()); err != errRowIndexExceed {
+			t.Errorf("%s: error mismatch, want %v, got %v", fname, errRowIndexExceed, err)
+		}
+		if err := rw.Flush(); err != nil {
+			t.Fatalf("%s: %v", fname, err)
+		}
+		// Reopen the file and check the recorded result
+		rw, err = newRW(batchfile)
+		if err != nil {
+			t.Fatalf("%s: %v", fname, err)
+		}
+		params, err := rw.ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v", fname, err)
+		}
+		if len(params) != 3 {
+			t.Fatalf("%s: entry number mismatch, want 3, got %d", fname, len(params))
+		}
+		for idx, param := range params {
+			if want := idx == 1; param.Status != want || (param.Hash == hash) != want {
+				t.Errorf("%s: entry %d result mismatch, status %v hash %s", fname, idx, param.Status, param.Hash.Hex())
+			}
+		}
+		if params[1].Data != "#TRANSFER EOS 200" || params[2].Passphrase != "helloworld" {
+			t.Errorf("%s: entry content corrupted after flush", fname)
+		}
+	}
+}
+
+func TestJSONValueExpression(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := `{"from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","value":5,"data":""}
+{"from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","value":"7","data":""}
+{"from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","value":"$ETHBALANCE - 0.01ether","data":""}
+`
+	batchfile := path.Join(dir, "batch.jsonl")
+	if err := ioutil.WriteFile(batchfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	check := func(params []TransactionParams) {
+		if len(params) != 3 {
+			t.Fatalf("entry number mismatch, want 3, got %d", len(params))
+		}
+		if params[0].Value != 5 || params[0].ValueExpr != "" {
+			t.Errorf("entry 0 value mismatch, got %d %q", params[0].Value, params[0].ValueExpr)
+		}
+		if params[1].Value != 7 || params[1].ValueExpr != "" {
+			t.Errorf("entry 1 value mismatch, got %d %q", params[1].Value, params[1].ValueExpr)
+		}
+		if params[2].Value != 0 || params[2].ValueExpr != "$ETHBALANCE - 0.01ether" {
+			t.Errorf("entry 2 value mismatch, got %d %q", params[2].Value, params[2].ValueExpr)
+		}
+	}
+	rw, err := NewJSONLRWriter(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := rw.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(params)
+
+	// The expression survives the result written back
+	if err := rw.WriteString("2", "0x01"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rw.Close()
+	rw, err = NewJSONLRWriter(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+	if params, err = rw.ReadAll(); err != nil {
+		t.Fatal(err)
+	}
+	check(params)
+	if params[2].Hash != common.HexToHash("0x01") {
+		t.Errorf("result mismatch, got %s", params[2].Hash.Hex())
+	}
+
+	var param TransactionParams
+	if err := json.Unmarshal([]byte(`{"value":"1.5"}`), &param); err == nil {
+		t.Errorf("expected error for invalid value")
+	}
+}
+
+func TestJSONCorruptedObject(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	batchfile := path.Join(dir, "batch.json")
+	content := `[
+  {"from": "0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e", "to": "0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf", "value": 1},
+  {"from": 123, "to": "0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf", "value": 2},
+  {"from": "0x7Cd6342b4b02A90bcf60F1f843d1002897e38b1f", "to": "0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf", "value": 3}
+]`
+	if err := ioutil.WriteFile(batchfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := NewJSONReader(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	// The object with invalid field is reported as corrupted and skipped
+	for idx, want := range []int64{0, 1, 2} {
+		param, err := reader.Read()
+		if idx == 1 {
+			if corrupted, ok := err.(*ErrCorrupted); !ok || corrupted.Pos != 1 {
+				t.Fatalf("error mismatch, want corrupted object 1, got %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if param.Row != int(want) || param.Value != want+1 {
+			t.Errorf("object %d mismatch: %+v", idx, param)
+		}
+	}
+	// The malformed json is still a fatal error
+	if err := ioutil.WriteFile(batchfile, []byte(content[:len(content)/2]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	truncated, err := NewJSONReader(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer truncated.Close()
+	if _, err := truncated.ReadAll(); err == nil {
+		t.Error("expect error for malformed json")
+	}
+}
+
+func ExampleCSVReader_ReadAll() {
+	reader, err := NewCSVReader(path.Join("test", "batch.csv"))
+	if err != nil {
+		return
+	}
+	params, err := reader.ReadAll()
+	if err != nil {
+		return
+	}
+	for _, param := range params {
+		fmt.Printf("%d %s %s|%s\n", param.Row, param.From.Hex(), param.Data, param.Passphrase)
+	}
+	// Output:
+	// 3 0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e #CALL 0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf "vote(uint256,bool)" 1 true|helloworld
+	// 6 0x7Cd6342b4b02A90bcf60F1f843d1002897e38b1f 0x123456|hello, world
+	// 7 0xfFc1736f670f305A3d752280d07F6895379cbD70 #TRANSFER EOS 200|helloworld
+}
+
+func TestCSVRWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content, err := ioutil.ReadFile(path.Join("test", "batch.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batchfile := path.Join(dir, "batch.csv")
+	if err := ioutil.WriteFile(batchfile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rw, err := NewCSVRWriter(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.WriteString("6", "0x01"); err != nil {
+		t.Fatal(err)
+	}
+	// Comment and blank lines are not addressable
+	for _, axis := range []string{"1", "4", "5", "8"} {
+		if err := rw.WriteString(axis, "0x02"); err != errRowIndexExceed {
+			t.Errorf("line %s: error mismatch, want %v, got %v", axis, errRowIndexExceed, err)
+		}
+	}
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Airdrop batch, columns are matched by the header\r\n" +
+		"from,to,value,data,passphrase,hash\r\n" +
+		"0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615e,0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf,100,\"#CALL 0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf \"\"vote(uint256,bool)\"\" 1 true\",helloworld\r\n" +
+		"\r\n" +
+		"# The second transaction is paid by the same account\r\n" +
+		"0x7Cd6342b4b02A90bcf60F1f843d1002897e38b1f,0x65F28650F3c9721580b81c6d552999270EffFCb1,100,0x123456,\"hello, world\",0x01\r\n" +
+		"0xfFc1736f670f305A3d752280d07F6895379cbD70,0x2C77c0eb01005f8abca57C7c94732A642F825bd8,100,\"#TRANSFER EOS 200\",helloworld\r\n"
+	got, err := ioutil.ReadFile(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("file content mismatch\nwant:\n%q\ngot:\n%q", want, got)
+	}
+	// The record is still addressable with the same row number
+	reader, err := NewCSVReader(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 3 || params[1].Row != 6 || params[1].Hash != common.HexToHash("0x01") {
+		t.Errorf("result mismatch after flush: %+v", params)
+	}
+}
+
+func TestCSVMalformedFirstRow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	batchfile := path.Join(dir, "batch.csv")
+	content := "0x7236Bc5a9Ff647D48b1eceaa07aa6438dCca615,0x168f70A4b92E630b31Ab887Fb7956ddB7C3813cf,100\n" +
+		"0x7Cd6342b4b02A90bcf60F1f843d1002897e38b1f,0x65F28650F3c9721580b81c6d552999270EffFCb1,100\n"
+	if err := ioutil.WriteFile(batchfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := NewCSVReader(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	// The malformed first row must be reported instead of dropped as a header
+	if _, err := reader.Read(); err == nil {
+		t.Fatal("expect error for malformed first row")
+	} else if corrupted, ok := err.(*ErrCorrupted); !ok || corrupted.Pos != 1 {
+		t.Fatalf("error mismatch, want corrupted row 1, got %v", err)
+	}
+	param, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if param.Row != 2 || param.From != common.HexToAddress("0x7Cd6342b4b02A90bcf60F1f843d1002897e38b1f") {
+		t.Errorf("record mismatch: %+v", param)
+	}
+	// The remaining row is still addressable by the writer
+	rw, err := NewCSVRWriter(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.WriteString("1", "0x01"); err != nil {
+		t.Errorf("malformed row not addressable: %v", err)
+	}
+}
+
+func TestExcelRWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-excel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content, err := ioutil.ReadFile(path.Join("test", "excel.xlsx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batchfile := path.Join(dir, "excel.xlsx")
+	if err := ioutil.WriteFile(batchfile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rw, err := NewExcelRWriter(batchfile, DefaultSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []int
+	for {
+		param, err := rw.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, param.Row)
+		if err := rw.WriteString(rw.Axis(param.Row), fmt.Sprintf("0x%02d", param.Row)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(rows) != 3 || rows[0] != 2 || rows[2] != 4 {
+		t.Fatalf("row number mismatch: %v", rows)
+	}
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rw.Close()
+
+	reader, err := NewExcelReader(batchfile, DefaultSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	params, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, param := range params {
+		if param.Hash != common.HexToHash(fmt.Sprintf("0x%02d", param.Row)) {
+			t.Errorf("row %d: result mismatch, got %s", param.Row, param.Hash.Hex())
+		}
+	}
+	if _, err := NewExcelReader(batchfile, "Sheet2"); err == nil {
+		t.Error("expect error for non-existent sheet")
+	}
+}
+
+func TestODSRWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-ods")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content, err := ioutil.ReadFile(path.Join("test", "batch.ods"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batchfile := path.Join(dir, "batch.ods")
+	if err := ioutil.WriteFile(batchfile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rw, err := NewODSRWriter(batchfile, DefaultSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin, err := rw.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write the results of repeated row and the row with existent result
+	for _, row := range []int{2, 5, 7} {
+		if err := rw.WriteString(rw.Axis(row), fmt.Sprintf("0x%02d", row)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rw.Close()
+
+	reader, err := NewODSReader(batchfile, DefaultSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	params, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != len(origin) {
+		t.Fatalf("row number mismatch, want %d, got %d", len(origin), len(params))
+	}
+	for i, param := range params {
+		want := origin[i]
+		switch param.Row {
+		case 2, 5, 7:
+			want.Hash = common.HexToHash(fmt.Sprintf("0x%02d", param.Row))
+		}
+		if param != want {
+			t.Errorf("row %d: mismatch, want %v, got %v", param.Row, want, param)
+		}
+	}
+	// The other sheet is left untouched
+	other, err := NewODSReader(batchfile, "Transfers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if params, err := other.ReadAll(); err != nil || len(params) != 1 || params[0].Data != "#TRANSFER EOS 1" {
+		t.Errorf("other sheet mismatch, got %v, %v", params, err)
+	}
+	// The mimetype entry is still the first and uncompressed one
+	zr, err := zip.OpenReader(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	if file := zr.File[0]; file.Name != "mimetype" || file.Method != zip.Store {
+		t.Errorf("mimetype entry mismatch, got %s", file.Name)
+	}
+	if _, err := NewODSReader(batchfile, "Sheet2"); err == nil {
+		t.Error("expect error for non-existent sheet")
+	}
+}
+
+func TestJournalRecovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content, err := ioutil.ReadFile(path.Join("test", "raw_text"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batchfile := path.Join(dir, "raw_text")
+	if err := ioutil.WriteFile(batchfile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rw, err := NewRawTextRWriter(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.WriteString("1", "0x01"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rw.WriteString("0", "0x00"); err != errRowOrder {
+		t.Errorf("error mismatch, want %v, got %v", errRowOrder, err)
+	}
+	if err := rw.WriteString("2", "0x02"); err != nil {
+		t.Fatal(err)
+	}
+	// Interrupt the sending without flushing, the last entry is half written
+	rw.Close()
+	fd, err := os.OpenFile(batchfile+journalSuffix, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("journal is missing: %v", err)
+	}
+	fd.WriteString("3\t\"0x")
+	fd.Close()
+
+	if got, _ := ioutil.ReadFile(batchfile); string(got) != string(content) {
+		t.Fatal("batch file is modified before flushing")
+	}
+	// Opening the batch file alone must not apply the journal
+	rw, err = NewRawTextRWriter(batchfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+	if got, _ := ioutil.ReadFile(batchfile); string(got) != string(content) {
+		t.Fatal("batch file is modified before recovery")
+	}
+	// Results should be recovered on request, e.g. in inplace mode
+	if err := rw.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(batchfile + journalSuffix); !os.IsNotExist(err) {
+		t.Error("journal is not removed after recovery")
+	}
+	want := strings.Split(string(content), "\n")
+	want[1] += ", 0x01"
+	want[2] += ", 0x02"
+	if got, _ := ioutil.ReadFile(batchfile); string(got) != strings.Join(want, "\n") {
+		t.Errorf("file content mismatch, got\n%s", got)
+	}
+}
+
+// generateBatchFile generates a batch file in given format with specified rows.
+func generateBatchFile(dir string, format string, rows int) (string, error) {
+	filename := path.Join(dir, "batch."+format)
+	fd, err := os.Create(filename)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+
+	w := bufio.NewWriter(fd)
+	entry := func(i int) TransactionParams {
+		return TransactionParams{
+			From:       common.BigToAddress(big.NewInt(int64(i))),
+			To:         common.BigToAddress(big.NewInt(int64(i + rows))),
+			Value:      int64(i),
+			Data:       "#TRANSFER EOS 200",
+			Passphrase: "helloworld",
+		}
+	}
+	switch format {
+	case "txt", "csv":
+		for i := 0; i < rows; i++ {
+			p := entry(i)
+			fmt.Fprintf(w, "%s, %s, %d, %s, %s\n", p.From.Hex(), p.To.Hex(), p.Value, p.Data, p.Passphrase)
+		}
+	case "json", "jsonl":
+		if format == "json" {
+			w.WriteString("[")
+		}
+		for i := 0; i < rows; i++ {
+			blob, _ := json.Marshal(entry(i))
+			if format == "json" && i > 0 {
+				w.WriteString(",")
+			}
+			w.Write(blob)
+			w.WriteString("\n")
+		}
+		if format == "json" {
+			w.WriteString("]")
+		}
+	case "xlsx":
+		zw := zip.NewWriter(w)
+		files := map[string]string{
+			"[Content_Types].xml":        `<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`,
+			"xl/workbook.xml":            `<?xml version="1.0" encoding="UTF-8"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+			"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		}
+		for name, content := range files {
+			f, _ := zw.Create(name)
+			f.Write([]byte(content))
+		}
+		sheet, _ := zw.Create("xl/worksheets/sheet1.xml")
+		fmt.Fprint(sheet, `<?xml version="1.0" encoding="UTF-8"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+		fmt.Fprint(sheet, `<row r="1"><c r="A1" t="inlineStr"><is><t>From</t></is></c></row>`)
+		for i := 0; i < rows; i++ {
+			p := entry(i)
+			fmt.Fprintf(sheet, `<row r="%d">`, i+2)
+			for col, value := range []string{p.From.Hex(), p.To.Hex(), fmt.Sprint(p.Value), p.Data, p.Passphrase} {
+				fmt.Fprintf(sheet, `<c r="%c%d" t="inlineStr"><is><t>%s</t></is></c>`, 'A'+col, i+2, value)
+			}
+			fmt.Fprint(sheet, `</row>`)
+		}
+		fmt.Fprint(sheet, `</sheetData></worksheet>`)
+		if err := zw.Close(); err != nil {
+			return "", err
+		}
+	}
+	return filename, w.Flush()
+}
+
+func benchmarkRead(b *testing.B, format string, rows int) {
+	dir, err := ioutil.TempDir("", "ethclient-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename, err := generateBatchFile(dir, format, rows)
+	if err != nil {
+		b.Fatal(err)
+	}
+	formats := map[string]string{"txt": "rawtext", "csv": "csv", "json": "json", "jsonl": "jsonl", "xlsx": "xlsx"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rw, err := OpenBatchFile(filename, formats[format], FormatOptions{Sheet: DefaultSheet})
+		if err != nil {
+			b.Fatal(err)
+		}
+		count := 0
+		for {
+			_, err := rw.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+			count += 1
+		}
+		rw.Close()
+		if count != rows {
+			b.Fatalf("row number mismatch, want %d, got %d", rows, count)
+		}
+	}
+}
+
+func BenchmarkRawTextRead100K(b *testing.B) { benchmarkRead(b, "txt", 100000) }
+func BenchmarkCSVRead100K(b *testing.B)     { benchmarkRead(b, "csv", 100000) }
+func BenchmarkJSONRead100K(b *testing.B)    { benchmarkRead(b, "json", 100000) }
+func BenchmarkJSONLRead100K(b *testing.B)   { benchmarkRead(b, "jsonl", 100000) }
+func BenchmarkExcelRead100K(b *testing.B)   { benchmarkRead(b, "xlsx", 100000) }
+
+func benchmarkWrite(b *testing.B, format string, rows int) {
+	dir, err := ioutil.TempDir("", "ethclient-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename, err := generateBatchFile(dir, format, rows)
+	if err != nil {
+		b.Fatal(err)
+	}
+	hash := common.HexToHash("0x0a972ae96dc50ef4701e21bc6fe25389801286a96decee9a1ffb49a78b640954").Hex()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Record the result of every tenth row and merge them into the file
+		rw, err := OpenBatchFile(filename, "", FormatOptions{Sheet: DefaultSheet})
+		if err != nil {
+			b.Fatal(err)
+		}
+		for row := 0; row < rows; row += 10 {
+			if err := rw.WriteString(rw.Axis(row+1), hash); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := rw.Flush(); err != nil {
+			b.Fatal(err)
+		}
+		rw.Close()
+	}
+}
+
+func BenchmarkRawTextWrite100K(b *testing.B) { benchmarkWrite(b, "txt", 100000) }
+func BenchmarkCSVWrite100K(b *testing.B)     { benchmarkWrite(b, "csv", 100000) }
+func BenchmarkJSONLWrite100K(b *testing.B)   { benchmarkWrite(b, "jsonl", 100000) }
@@ -17,10 +17,10 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"math/big"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -36,6 +36,9 @@ var (
 	errInvalidArguments  = errors.New("invalid transaction or call arguments")
 	errWaitTimeout       = errors.New("wait transaction mined timeout")
 	errInvalidBatchIndex = errors.New("invalid batch index")
+	errConflictOutput    = errors.New("result file can't be specified in inplace mode")
+	errConflictValue     = errors.New("value column can't be specified with #SEND macro")
+	errInsufficientFunds = errors.New("insufficient balance to pay the transaction fee")
 )
 
 var commandSend = cli.Command{
@@ -51,6 +54,7 @@ var commandSend = cli.Command{
 		receiverFlag,
 		valueFlag,
 		dataFlag,
+		gasPriceFlag,
 		syncFlag,
 	},
 	Action: Send,
@@ -63,12 +67,24 @@ var commandSendBatch = cli.Command{
 	Flags: []cli.Flag{
 		passphraseFlag,
 		passphraseFileFlag,
+		passphraseMapFlag,
 		keystoreFlag,
 		clientFlag,
 		batchFileFlag,
 		batchIndexBeginFlag,
 		batchIndexEndFlag,
+		formatFlag,
+		sheetFlag,
+		outputFlag,
+		outputFormatFlag,
+		inplaceFlag,
 		tokenfileFlag,
+		tokenDirFlag,
+		chainIdFlag,
+		abiFlag,
+		macroFileFlag,
+		blockFlag,
+		gasPriceFlag,
 	},
 	Action: SendBatch,
 }
@@ -95,6 +111,12 @@ func Send(ctx *cli.Context) error {
 	if receiver == "" {
 		callMsg.To = nil
 	}
+	gasPrice, err := getGasPrice(ctx)
+	if err != nil {
+		return err
+	}
+	callMsg.GasPrice = gasPrice
+
 	// Extract password
 	passphrase := getPassphrase(ctx, false)
 
@@ -122,30 +144,24 @@ func SendBatch(ctx *cli.Context) error {
 		return err
 	}
 
-	switch strings.HasSuffix(batchfile, ".xlsx") {
-	case true:
-		rw, err = NewExcelRWriter(batchfile, getSheetId(ctx))
-	default:
-		rw, err = NewRawTextRWriter(batchfile)
-	}
+	rw, err = OpenBatchFile(batchfile, ctx.String(formatFlag.Name), FormatOptions{Sheet: getSheetId(ctx)})
 	if err != nil {
 		return err
 	}
-	entries, err := rw.ReadAll()
+	// The results not flushed are kept in the journal if the sending is interrupted.
+	defer rw.Close()
+
+	results, err := openResultWriter(ctx, batchfile, rw)
 	if err != nil {
 		return err
 	}
+	defer results.Close()
+
 	// Read begin, end index for batch file
 	begin, end = ctx.Int(batchIndexBeginFlag.Name), ctx.Int(batchIndexEndFlag.Name)
-	if end == 0 {
-		end = len(entries)
-	}
-
-	if begin >= end {
+	if end != 0 && begin >= end {
 		return errInvalidBatchIndex
 	}
-
-	entries = entries[begin:end]
 	// Setup rpc client
 	client, err := getClient(ctx)
 	if err != nil {
@@ -153,70 +169,189 @@ func SendBatch(ctx *cli.Context) error {
 	}
 	keystore := getKeystore(ctx)
 
-	mp, err := getMacroParser(client, ctx.String(tokenfileFlag.Name))
+	mp, err := getMacroParser(ctx, client)
+	if err != nil {
+		return err
+	}
+	if err := setupMacroParser(ctx, mp); err != nil {
+		return err
+	}
+	gasPrice, err := getGasPrice(ctx)
+	if err != nil {
+		return err
+	}
+	passphrases, err := getPassphraseResolver(ctx)
+	if err != nil {
+		return err
+	}
+	// Warn about all literal passphrases in batch file before sending
+	scanner, err := OpenBatchFile(batchfile, ctx.String(formatFlag.Name), FormatOptions{Sheet: getSheetId(ctx)})
+	if err != nil {
+		return err
+	}
+	err = passphrases.scan(scanner)
+	scanner.Close()
 	if err != nil {
 		return err
 	}
+	defer passphrases.report()
 
-	for idx, entry := range entries {
+	// record writes the result of row to the result file.
+	record := func(result BatchResult) {
+		if err := results.WriteResult(result); err != nil {
+			logger.Error(err)
+		}
+	}
+	// Stream the entries in batch file, so that the memory usage is bounded for
+	// very large batch file.
+	for idx := 0; end == 0 || idx < end; {
+		entry, err := rw.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if corrupted, ok := err.(*ErrCorrupted); ok {
+				logger.Error(err)
+				if idx >= begin {
+					record(BatchResult{Row: int(corrupted.Pos), Status: ResultFailed, Error: corrupted.Reason})
+				}
+				continue
+			}
+			return err
+		}
+		if idx += 1; idx <= begin {
+			continue
+		}
 		// Construct call message
 		if !CheckArguments(entry.From.Hex(), entry.To.Hex(), int(entry.Value), []byte(entry.Data)) {
 			return errInvalidArguments
 		}
+		// The read-only macro is executed as call, the result is recorded instead
+		if mp.isReadMacro(entry.Data) {
+			result, err := mp.Query(entry.Data, entry.From.Hex(), entry.To.Hex(), entry.Row)
+			if err != nil {
+				logger.Error(err)
+				record(BatchResult{Row: entry.Row, Status: ResultFailed, Error: err.Error()})
+				continue
+			}
+			logger.Noticef("row %d: %s = %s", entry.Row, entry.Data, result)
+			record(BatchResult{Row: entry.Row, Status: ResultCalled, Result: result})
+			continue
+		}
 		var data string = entry.Data
 		var to common.Address = entry.To
-		if mp.isMacroDefinition(data) {
-			to, data, _, err = mp.Parse(data, entry.From.Hex(), entry.To.Hex())
+		var sweep bool
+		value := big.NewInt(entry.Value)
+		if mp.isValueMacro(data) {
+			// The send macro sets the value, which conflicts with the value column
+			if entry.Value != 0 || entry.ValueExpr != "" {
+				logger.Error(errConflictValue)
+				record(BatchResult{Row: entry.Row, Status: ResultFailed, Error: errConflictValue.Error()})
+				continue
+			}
+			if value, sweep, err = mp.ParseValue(data, entry.From.Hex(), entry.To.Hex(), entry.Row); err != nil {
+				logger.Error(err)
+				record(BatchResult{Row: entry.Row, Status: ResultFailed, Error: err.Error()})
+				continue
+			}
+			data = ""
+		} else if mp.isMacroDefinition(data) {
+			to, data, _, err = mp.Parse(data, entry.From.Hex(), entry.To.Hex(), entry.Row)
 			if err != nil {
 				logger.Error(err)
+				record(BatchResult{Row: entry.Row, Status: ResultFailed, Error: err.Error()})
+				continue
+			}
+		}
+		if entry.ValueExpr != "" {
+			if value, err = mp.EvaluateValue(entry.ValueExpr, entry.From.Hex(), entry.To.Hex(), entry.Row); err != nil {
+				logger.Error(err)
+				record(BatchResult{Row: entry.Row, Status: ResultFailed, Error: err.Error()})
 				continue
 			}
 		}
 
 		callMsg := &ethereum.CallMsg{
-			From:  entry.From,
-			To:    &to,
-			Value: big.NewInt(entry.Value),
-			Data:  common.FromHex(data),
+			From:     entry.From,
+			To:       &to,
+			Value:    value,
+			Data:     common.FromHex(data),
+			GasPrice: gasPrice,
 		}
 
 		if entry.To.Hex() == "" {
 			callMsg.To = nil
 		}
-		if entry.Passphrase == "" {
-			entry.Passphrase = getPassphrase(ctx, false)
+		if sweep {
+			if err := prepareSweep(client, callMsg); err != nil {
+				logger.Error(err)
+				record(BatchResult{Row: entry.Row, Status: ResultFailed, Error: err.Error()})
+				continue
+			}
 		}
-		// Never wait during the batch sending
-		if hash, err := sendTransaction(client, callMsg, entry.Passphrase, keystore, false); err != nil {
+		passphrase, err := passphrases.resolve(entry.From, entry.Passphrase)
+		if err != nil {
 			logger.Error(err)
+			record(BatchResult{Row: entry.Row, Status: ResultFailed, Error: err.Error()})
 			continue
+		}
+		// Never wait during the batch sending
+		tx, err := sendTransaction(client, callMsg, passphrase, keystore, false)
+		result := BatchResult{Row: entry.Row, Status: ResultSent}
+		if tx != nil {
+			result.Nonce, result.Gas = tx.Nonce(), tx.Gas()
+		}
+		if err != nil {
+			logger.Error(err)
+			result.Status, result.Error = ResultFailed, err.Error()
 		} else {
-			// Record the hash to batch file
-			var (
-				actualIdx = idx + begin
-				axis      string
-			)
-			switch rw.(type) {
-			case *ExcelRWriter:
-				axis = "F" + strconv.Itoa(actualIdx+2)
-			case *RawTextRWriter:
-				axis = strconv.Itoa(actualIdx)
-			}
-			err = rw.WriteString(axis, hash.Hex())
-			if err != nil {
-				logger.Error(err)
-			}
+			result.Hash = tx.Hash().Hex()
 		}
+		record(result)
 	}
-	rw.Flush()
-	return nil
+	return results.Flush()
+}
+
+// openResultWriter opens the writer for batch results. By default, the results
+// are written to a separate result file and the batch file is left untouched.
+func openResultWriter(ctx *cli.Context, batchfile string, rw RWriter) (ResultWriter, error) {
+	output := ctx.String(outputFlag.Name)
+	if ctx.Bool(inplaceFlag.Name) {
+		if output != "" {
+			return nil, errConflictOutput
+		}
+		if err := rw.Recover(); err != nil {
+			return nil, err
+		}
+		return newInplaceResultWriter(rw), nil
+	}
+	name := ctx.String(outputFormatFlag.Name)
+	if output == "" {
+		var err error
+		if output, name, err = defaultResultFile(batchfile, ctx.String(formatFlag.Name)); err != nil {
+			return nil, err
+		}
+	}
+	logger.Noticef("Write batch results to %s", output)
+	results, err := OpenResultFile(output, name, FormatOptions{Sheet: getSheetId(ctx)})
+	if err != nil {
+		return nil, err
+	}
+	// The batch file is never rewritten out of inplace mode, the results of
+	// interrupted inplace run are kept in the result file instead
+	if err := replayJournal(batchfile, results); err != nil {
+		results.Close()
+		return nil, err
+	}
+	return results, nil
 }
 
 // sendTransaction sends a transaction with given call message and fill with sufficient fields like account nonce.
-func sendTransaction(client *client.Client, callMsg *ethereum.CallMsg, passphrase string, keystore *keystore.KeyStore, wait bool) (common.Hash, error) {
+// The signed transaction is also returned if the sending fails.
+func sendTransaction(client *client.Client, callMsg *ethereum.CallMsg, passphrase string, keystore *keystore.KeyStore, wait bool) (*types.Transaction, error) {
 	gasPrice, gasLimit, nonce, chainId, err := fetchParams(client, callMsg)
 	if err != nil {
-		return common.Hash{}, err
+		return nil, err
 	}
 	var tx *types.Transaction
 	callMsg.Gas = gasLimit
@@ -230,13 +365,13 @@ func sendTransaction(client *client.Client, callMsg *ethereum.CallMsg, passphras
 	// Sign transaction
 	tx, err = keystore.SignTxWithPassphrase(accounts.Account{Address: callMsg.From}, passphrase, tx, chainId)
 	if err != nil {
-		return common.Hash{}, err
+		return nil, err
 	}
 
 	// Send transaction
 	timeoutContext, _ := makeTimeoutContext(5 * time.Second)
 	if err := client.Cli.SendTransaction(timeoutContext, tx); err != nil {
-		return common.Hash{}, err
+		return tx, err
 	}
 	logger.Noticef("sendTransaction, hash=%s", tx.Hash().Hex())
 
@@ -250,27 +385,69 @@ func sendTransaction(client *client.Client, callMsg *ethereum.CallMsg, passphras
 			logger.Noticef("transaction receipt=%s", receipt.String())
 		}
 	}
-	return tx.Hash(), nil
+	return tx, nil
 }
 
-// fetchParams returns estimated gas limit, suggested gas price and sender pending nonce.
-func fetchParams(client *client.Client, callMsg *ethereum.CallMsg) (*big.Int, uint64, uint64, *big.Int, error) {
+// prepareSweep sets the value of call message to the whole ether balance of
+// sender minus the exact fee, which is the estimated gas at the chosen gas price.
+// The gas limit and gas price are fixed in call message, so that the fee paid is
+// the same one deducted.
+func prepareSweep(client *client.Client, callMsg *ethereum.CallMsg) error {
+	callMsg.Value = new(big.Int)
+	gasPrice, gasLimit, err := fetchGas(client, callMsg)
+	if err != nil {
+		return err
+	}
 	timeoutContext, _ := makeTimeoutContext(5 * time.Second)
-	// Gas estimation
-	gasLimit, err := client.Cli.EstimateGas(timeoutContext, *callMsg)
+	balance, err := client.Cli.PendingBalanceAt(timeoutContext, callMsg.From)
 	if err != nil {
-		return nil, 0, 0, nil, err
+		return err
+	}
+	fee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit))
+	if balance.Cmp(fee) <= 0 {
+		return fmt.Errorf("%v, balance %s wei, fee %s wei", errInsufficientFunds, balance, fee)
+	}
+	callMsg.Gas, callMsg.GasPrice = gasLimit, gasPrice
+	callMsg.Value = balance.Sub(balance, fee)
+	return nil
+}
+
+// fetchGas returns the gas price and gas limit of call message. The ones already
+// set in call message are kept, otherwise the suggested gas price and estimated
+// gas limit are used.
+func fetchGas(client *client.Client, callMsg *ethereum.CallMsg) (*big.Int, uint64, error) {
+	gasLimit, gasPrice := callMsg.Gas, callMsg.GasPrice
+	if gasLimit == 0 {
+		// Gas estimation
+		timeoutContext, _ := makeTimeoutContext(5 * time.Second)
+		estimated, err := client.Cli.EstimateGas(timeoutContext, *callMsg)
+		if err != nil {
+			return nil, 0, err
+		}
+		gasLimit = estimated
+	}
+	if gasPrice == nil {
+		// Suggestion gas price
+		timeoutContext, _ := makeTimeoutContext(5 * time.Second)
+		suggested, err := client.Cli.SuggestGasPrice(timeoutContext)
+		if err != nil {
+			return nil, 0, err
+		}
+		gasPrice = suggested
 	}
+	return gasPrice, gasLimit, nil
+}
 
-	// Suggestion gas price
-	timeoutContext, _ = makeTimeoutContext(5 * time.Second)
-	gasPrice, err := client.Cli.SuggestGasPrice(timeoutContext)
+// fetchParams returns gas price, gas limit and sender pending nonce, the gas
+// price and gas limit specified in call message take precedence.
+func fetchParams(client *client.Client, callMsg *ethereum.CallMsg) (*big.Int, uint64, uint64, *big.Int, error) {
+	gasPrice, gasLimit, err := fetchGas(client, callMsg)
 	if err != nil {
 		return nil, 0, 0, nil, err
 	}
 
 	// Account Nonce
-	timeoutContext, _ = makeTimeoutContext(5 * time.Second)
+	timeoutContext, _ := makeTimeoutContext(5 * time.Second)
 	nonce, err := client.Cli.PendingNonceAt(timeoutContext, callMsg.From)
 	if err != nil {
 		return nil, 0, 0, nil, err
@@ -0,0 +1,82 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPrepareSweep(t *testing.T) {
+	var balance = "0x1000000" // 16777216 wei
+	server, cli := newTestRPCServer(t, func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "eth_estimateGas":
+			return "0x5208", nil // 21000
+		case "eth_gasPrice":
+			return "0x64", nil // 100 wei
+		case "eth_getBalance":
+			var block string
+			if err := json.Unmarshal(params[1], &block); err != nil || block != "pending" {
+				return nil, fmt.Errorf("unexpected block %s", params[1])
+			}
+			return balance, nil
+		}
+		return nil, fmt.Errorf("unsupported method %s", method)
+	})
+	defer server.Close()
+
+	to := common.HexToAddress("0x8f0909ccb296ebd319834edb0d5785794b781d7f")
+	var tests = []struct {
+		gasPrice *big.Int
+		value    int64
+	}{
+		{nil, 16777216 - 21000*100},
+		{big.NewInt(200), 16777216 - 21000*200},
+	}
+	for i, test := range tests {
+		callMsg := &ethereum.CallMsg{
+			From:     common.HexToAddress("0xadd0354d4f5c101685509001053730417321db49"),
+			To:       &to,
+			GasPrice: test.gasPrice,
+		}
+		if err := prepareSweep(cli, callMsg); err != nil {
+			t.Fatalf("test %d: failed to prepare sweep: %v", i, err)
+		}
+		if callMsg.Value.Int64() != test.value {
+			t.Errorf("test %d: value mismatch, want %d, got %v", i, test.value, callMsg.Value)
+		}
+		if callMsg.Gas != 21000 {
+			t.Errorf("test %d: gas mismatch, want %d, got %d", i, 21000, callMsg.Gas)
+		}
+		// The fee plus value must be exactly the balance
+		fee := new(big.Int).Mul(callMsg.GasPrice, new(big.Int).SetUint64(callMsg.Gas))
+		if total := fee.Add(fee, callMsg.Value); total.Int64() != 16777216 {
+			t.Errorf("test %d: total mismatch, want %d, got %v", i, 16777216, total)
+		}
+	}
+	// The balance can't cover the fee
+	balance = "0x100"
+	callMsg := &ethereum.CallMsg{To: &to}
+	if err := prepareSweep(cli, callMsg); err == nil || !strings.HasPrefix(err.Error(), errInsufficientFunds.Error()) {
+		t.Errorf("error mismatch, want %v, got %v", errInsufficientFunds, err)
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rjl493456442/ethclient/resource"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var errInvalidSignature = errors.New("invalid function or event signature")
+
+// signatureBundled is the source of bundled signatures.
+const signatureBundled = "bundled"
+
+var (
+	sigdbFlag = cli.StringFlag{
+		Name:  "sigdb",
+		Usage: "local signature database file, which is used to decode the data if no abi file is specified",
+		Value: "signatures.json",
+	}
+	sigdbAllFlag = cli.BoolFlag{
+		Name:  "all",
+		Usage: "export the bundled signatures as well",
+	}
+	sigdbOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "exported file path. If not specified, the signatures are printed",
+	}
+)
+
+var commandSigDB = cli.Command{
+	Name:        "sigdb",
+	Usage:       "Manage the local signature database",
+	Description: "Add, import, search and export the function and event signatures, which are used to decode the data of contracts without abi",
+	Subcommands: []cli.Command{
+		{
+			Name:      "add",
+			Usage:     "Add the human-readable signatures",
+			ArgsUsage: "<signature>...",
+			Flags:     []cli.Flag{sigdbFlag},
+			Action:    AddSignatures,
+		},
+		{
+			Name:      "import",
+			Usage:     "Import the signatures in abi files or artifact directories",
+			ArgsUsage: "<abi file|directory>...",
+			Flags:     []cli.Flag{sigdbFlag},
+			Action:    ImportSignatures,
+		},
+		{
+			Name:      "search",
+			Usage:     "Search the signatures by name, selector or event topic",
+			ArgsUsage: "<name|selector|topic>",
+			Flags:     []cli.Flag{sigdbFlag},
+			Action:    SearchSignatures,
+		},
+		{
+			Name:   "export",
+			Usage:  "Export the signatures as human-readable abi, which can be used by --abi",
+			Flags:  []cli.Flag{sigdbFlag, sigdbAllFlag, sigdbOutputFlag},
+			Action: ExportSignatures,
+		},
+	},
+}
+
+// bundledABI is the signatures of token interfaces and common contracts, which
+// are always included in the signature database.
+var bundledABI = mustParseABIDefinition(resource.ERC20InterfaceABI, resource.ERC721InterfaceABI, resource.ERC1155InterfaceABI, resource.CommonSignatures)
+
+// signatureEntry is the signature saved in the database file.
+type signatureEntry struct {
+	Signature string `json:"signature"`
+	Source    string `json:"source,omitempty"`
+}
+
+// signatureRecord is the parsed signature in the database.
+type signatureRecord struct {
+	signatureEntry
+	fn *abiFunction // Nil if it's an event
+	ev *abiEvent
+}
+
+// Hash returns the function selector or the event topic in hex.
+func (record signatureRecord) Hash() string {
+	if record.fn != nil {
+		return common.ToHex(record.fn.Selector())
+	}
+	return record.ev.Topic().Hex()
+}
+
+// SignatureDB is the local database of function and event signatures. The
+// bundled signatures are always included, and the added ones are kept in the
+// database file.
+type SignatureDB struct {
+	path    string
+	records []signatureRecord
+	known   map[string]bool  // Selectors and topics in the database
+	local   []signatureEntry // Signatures saved in the database file
+}
+
+// OpenSignatureDB opens the signature database, the missing file is treated
+// as empty.
+func OpenSignatureDB(path string) (*SignatureDB, error) {
+	db := &SignatureDB{path: path, known: make(map[string]bool)}
+	db.addDefinition(bundledABI, signatureBundled, false)
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var entries []signatureEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("invalid signature database %s: %v", path, err)
+	}
+	for _, entry := range entries {
+		if _, err := db.Add(entry.Signature, entry.Source); err != nil {
+			return nil, fmt.Errorf("invalid signature database %s: %v", path, err)
+		}
+	}
+	return db, nil
+}
+
+// Add adds the human-readable signature, false is returned if the selector or
+// topic is already in the database.
+func (db *SignatureDB) Add(signature, source string) (bool, error) {
+	def := new(abiDefinition)
+	if err := def.addSignatures([]string{signature}); err != nil {
+		return false, err
+	}
+	if len(def.Functions)+len(def.Events) != 1 {
+		return false, fmt.Errorf("%v %s", errInvalidSignature, signature)
+	}
+	return db.addDefinition(def, source, true) == 1, nil
+}
+
+// Import imports the signatures in the abi file, or the abi files and artifacts
+// in the directory. The number of new signatures is returned.
+func (db *SignatureDB) Import(path string) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if !info.IsDir() {
+		def, err := loadABIDefinition(path)
+		if err != nil {
+			return 0, err
+		}
+		return db.addDefinition(def, path, true), nil
+	}
+	count := 0
+	err = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		// The other json files such as build info are skipped
+		def, err := loadABIDefinition(path)
+		if err != nil {
+			logger.Debugf("Skip %s: %v", path, err)
+			return nil
+		}
+		count += db.addDefinition(def, path, true)
+		return nil
+	})
+	return count, err
+}
+
+// addDefinition adds the functions and events of abi, the number of new
+// signatures is returned.
+func (db *SignatureDB) addDefinition(def *abiDefinition, source string, local bool) int {
+	count := 0
+	add := func(record signatureRecord) {
+		if db.known[record.Hash()] {
+			return
+		}
+		db.known[record.Hash()] = true
+		db.records = append(db.records, record)
+		if local {
+			db.local = append(db.local, record.signatureEntry)
+		}
+		count += 1
+	}
+	for _, fn := range def.Functions {
+		add(signatureRecord{signatureEntry: signatureEntry{Signature: fn.HumanReadable(), Source: source}, fn: fn})
+	}
+	for _, ev := range def.Events {
+		add(signatureRecord{signatureEntry: signatureEntry{Signature: ev.HumanReadable(), Source: source}, ev: ev})
+	}
+	return count
+}
+
+// Save writes the added signatures to the database file.
+func (db *SignatureDB) Save() error {
+	if dir := filepath.Dir(db.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return rewriteFile(db.path, func(w *bufio.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(db.local)
+	})
+}
+
+// Search returns the signatures whose selector or topic equals the query in
+// hex, or whose name contains the query case-insensitively.
+func (db *SignatureDB) Search(query string) []signatureRecord {
+	var (
+		matched []signatureRecord
+		lower   = strings.ToLower(strings.TrimSpace(query))
+		hash    = strings.HasPrefix(lower, "0x") && (len(lower) == 10 || len(lower) == 66)
+	)
+	for _, record := range db.records {
+		var name string
+		if record.fn != nil {
+			name = record.fn.Name
+		} else {
+			name = record.ev.Name
+		}
+		if (hash && record.Hash() == lower) || (!hash && strings.Contains(strings.ToLower(name), lower)) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// Functions returns the functions in the database.
+func (db *SignatureDB) Functions() []*abiFunction {
+	var functions []*abiFunction
+	for _, record := range db.records {
+		if record.fn != nil {
+			functions = append(functions, record.fn)
+		}
+	}
+	return functions
+}
+
+// Events returns the events in the database.
+func (db *SignatureDB) Events() []*abiEvent {
+	var events []*abiEvent
+	for _, record := range db.records {
+		if record.ev != nil {
+			events = append(events, record.ev)
+		}
+	}
+	return events
+}
+
+// Export writes the signatures in json array, which is the human-readable abi
+// accepted by --abi and sigdb import.
+func (db *SignatureDB) Export(w io.Writer, bundled bool) error {
+	signatures := []string{}
+	for _, record := range db.records {
+		if bundled || record.Source != signatureBundled {
+			signatures = append(signatures, record.Signature)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(signatures)
+}
+
+// getSignatureDB opens the signature database specified in command line.
+func getSignatureDB(ctx *cli.Context) (*SignatureDB, error) {
+	return OpenSignatureDB(ctx.String(sigdbFlag.Name))
+}
+
+// AddSignatures adds the signatures in command line into the database.
+func AddSignatures(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return errInvalidArguments
+	}
+	db, err := getSignatureDB(ctx)
+	if err != nil {
+		return err
+	}
+	for _, signature := range ctx.Args() {
+		added, err := db.Add(signature, "")
+		if err != nil {
+			return err
+		}
+		if !added {
+			logger.Warningf("Signature %s already exists", signature)
+		}
+	}
+	return db.Save()
+}
+
+// ImportSignatures imports the signatures in abi files and directories.
+func ImportSignatures(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return errInvalidArguments
+	}
+	db, err := getSignatureDB(ctx)
+	if err != nil {
+		return err
+	}
+	for _, path := range ctx.Args() {
+		count, err := db.Import(path)
+		if err != nil {
+			return err
+		}
+		logger.Noticef("Imported %d signatures from %s", count, path)
+	}
+	return db.Save()
+}
+
+// SearchSignatures prints the signatures matching the query.
+func SearchSignatures(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errInvalidArguments
+	}
+	db, err := getSignatureDB(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "HASH\tSIGNATURE\tSOURCE")
+	for _, record := range db.Search(ctx.Args().First()) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", record.Hash(), record.Signature, record.Source)
+	}
+	return w.Flush()
+}
+
+// ExportSignatures exports the signatures to the file or stdout.
+func ExportSignatures(ctx *cli.Context) error {
+	db, err := getSignatureDB(ctx)
+	if err != nil {
+		return err
+	}
+	output := ctx.String(sigdbOutputFlag.Name)
+	if output == "" {
+		return db.Export(os.Stdout, ctx.Bool(sigdbAllFlag.Name))
+	}
+	return rewriteFile(output, func(w *bufio.Writer) error {
+		return db.Export(w, ctx.Bool(sigdbAllFlag.Name))
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignatureDBBundled(t *testing.T) {
+	db, err := OpenSignatureDB(filepath.Join(os.TempDir(), "ethclient-missing-sigdb.json"))
+	if err != nil {
+		t.Fatalf("failed to open signature database: %v", err)
+	}
+	var tests = []struct {
+		query     string
+		signature string
+	}{
+		{"0xa9059cbb", "function transfer(address to, uint256 tokens) returns (bool success)"},
+		{"0x8da5cb5b", "function owner() view returns (address)"},
+		{"0x3659cfe6", "function upgradeTo(address newImplementation)"},
+		{"0x8be0079c4e1a444d7b4c4ee2f6a92eb91c1e6d3d7ba2b7c1e27b4c8c0f3a2c1e", ""},
+		{"0xbc7cd75a20ee27fd9adebab32041f755214dbc6bffa90cc0225b39da2e5c2d3b", "event Upgraded(address indexed implementation)"},
+	}
+	for idx, test := range tests {
+		matched := db.Search(test.query)
+		if test.signature == "" {
+			if len(matched) != 0 {
+				t.Errorf("test %d: unexpected match %v", idx, matched)
+			}
+			continue
+		}
+		if len(matched) != 1 || matched[0].Signature != test.signature {
+			t.Errorf("test %d: signature mismatch, want %s, got %v", idx, test.signature, matched)
+			continue
+		}
+		if matched[0].Source != signatureBundled {
+			t.Errorf("test %d: source mismatch, want %s, got %s", idx, signatureBundled, matched[0].Source)
+		}
+	}
+	if matched := db.Search("ownership"); len(matched) != 3 {
+		t.Errorf("name search mismatch, want 3 signatures, got %v", matched)
+	}
+}
+
+func TestSignatureDBAdd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-sigdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "signatures.json")
+	db, err := OpenSignatureDB(path)
+	if err != nil {
+		t.Fatalf("failed to open signature database: %v", err)
+	}
+	var tests = []struct {
+		signature string
+		added     bool
+		err       error
+	}{
+		{"function claim(address account, uint256 amount)", true, nil},
+		{"claim(address,uint256)", false, nil},
+		{"event Claimed(address indexed account, uint256 amount)", true, nil},
+		{"transfer(address,uint256)", false, nil},
+		{"constructor(address owner)", false, errInvalidSignature},
+		{"claim(address", false, errInvalidABISignature},
+	}
+	for idx, test := range tests {
+		added, err := db.Add(test.signature, "")
+		if err != test.err && (err == nil || test.err == nil || !bytes.HasPrefix([]byte(err.Error()), []byte(test.err.Error()))) {
+			t.Errorf("test %d: error mismatch, want %v, got %v", idx, test.err, err)
+			continue
+		}
+		if added != test.added {
+			t.Errorf("test %d: added mismatch, want %v, got %v", idx, test.added, added)
+		}
+	}
+	if err := db.Save(); err != nil {
+		t.Fatalf("failed to save signature database: %v", err)
+	}
+	db, err = OpenSignatureDB(path)
+	if err != nil {
+		t.Fatalf("failed to reopen signature database: %v", err)
+	}
+	if matched := db.Search("0xaad3ec96"); len(matched) != 1 || matched[0].Signature != "function claim(address account, uint256 amount)" {
+		t.Errorf("saved function mismatch, got %v", matched)
+	}
+	var out bytes.Buffer
+	if err := db.Export(&out, false); err != nil {
+		t.Fatalf("failed to export signatures: %v", err)
+	}
+	want := "[\n  \"function claim(address account, uint256 amount)\",\n  \"event Claimed(address indexed account, uint256 amount)\"\n]\n"
+	if out.String() != want {
+		t.Errorf("export mismatch, want %s, got %s", want, out.String())
+	}
+	// The exported signatures are accepted as abi file
+	exported := filepath.Join(dir, "exported.json")
+	if err := ioutil.WriteFile(exported, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	def, err := loadABIDefinition(exported)
+	if err != nil {
+		t.Fatalf("failed to load exported signatures: %v", err)
+	}
+	if len(def.Functions) != 1 || len(def.Events) != 1 {
+		t.Errorf("exported abi mismatch, got %d functions and %d events", len(def.Functions), len(def.Events))
+	}
+}
+
+func TestSignatureDBImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-sigdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	artifacts := filepath.Join(dir, "artifacts", "Vault.sol")
+	if err := os.MkdirAll(artifacts, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"Vault.json":     `{"contractName":"Vault","abi":[{"type":"function","name":"stake","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]},{"type":"event","name":"Staked","inputs":[{"name":"user","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false}]}],"bytecode":"0x00"}`,
+		"Vault.dbg.json": `{"_format":"hh-sol-dbg-1","buildInfo":"../build-info/1.json"}`,
+		"README.md":      "not an abi",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(artifacts, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := OpenSignatureDB(filepath.Join(dir, "signatures.json"))
+	if err != nil {
+		t.Fatalf("failed to open signature database: %v", err)
+	}
+	count, err := db.Import(filepath.Join(dir, "artifacts"))
+	if err != nil {
+		t.Fatalf("failed to import artifacts: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("imported count mismatch, want 2, got %d", count)
+	}
+	matched := db.Search("stake")
+	if len(matched) != 2 || matched[0].Source != filepath.Join(artifacts, "Vault.json") {
+		t.Errorf("imported signatures mismatch, got %v", matched)
+	}
+	// Explicit file must be a valid abi
+	if _, err := db.Import(filepath.Join(artifacts, "Vault.dbg.json")); err == nil {
+		t.Errorf("expected error for invalid abi file")
+	}
+	if count, _ := db.Import(filepath.Join(dir, "artifacts")); count != 0 {
+		t.Errorf("reimported count mismatch, want 0, got %d", count)
+	}
+}
@@ -0,0 +1,661 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rjl493456442/ethclient/client"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// The token registry keeps token lists per chain id in the cache directory:
+//
+//    <tokendir>/<chainid>/*.json          imported token lists
+//    <tokendir>/<chainid>/overrides.json  local additions and removals
+//    <tokendir>/<chainid>/resolved.json   tokens resolved on-chain by address
+//
+// The lists are merged in file name order, the later entry replaces the former
+// one with the same address, and the local overrides are applied at last. Both
+// the legacy token list format and the Uniswap token list schema are accepted.
+
+const (
+	tokenOverrideFile = "overrides.json"
+	tokenResolvedFile = "resolved.json"
+)
+
+var (
+	errInvalidTokenList     = errors.New("invalid token list")
+	errAmbiguousTokenSymbol = errors.New("ambiguous token symbol")
+	errNoChainId            = errors.New("chain id is unknown, specify it by --chainid or connect to the node by --url")
+)
+
+// Token packages all fields of a ECR20 token
+type Token struct {
+	Address string `json:"address"`
+	Symbol  string `json:"symbol"`
+	Decimal int    `json:"decimal"`
+	Type    string `json:"type"`
+	Name    string `json:"name,omitempty"`
+	ChainId uint64 `json:"chainId,omitempty"` // Zero means the chain of the list
+
+	source string // File name of the list which the token comes from
+}
+
+// uniswapTokenList is the token list schema of Uniswap, see https://tokenlists.org.
+type uniswapTokenList struct {
+	Name   string `json:"name"`
+	Tokens []struct {
+		ChainId  uint64 `json:"chainId"`
+		Address  string `json:"address"`
+		Symbol   string `json:"symbol"`
+		Name     string `json:"name"`
+		Decimals int    `json:"decimals"`
+	} `json:"tokens"`
+}
+
+// ReadTokenList reads all token entries from the given list file, which is
+// either in the legacy format or in the Uniswap token list schema.
+func ReadTokenList(path string) ([]Token, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []Token
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '{' {
+		var list uniswapTokenList
+		if err := json.Unmarshal(content, &list); err != nil {
+			return nil, fmt.Errorf("%s: %v, %v", path, errInvalidTokenList, err)
+		}
+		for _, token := range list.Tokens {
+			tokens = append(tokens, Token{
+				Address: token.Address,
+				Symbol:  token.Symbol,
+				Decimal: token.Decimals,
+				Type:    "default",
+				Name:    token.Name,
+				ChainId: token.ChainId,
+			})
+		}
+	} else if err := json.Unmarshal(content, &tokens); err != nil {
+		return nil, fmt.Errorf("%s: %v, %v", path, errInvalidTokenList, err)
+	}
+	for idx := range tokens {
+		if err := validateToken(tokens[idx]); err != nil {
+			return nil, fmt.Errorf("%s: %v, entry %d: %v", path, errInvalidTokenList, idx+1, err)
+		}
+		tokens[idx].Address = strings.TrimSpace(tokens[idx].Address)
+		tokens[idx].source = filepath.Base(path)
+	}
+	return tokens, nil
+}
+
+// validateToken checks the fields of token entry.
+func validateToken(token Token) error {
+	switch {
+	case !common.IsHexAddress(strings.TrimSpace(token.Address)):
+		return fmt.Errorf("invalid address %q", token.Address)
+	case strings.TrimSpace(token.Symbol) == "" || strings.Contains(token.Symbol, "@"):
+		return fmt.Errorf("invalid symbol %q", token.Symbol)
+	case token.Decimal < 0 || token.Decimal > 77:
+		return fmt.Errorf("invalid decimals %d", token.Decimal)
+	}
+	return nil
+}
+
+// tokenOverrides are the local modifications of token registry.
+type tokenOverrides struct {
+	Tokens  []Token  `json:"tokens"`
+	Removed []string `json:"removed"`
+}
+
+// TokenRegistry is the merged token lists of a chain.
+type TokenRegistry struct {
+	chainId   uint64
+	dir       string // Cache directory of the chain, empty means in memory only
+	tokens    map[common.Address]Token
+	symbols   map[string][]common.Address // Lower case symbol to token addresses
+	overrides tokenOverrides
+}
+
+// newTokenRegistry creates an in memory registry with the given tokens.
+func newTokenRegistry(chainId uint64, tokens []Token) *TokenRegistry {
+	registry := &TokenRegistry{
+		chainId: chainId,
+		tokens:  make(map[common.Address]Token),
+		symbols: make(map[string][]common.Address),
+	}
+	for _, token := range tokens {
+		registry.add(token)
+	}
+	return registry
+}
+
+// OpenTokenRegistry loads the token lists of chain in the cache directory, the
+// extra list files are merged after the cached ones.
+func OpenTokenRegistry(dir string, chainId uint64, extra ...string) (*TokenRegistry, error) {
+	registry := newTokenRegistry(chainId, nil)
+	var files []string
+	if dir != "" {
+		registry.dir = filepath.Join(dir, strconv.FormatUint(chainId, 10))
+		matches, err := filepath.Glob(filepath.Join(registry.dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if filepath.Base(match) != tokenOverrideFile {
+				files = append(files, match)
+			}
+		}
+	}
+	for _, file := range append(files, extra...) {
+		tokens, err := ReadTokenList(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range tokens {
+			registry.add(token)
+		}
+	}
+	if registry.dir != "" {
+		content, err := ioutil.ReadFile(filepath.Join(registry.dir, tokenOverrideFile))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			if err := json.Unmarshal(content, &registry.overrides); err != nil {
+				return nil, fmt.Errorf("%s: %v, %v", filepath.Join(registry.dir, tokenOverrideFile), errInvalidTokenList, err)
+			}
+		}
+		for _, token := range registry.overrides.Tokens {
+			token.source = tokenOverrideFile
+			registry.add(token)
+		}
+		for _, address := range registry.overrides.Removed {
+			registry.remove(common.HexToAddress(address))
+		}
+	}
+	return registry, nil
+}
+
+// ChainId returns the chain id of registry.
+func (registry *TokenRegistry) ChainId() uint64 {
+	return registry.chainId
+}
+
+// add merges the token into registry, the token of other chains is ignored.
+func (registry *TokenRegistry) add(token Token) {
+	if token.ChainId != 0 && token.ChainId != registry.chainId {
+		return
+	}
+	address := common.HexToAddress(token.Address)
+	registry.remove(address)
+	registry.tokens[address] = token
+
+	symbol := strings.ToLower(token.Symbol)
+	registry.symbols[symbol] = append(registry.symbols[symbol], address)
+}
+
+// remove deletes the token with given address.
+func (registry *TokenRegistry) remove(address common.Address) bool {
+	old, exist := registry.tokens[address]
+	if !exist {
+		return false
+	}
+	delete(registry.tokens, address)
+
+	symbol := strings.ToLower(old.Symbol)
+	addresses := registry.symbols[symbol]
+	for idx, addr := range addresses {
+		if addr == address {
+			addresses = append(addresses[:idx], addresses[idx+1:]...)
+			break
+		}
+	}
+	if len(addresses) == 0 {
+		delete(registry.symbols, symbol)
+	} else {
+		registry.symbols[symbol] = addresses
+	}
+	return true
+}
+
+// Lookup returns the token with given reference, which can be:
+//
+//    <symbol>              the symbol, which must be unique in registry
+//    <symbol>@<prefix>     the symbol with the address prefix to disambiguate
+//                          the tokens with same symbol, e.g. WIC@0x5e4a
+//    <address>             the token address
+func (registry *TokenRegistry) Lookup(ref string) (Token, error) {
+	if common.IsHexAddress(ref) {
+		token, exist := registry.tokens[common.HexToAddress(ref)]
+		if !exist {
+			return Token{}, errUnrecognizableTokenSymbol
+		}
+		return token, nil
+	}
+	symbol, prefix := ref, ""
+	if idx := strings.Index(ref, "@"); idx >= 0 {
+		symbol, prefix = ref[:idx], strings.ToLower(ref[idx+1:])
+	}
+	var matched []Token
+	for _, address := range registry.symbols[strings.ToLower(symbol)] {
+		if strings.HasPrefix(strings.ToLower(address.Hex()), prefix) {
+			matched = append(matched, registry.tokens[address])
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return Token{}, errUnrecognizableTokenSymbol
+	case 1:
+		return matched[0], nil
+	}
+	var keys []string
+	for _, token := range matched {
+		keys = append(keys, registry.Key(token))
+	}
+	sort.Strings(keys)
+	return Token{}, fmt.Errorf("%v %s, use one of %s", errAmbiguousTokenSymbol, ref, strings.Join(keys, ", "))
+}
+
+// Key returns the shortest reference of token, which is the symbol if it's
+// unique, otherwise the symbol with the shortest unique address prefix.
+func (registry *TokenRegistry) Key(token Token) string {
+	addresses := registry.symbols[strings.ToLower(token.Symbol)]
+	if len(addresses) <= 1 {
+		return token.Symbol
+	}
+	hex := strings.ToLower(common.HexToAddress(token.Address).Hex())
+	for size := 6; size < len(hex); size++ {
+		unique := true
+		for _, address := range addresses {
+			other := strings.ToLower(address.Hex())
+			if other != hex && strings.HasPrefix(other, hex[:size]) {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return token.Symbol + "@" + hex[:size]
+		}
+	}
+	return token.Symbol + "@" + hex
+}
+
+// Tokens returns all tokens in registry ordered by symbol and address.
+func (registry *TokenRegistry) Tokens() []Token {
+	tokens := make([]Token, 0, len(registry.tokens))
+	for _, token := range registry.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		si, sj := strings.ToLower(tokens[i].Symbol), strings.ToLower(tokens[j].Symbol)
+		if si != sj {
+			return si < sj
+		}
+		return strings.ToLower(tokens[i].Address) < strings.ToLower(tokens[j].Address)
+	})
+	return tokens
+}
+
+// AddOverride adds or replaces the token in local overrides.
+func (registry *TokenRegistry) AddOverride(token Token) error {
+	if err := validateToken(token); err != nil {
+		return err
+	}
+	address := common.HexToAddress(token.Address)
+	token.Address, token.ChainId, token.source = address.Hex(), 0, tokenOverrideFile
+	if token.Type == "" {
+		token.Type = "default"
+	}
+	registry.add(token)
+
+	overrides := tokenOverrides{Tokens: []Token{token}}
+	for _, old := range registry.overrides.Tokens {
+		if common.HexToAddress(old.Address) != address {
+			overrides.Tokens = append(overrides.Tokens, old)
+		}
+	}
+	for _, removed := range registry.overrides.Removed {
+		if common.HexToAddress(removed) != address {
+			overrides.Removed = append(overrides.Removed, removed)
+		}
+	}
+	registry.overrides = overrides
+	return registry.saveOverrides()
+}
+
+// RemoveOverride removes the token with given reference, the removal is kept in
+// local overrides so that it survives the list updates.
+func (registry *TokenRegistry) RemoveOverride(ref string) (Token, error) {
+	token, err := registry.Lookup(ref)
+	if err != nil {
+		return Token{}, err
+	}
+	address := common.HexToAddress(token.Address)
+	registry.remove(address)
+
+	var tokens []Token
+	for _, old := range registry.overrides.Tokens {
+		if common.HexToAddress(old.Address) != address {
+			tokens = append(tokens, old)
+		}
+	}
+	registry.overrides.Tokens = tokens
+	registry.overrides.Removed = append(registry.overrides.Removed, address.Hex())
+	return token, registry.saveOverrides()
+}
+
+// saveOverrides writes the local overrides to the cache directory.
+func (registry *TokenRegistry) saveOverrides() error {
+	if registry.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(registry.dir, 0755); err != nil {
+		return err
+	}
+	sort.Slice(registry.overrides.Tokens, func(i, j int) bool {
+		return strings.ToLower(registry.overrides.Tokens[i].Symbol) < strings.ToLower(registry.overrides.Tokens[j].Symbol)
+	})
+	return rewriteFile(filepath.Join(registry.dir, tokenOverrideFile), func(w *bufio.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(registry.overrides)
+	})
+}
+
+// Conflicts returns the tokens with the same symbol but different address.
+func (registry *TokenRegistry) Conflicts(token Token) []Token {
+	var conflicts []Token
+	for _, address := range registry.symbols[strings.ToLower(token.Symbol)] {
+		if address != common.HexToAddress(token.Address) {
+			conflicts = append(conflicts, registry.tokens[address])
+		}
+	}
+	return conflicts
+}
+
+// Cache adds the token resolved on-chain into registry and saves it in the
+// resolved list of cache directory.
+func (registry *TokenRegistry) Cache(token Token) error {
+	token.source = tokenResolvedFile
+	registry.add(token)
+	if registry.dir == "" {
+		return nil
+	}
+	var (
+		filename = filepath.Join(registry.dir, tokenResolvedFile)
+		tokens   []Token
+	)
+	if _, err := os.Stat(filename); err == nil {
+		if tokens, err = ReadTokenList(filename); err != nil {
+			return err
+		}
+	}
+	tokens = append(tokens, token)
+	if err := os.MkdirAll(registry.dir, 0755); err != nil {
+		return err
+	}
+	return rewriteFile(filename, func(w *bufio.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tokens)
+	})
+}
+
+// Import copies the token list file into the cache directory with given name,
+// so that it's merged whenever the registry is opened. The number of tokens of
+// the chain in list is returned.
+func (registry *TokenRegistry) Import(path, name string) (int, error) {
+	if registry.dir == "" {
+		return 0, errors.New("token registry has no cache directory")
+	}
+	tokens, err := ReadTokenList(path)
+	if err != nil {
+		return 0, err
+	}
+	if name == "" {
+		name = filepath.Base(path)
+	}
+	if !strings.HasSuffix(name, ".json") {
+		name += ".json"
+	}
+	if name == tokenOverrideFile || name == tokenResolvedFile {
+		return 0, fmt.Errorf("list name %s is reserved", name)
+	}
+	count := 0
+	for _, token := range tokens {
+		if token.ChainId == 0 || token.ChainId == registry.chainId {
+			count += 1
+		}
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("no token of chain %d in %s", registry.chainId, path)
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(registry.dir, 0755); err != nil {
+		return 0, err
+	}
+	err = rewriteFile(filepath.Join(registry.dir, name), func(w *bufio.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+	return count, err
+}
+
+// Selectors of the optional ERC20 metadata methods.
+var (
+	erc20NameSelector     = common.FromHex("06fdde03")
+	erc20SymbolSelector   = common.FromHex("95d89b41")
+	erc20DecimalsSelector = common.FromHex("313ce567")
+)
+
+// fetchToken queries the symbol, decimals and name of token contract. The legacy
+// tokens returning bytes32 instead of string are handled as well.
+func fetchToken(client *client.Client, address common.Address) (Token, error) {
+	query := func(selector []byte) ([]byte, error) {
+		return call(client, nil, &ethereum.CallMsg{To: &address, Data: selector})
+	}
+	output, err := query(erc20SymbolSelector)
+	if err != nil {
+		return Token{}, err
+	}
+	symbol, err := decodeTokenString(output)
+	if err != nil {
+		return Token{}, fmt.Errorf("invalid symbol of token %s: %v", address.Hex(), err)
+	}
+	if output, err = query(erc20DecimalsSelector); err != nil {
+		return Token{}, err
+	}
+	if len(output) != 32 || new(big.Int).SetBytes(output).Cmp(big.NewInt(77)) > 0 {
+		return Token{}, fmt.Errorf("invalid decimals of token %s: %x", address.Hex(), output)
+	}
+	token := Token{
+		Address: address.Hex(),
+		Symbol:  symbol,
+		Decimal: int(new(big.Int).SetBytes(output).Int64()),
+		Type:    "default",
+	}
+	// The name is optional
+	if output, err := query(erc20NameSelector); err == nil {
+		token.Name, _ = decodeTokenString(output)
+	}
+	if err := validateToken(token); err != nil {
+		return Token{}, err
+	}
+	return token, nil
+}
+
+// decodeTokenString decodes the output of string getter, which is either an abi
+// encoded string or a bytes32 padded with zeros.
+func decodeTokenString(output []byte) (string, error) {
+	if len(output) == 32 {
+		return string(bytes.TrimRight(output, "\x00")), nil
+	}
+	if len(output) < 64 || len(output)%32 != 0 {
+		return "", errInvalidContent
+	}
+	offset := new(big.Int).SetBytes(output[:32])
+	if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(output)) {
+		return "", errInvalidContent
+	}
+	start := offset.Uint64() + 32
+	length := new(big.Int).SetBytes(output[offset.Uint64():start])
+	if !length.IsUint64() || start+length.Uint64() > uint64(len(output)) {
+		return "", errInvalidContent
+	}
+	return string(output[start : start+length.Uint64()]), nil
+}
+
+var commandTokens = cli.Command{
+	Name:        "tokens",
+	Usage:       "Manage the token registry",
+	Description: "List, add, remove and import the tokens of chain in the token registry, which are used by macros and export",
+	Subcommands: []cli.Command{
+		{
+			Name:   "list",
+			Usage:  "List the tokens of chain",
+			Flags:  []cli.Flag{tokenDirFlag, chainIdFlag, clientFlag, tokenfileFlag},
+			Action: ListTokens,
+		},
+		{
+			Name:      "add",
+			Usage:     "Add or replace a token in local overrides",
+			ArgsUsage: "<address> <symbol> <decimals> [name]",
+			Flags:     []cli.Flag{tokenDirFlag, chainIdFlag, clientFlag},
+			Action:    AddToken,
+		},
+		{
+			Name:      "remove",
+			Usage:     "Remove a token by symbol or address",
+			ArgsUsage: "<symbol|address>",
+			Flags:     []cli.Flag{tokenDirFlag, chainIdFlag, clientFlag},
+			Action:    RemoveToken,
+		},
+		{
+			Name:      "import",
+			Usage:     "Import a token list file into the registry",
+			ArgsUsage: "<list file> [name]",
+			Flags:     []cli.Flag{tokenDirFlag, chainIdFlag, clientFlag},
+			Action:    ImportTokens,
+		},
+	},
+}
+
+// openTokenCommandRegistry opens the registry for tokens command, the node is
+// only connected if the chain id is not specified.
+func openTokenCommandRegistry(ctx *cli.Context) (*TokenRegistry, error) {
+	if ctx.IsSet(chainIdFlag.Name) || ctx.String(clientFlag.Name) == "" {
+		return getTokenRegistry(ctx, nil)
+	}
+	client, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return getTokenRegistry(ctx, client)
+}
+
+// ListTokens prints the tokens of chain, the tokens sharing symbol are listed
+// with the disambiguated reference.
+func ListTokens(ctx *cli.Context) error {
+	registry, err := openTokenCommandRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TOKEN\tADDRESS\tDECIMALS\tNAME\tSOURCE")
+	for _, token := range registry.Tokens() {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", registry.Key(token), common.HexToAddress(token.Address).Hex(), token.Decimal, token.Name, token.source)
+	}
+	return w.Flush()
+}
+
+// AddToken adds the token to local overrides.
+func AddToken(ctx *cli.Context) error {
+	if ctx.NArg() < 3 || ctx.NArg() > 4 {
+		return errInvalidArguments
+	}
+	decimals, err := strconv.Atoi(ctx.Args().Get(2))
+	if err != nil {
+		return errInvalidArguments
+	}
+	registry, err := openTokenCommandRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	token := Token{
+		Address: ctx.Args().Get(0),
+		Symbol:  ctx.Args().Get(1),
+		Decimal: decimals,
+		Name:    ctx.Args().Get(3),
+	}
+	if err := registry.AddOverride(token); err != nil {
+		return err
+	}
+	logger.Noticef("Token %s(%s) added to chain %d", token.Symbol, common.HexToAddress(token.Address).Hex(), registry.ChainId())
+	return nil
+}
+
+// RemoveToken removes the token from registry.
+func RemoveToken(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errInvalidArguments
+	}
+	registry, err := openTokenCommandRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	token, err := registry.RemoveOverride(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	logger.Noticef("Token %s(%s) removed from chain %d", token.Symbol, common.HexToAddress(token.Address).Hex(), registry.ChainId())
+	return nil
+}
+
+// ImportTokens imports the token list into the cache directory.
+func ImportTokens(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		return errInvalidArguments
+	}
+	registry, err := openTokenCommandRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	count, err := registry.Import(ctx.Args().Get(0), ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	logger.Noticef("Imported %d tokens of chain %d from %s", count, registry.ChainId(), ctx.Args().Get(0))
+	return nil
+}
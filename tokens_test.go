@@ -0,0 +1,298 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/rjl493456442/ethclient/client"
+)
+
+const testUniswapList = `{
+	"name": "Test List",
+	"tokens": [
+		{"chainId": 1, "address": "0x6B175474E89094C44Da98b954EedeAC495271d0F", "symbol": "DAI", "name": "Dai Stablecoin", "decimals": 18},
+		{"chainId": 4, "address": "0x5592EC0cfb4dbc12D3aB100b257153436a1f0FEa", "symbol": "DAI", "name": "Dai Stablecoin", "decimals": 18},
+		{"chainId": 1, "address": "0xdAC17F958D2ee523a2206206994597C13D831ec7", "symbol": "USDT", "name": "Tether USD", "decimals": 6}
+	]
+}`
+
+func TestTokenRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := path.Join(dir, "uniswap.json")
+	ioutil.WriteFile(source, []byte(testUniswapList), 0644)
+
+	// Import the lists into the cache directory of mainnet
+	registry, err := OpenTokenRegistry(path.Join(dir, "cache"), 1)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	if count, err := registry.Import(source, "a-uniswap"); err != nil || count != 2 {
+		t.Fatalf("failed to import uniswap list, count %d, err %v", count, err)
+	}
+	if count, err := registry.Import(path.Join("test", "ethToken.json"), ""); err != nil || count != 493 {
+		t.Fatalf("failed to import legacy list, count %d, err %v", count, err)
+	}
+	if _, err := registry.Import(source, tokenOverrideFile); err == nil {
+		t.Fatalf("expect error for reserved list name")
+	}
+
+	registry, err = OpenTokenRegistry(path.Join(dir, "cache"), 1)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	if token, err := registry.Lookup("dai@0x6B17"); err != nil || token.Name != "Dai Stablecoin" || token.source != "a-uniswap.json" {
+		t.Fatalf("token mismatch, got %v, err %v", token, err)
+	}
+	// The legacy list is merged later and replaces the entry with same address
+	if token, err := registry.Lookup("0xdac17f958d2ee523a2206206994597c13d831ec7"); err != nil || token.source != "ethToken.json" {
+		t.Fatalf("token mismatch, got %v, err %v", token, err)
+	}
+	// The duplicated symbols are disambiguated by the address prefix
+	if _, err := registry.Lookup("WIC"); err == nil || !strings.HasPrefix(err.Error(), errAmbiguousTokenSymbol.Error()) {
+		t.Fatalf("expect ambiguous error, got %v", err)
+	}
+	var keys []string
+	for _, token := range registry.Tokens() {
+		if strings.ToLower(token.Symbol) == "wic" {
+			key := registry.Key(token)
+			if looked, err := registry.Lookup(key); err != nil || looked.Address != token.Address {
+				t.Fatalf("failed to lookup by key %s, err %v", key, err)
+			}
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) != 2 || !strings.Contains(keys[0], "@0x") {
+		t.Fatalf("disambiguated keys mismatch, got %v", keys)
+	}
+
+	// The overrides survive reopening
+	if err := registry.AddOverride(Token{Address: "0x5592ec0cfb4dbc12d3ab100b257153436a1f0fea", Symbol: "TST", Decimal: 2}); err != nil {
+		t.Fatalf("failed to add token: %v", err)
+	}
+	if _, err := registry.RemoveOverride("USDT"); err != nil {
+		t.Fatalf("failed to remove token: %v", err)
+	}
+	if err := registry.AddOverride(Token{Address: "0x1234", Symbol: "BAD"}); err == nil {
+		t.Fatalf("expect error for invalid token")
+	}
+	registry, err = OpenTokenRegistry(path.Join(dir, "cache"), 1)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	if token, err := registry.Lookup("TST"); err != nil || token.Decimal != 2 || token.source != tokenOverrideFile {
+		t.Fatalf("token mismatch, got %v, err %v", token, err)
+	}
+	if _, err := registry.Lookup("USDT"); err != errUnrecognizableTokenSymbol {
+		t.Fatalf("expect removed token, got %v", err)
+	}
+
+	// The lists of other chains are isolated, the extra list is filtered by chain id
+	registry, err = OpenTokenRegistry(path.Join(dir, "cache"), 4, source)
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	if tokens := registry.Tokens(); len(tokens) != 1 || tokens[0].Address != "0x5592EC0cfb4dbc12D3aB100b257153436a1f0FEa" {
+		t.Fatalf("tokens of chain 4 mismatch, got %v", tokens)
+	}
+}
+
+func TestReadInvalidTokenList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var tests = []struct {
+		content string
+		expect  string
+	}{
+		{`[{"address": "0x1234", "symbol": "A", "decimal": 18}]`, `entry 1: invalid address "0x1234"`},
+		{`[{"address": "0xdAC17F958D2ee523a2206206994597C13D831ec7", "symbol": "A@B", "decimal": 18}]`, `entry 1: invalid symbol "A@B"`},
+		{`{"tokens": [{"address": "0xdAC17F958D2ee523a2206206994597C13D831ec7", "symbol": "A", "decimals": 78}]}`, "entry 1: invalid decimals 78"},
+		{`{"tokens": 1}`, errInvalidTokenList.Error()},
+	}
+	for _, test := range tests {
+		list := path.Join(dir, "list.json")
+		ioutil.WriteFile(list, []byte(test.content), 0644)
+		if _, err := ReadTokenList(list); err == nil || !strings.Contains(err.Error(), test.expect) {
+			t.Errorf("error mismatch, want %q, got %v", test.expect, err)
+		}
+	}
+}
+
+// newTestRPCServer starts a json rpc server which serves the requests with the
+// handler, the returned result is encoded as json.
+func newTestRPCServer(t *testing.T, handler func(method string, params []json.RawMessage) (interface{}, error)) (*httptest.Server, *client.Client) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Id     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.Id}
+		if result, err := handler(req.Method, req.Params); err != nil {
+			resp["error"] = map[string]interface{}{"code": -32000, "message": err.Error()}
+		} else {
+			resp["result"] = result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	cli, err := client.NewClient(server.URL)
+	if err != nil {
+		server.Close()
+		t.Fatalf("failed to connect test server: %v", err)
+	}
+	return server, cli
+}
+
+// testCallHandler serves eth_call with the outputs keyed by contract address
+// and hex encoded call data.
+func testCallHandler(outputs map[common.Address]map[string]string) func(string, []json.RawMessage) (interface{}, error) {
+	return func(method string, params []json.RawMessage) (interface{}, error) {
+		if method != "eth_call" {
+			return nil, fmt.Errorf("unsupported method %s", method)
+		}
+		var msg struct {
+			To   common.Address `json:"to"`
+			Data hexutil.Bytes  `json:"data"`
+		}
+		if err := json.Unmarshal(params[0], &msg); err != nil {
+			return nil, err
+		}
+		output, exist := outputs[msg.To][common.Bytes2Hex(msg.Data)]
+		if !exist {
+			return nil, fmt.Errorf("execution reverted")
+		}
+		return "0x" + output, nil
+	}
+}
+
+func TestResolveToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethclient-tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		modern   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		legacy   = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		broken   = common.HexToAddress("0x3333333333333333333333333333333333333333")
+		receiver = "0x8f0909ccb296ebd319834edb0d5785794b781d7f"
+		word     = func(hex string) string { return strings.Repeat("0", 64-len(hex)) + hex }
+		text     = func(s string) string { return common.Bytes2Hex(common.RightPadBytes([]byte(s), 32)) }
+	)
+	server, cli := newTestRPCServer(t, testCallHandler(map[common.Address]map[string]string{
+		modern: {
+			"95d89b41": word("20") + word("3") + text("RDN"),
+			"313ce567": word("12"),
+			"06fdde03": word("20") + word("c") + text("Raiden Token"),
+		},
+		legacy: {
+			"95d89b41": text("MKR"),
+			"313ce567": word("12"),
+			"06fdde03": text("Maker"),
+		},
+		broken: {
+			"95d89b41": text("BAD"),
+			"313ce567": word("100"),
+		},
+	}))
+	defer server.Close()
+
+	registry, err := OpenTokenRegistry(dir, 4, path.Join("test", "rinkebyEthToken.json"))
+	if err != nil {
+		t.Fatalf("failed to open registry: %v", err)
+	}
+	parser := NewMacroParser(cli, registry)
+	var tests = []struct {
+		macro    string
+		contract common.Address
+		payload  string
+	}{
+		{"#TRANSFER " + modern.Hex() + " 1.5", modern, "a9059cbb" + word(receiver[2:]) + word("14d1120d7b160000")},
+		{"#APPROVE " + strings.ToLower(legacy.Hex()) + " 1", legacy, "095ea7b3" + word(receiver[2:]) + word("de0b6b3a7640000")},
+		{"#TRANSFER MKR 2", legacy, "a9059cbb" + word(receiver[2:]) + word("1bc16d674ec80000")},
+	}
+	for _, test := range tests {
+		addr, payload, _, err := parser.Parse(test.macro, "", receiver, 0)
+		if err != nil {
+			t.Fatalf("%s: failed to parse: %v", test.macro, err)
+		}
+		if addr != test.contract || payload != test.payload {
+			t.Errorf("%s: invalid parse result, got %s %s", test.macro, addr.Hex(), payload)
+		}
+	}
+	if _, _, _, err := parser.Parse("#TRANSFER "+broken.Hex()+" 1", "", receiver, 0); err == nil {
+		t.Errorf("expect error for invalid decimals")
+	}
+	if _, _, _, err := parser.Parse("#TRANSFER 0x4444444444444444444444444444444444444444 1", "", receiver, 0); err == nil {
+		t.Errorf("expect error for non-token contract")
+	}
+	// The resolved tokens are cached, the on-chain RDN conflicts with the list one
+	registry, err = OpenTokenRegistry(dir, 4, path.Join("test", "rinkebyEthToken.json"))
+	if err != nil {
+		t.Fatalf("failed to reopen registry: %v", err)
+	}
+	token, err := registry.Lookup(legacy.Hex())
+	if err != nil || token.Symbol != "MKR" || token.Name != "Maker" || token.Decimal != 18 || token.source != tokenResolvedFile {
+		t.Fatalf("cached token mismatch, got %v, err %v", token, err)
+	}
+	token, _ = registry.Lookup(modern.Hex())
+	if conflicts := registry.Conflicts(token); len(conflicts) != 1 || conflicts[0].source != "rinkebyEthToken.json" {
+		t.Fatalf("conflicts mismatch, got %v", conflicts)
+	}
+}
+
+func TestDecodeTokenString(t *testing.T) {
+	text := func(s string) []byte { return common.RightPadBytes([]byte(s), 32) }
+	word := func(n int64) []byte { return common.LeftPadBytes(big.NewInt(n).Bytes(), 32) }
+
+	if s, err := decodeTokenString(text("MKR")); err != nil || s != "MKR" {
+		t.Errorf("bytes32 mismatch, got %q, err %v", s, err)
+	}
+	encoded := append(append(word(32), word(5)...), text("hello")...)
+	if s, err := decodeTokenString(encoded); err != nil || s != "hello" {
+		t.Errorf("string mismatch, got %q, err %v", s, err)
+	}
+	for _, invalid := range [][]byte{nil, text("MKR")[:31], append(word(32), word(64)...), append(word(1024), word(5)...)} {
+		if _, err := decodeTokenString(invalid); err == nil {
+			t.Errorf("expect error for %x", invalid)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2016-2017 Hyperchain Corp.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// xlsxSheetReader streams the rows of a worksheet in xlsx file without loading
+// the whole workbook into memory. Only the shared string table is kept in memory.
+type xlsxSheetReader struct {
+	zr      *zip.ReadCloser
+	sheet   io.ReadCloser
+	decoder *xml.Decoder
+	strings []string
+	number  int // number of the last visited row
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+func openXLSXSheet(filename string, sheet string) (*xlsxSheetReader, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File)
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+	target, err := xlsxSheetPath(files, sheet)
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	reader := &xlsxSheetReader{zr: zr}
+	if file, exist := files["xl/sharedStrings.xml"]; exist {
+		if reader.strings, err = readXLSXSharedStrings(file); err != nil {
+			zr.Close()
+			return nil, err
+		}
+	}
+	file, exist := files[target]
+	if !exist {
+		zr.Close()
+		return nil, fmt.Errorf("sheet %s not found", sheet)
+	}
+	if reader.sheet, err = file.Open(); err != nil {
+		zr.Close()
+		return nil, err
+	}
+	reader.decoder = xml.NewDecoder(reader.sheet)
+	return reader, nil
+}
+
+// xlsxSheetPath resolves the path of worksheet with given name in the package.
+func xlsxSheetPath(files map[string]*zip.File, sheet string) (string, error) {
+	var (
+		workbook xlsxWorkbook
+		rels     xlsxRelationships
+	)
+	if err := decodeZipXML(files["xl/workbook.xml"], &workbook); err != nil {
+		return "", err
+	}
+	if err := decodeZipXML(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return "", err
+	}
+	for _, s := range workbook.Sheets {
+		if s.Name != sheet {
+			continue
+		}
+		for _, rel := range rels.Relationships {
+			if rel.ID != s.ID {
+				continue
+			}
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return "", fmt.Errorf("sheet %s not found", sheet)
+}
+
+func decodeZipXML(file *zip.File, v interface{}) error {
+	if file == nil {
+		return errInvalidContent
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// readXLSXSharedStrings reads the shared string table. Rich text runs are
+// concatenated and phonetic hints are ignored.
+func readXLSXSharedStrings(file *zip.File) ([]string, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var (
+		decoder  = xml.NewDecoder(rc)
+		table    []string
+		text     []byte
+		inText   bool
+		phonetic int
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return table, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				text = text[:0]
+			case "rPh":
+				phonetic += 1
+			case "t":
+				inText = phonetic == 0
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				table = append(table, string(text))
+			case "rPh":
+				phonetic -= 1
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				text = append(text, t...)
+			}
+		}
+	}
+}
+
+// next returns the next non-empty row in the sheet with its row number(1 based).
+func (reader *xlsxSheetReader) next() (int, []string, error) {
+	var (
+		row    []string
+		col    int
+		typ    string
+		value  []byte
+		inRow  bool
+		inCell bool
+		inText bool
+	)
+	for {
+		token, err := reader.decoder.Token()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				inRow, row, col = true, nil, 0
+				reader.number += 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "r" {
+						if n, err := strconv.Atoi(attr.Value); err == nil {
+							reader.number = n
+						}
+					}
+				}
+			case "c":
+				inCell, typ, value = true, "", value[:0]
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "r":
+						if idx := xlsxColumnIndex(attr.Value); idx >= 0 {
+							col = idx
+						}
+					case "t":
+						typ = attr.Value
+					}
+				}
+			case "v", "t":
+				inText = inCell
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "row":
+				if inRow && len(row) > 0 {
+					return reader.number, row, nil
+				}
+				inRow = false
+			case "c":
+				for len(row) <= col {
+					row = append(row, "")
+				}
+				row[col] = reader.cellValue(typ, string(value))
+				inCell = false
+				col += 1
+			case "v", "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				value = append(value, t...)
+			}
+		}
+	}
+}
+
+// cellValue resolves the raw value of cell with given type.
+func (reader *xlsxSheetReader) cellValue(typ string, value string) string {
+	if typ == "s" {
+		idx, err := strconv.Atoi(value)
+		if err != nil || idx < 0 || idx >= len(reader.strings) {
+			return ""
+		}
+		return reader.strings[idx]
+	}
+	return value
+}
+
+func (reader *xlsxSheetReader) close() error {
+	reader.sheet.Close()
+	return reader.zr.Close()
+}
+
+// xlsxColumnIndex returns the column index(0 based) of given cell reference, e.g. "B2" is 1.
+func xlsxColumnIndex(ref string) int {
+	col := 0
+	for _, c := range strings.ToUpper(ref) {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+	}
+	return col - 1
+}